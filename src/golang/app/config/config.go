@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 const (
@@ -16,7 +17,13 @@ const (
 	RESOURCE_DIR_NAME         = "resources"
 	DEFAULT_REST_SERVER_PORT  = 7000
 	JOBS_FILE                 = "jobs.json"
+	RUNS_FILE                 = "runs.json"
+	BLACKOUTS_FILE            = "blackouts.json"
+	RUN_OUTPUT_DIR_NAME       = "outputs"
+	CALENDAR_DIR_NAME         = "calendars"
 	DEFAULT_MAX_RUNNING_JOBS  = 100
+	// Time given to the running jobs to complete when the job manager shuts down.
+	DEFAULT_SHUTDOWN_DRAIN_TIMEOUT_SECONDS = 30
 )
 
 var AppConfig *Config
@@ -29,6 +36,14 @@ type Config struct {
 	WorkingDirectory string `json:"workingDirectory"`
 	LogLevel         string `json:"logLevel"`
 	MaxRunningJobs   int16  `json:"maxRunningJobs"`
+	// Retention of the job run history. Defaults are used when not set.
+	RunHistoryMaxRunsPerJob int `json:"runHistoryMaxRunsPerJob"`
+	RunHistoryMaxAgeDays    int `json:"runHistoryMaxAgeDays"`
+	// Size limit of each captured output stream (stdout/stderr) of a job run.
+	RunOutputMaxBytes int64 `json:"runOutputMaxBytes"`
+	// Time in seconds given to the running jobs to complete on shutdown before they are killed.
+	// Defaults to DEFAULT_SHUTDOWN_DRAIN_TIMEOUT_SECONDS when not set. Negative value kills them right away.
+	ShutdownDrainTimeoutSeconds int `json:"shutdownDrainTimeoutSeconds"`
 }
 
 func ReadConfig(configFile string) (Config, error) {
@@ -83,3 +98,44 @@ func (config *Config) GetJobResourceFilePath() (jobResourcePath string) {
 	jobResourcePath = filepath.Join(resourceDir, JOBS_FILE)
 	return
 }
+
+func (config *Config) GetMaxRunningJobs() (maxRunningJobs int16) {
+	if config.MaxRunningJobs <= 0 {
+		return DEFAULT_MAX_RUNNING_JOBS
+	}
+	return config.MaxRunningJobs
+}
+
+func (config *Config) GetRunHistoryFilePath() (runHistoryPath string) {
+	resourceDir := config.GetResourceDirectory()
+	runHistoryPath = filepath.Join(resourceDir, RUNS_FILE)
+	return
+}
+
+func (config *Config) GetBlackoutFilePath() (blackoutFilePath string) {
+	resourceDir := config.GetResourceDirectory()
+	blackoutFilePath = filepath.Join(resourceDir, BLACKOUTS_FILE)
+	return
+}
+
+func (config *Config) GetRunOutputDirectory() (runOutputDir string) {
+	resourceDir := config.GetResourceDirectory()
+	runOutputDir = filepath.Join(resourceDir, RUN_OUTPUT_DIR_NAME)
+	return
+}
+
+func (config *Config) GetCalendarDirectory() (calendarDir string) {
+	resourceDir := config.GetResourceDirectory()
+	calendarDir = filepath.Join(resourceDir, CALENDAR_DIR_NAME)
+	return
+}
+
+func (config *Config) GetShutdownDrainTimeout() (drainTimeout time.Duration) {
+	if config.ShutdownDrainTimeoutSeconds < 0 {
+		return 0
+	}
+	if config.ShutdownDrainTimeoutSeconds == 0 {
+		return DEFAULT_SHUTDOWN_DRAIN_TIMEOUT_SECONDS * time.Second
+	}
+	return time.Duration(config.ShutdownDrainTimeoutSeconds) * time.Second
+}
@@ -6,9 +6,11 @@ import (
 )
 
 type AppContext struct {
-	Logger     core.Logger
-	AppConfig  *config.Config
-	JobManager *core.JobManager
+	Logger      core.Logger
+	AppConfig   *config.Config
+	JobManager  *core.JobManager
+	JobRegistry *core.JobRegistry
+	JobStore    core.JobStore
 }
 
 func NewContext(logger core.Logger, appConfig *config.Config) (ctx *AppContext) {
@@ -24,3 +26,9 @@ func (appCtx *AppContext) SetCronManager(jm *core.JobManager) {
 	appCtx.Logger.Infof("Setting the Cron manager object in the application context instance.")
 	appCtx.JobManager = jm
 }
+
+func (appCtx *AppContext) SetJobStore(registry *core.JobRegistry, store core.JobStore) {
+	appCtx.Logger.Infof("Setting the job registry and the job store in the application context instance.")
+	appCtx.JobRegistry = registry
+	appCtx.JobStore = store
+}
@@ -0,0 +1,138 @@
+package blackout
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/shreyasksrao/jobmanager/app/common"
+	"github.com/shreyasksrao/jobmanager/app/context"
+	"github.com/shreyasksrao/jobmanager/lib/core"
+)
+
+// GetAllBlackoutWindows returns all the blackout windows.
+func GetAllBlackoutWindows(ctx *context.AppContext) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		ctx.Logger.Infof("Inside GetAllBlackoutWindows function")
+		common.WriteOkResponse(w, ctx.JobManager.ListBlackoutWindows())
+	}
+}
+
+// GetBlackoutWindowById returns a single blackout window.
+func GetBlackoutWindowById(ctx *context.AppContext) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		logger := ctx.Logger
+		windowId := params.ByName("id")
+		logger.Infof("Inside GetBlackoutWindowById function for the window - %v", windowId)
+		window, found := ctx.JobManager.GetBlackoutWindow(windowId)
+		if !found {
+			errMsg := "Failed to get the blackout window with ID " + windowId + ". Window doesn't exist."
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Not Found", http.StatusNotFound)
+			return
+		}
+		common.WriteOkResponse(w, window)
+	}
+}
+
+// CreateBlackoutWindow adds a blackout window. The ID of the window is generated.
+func CreateBlackoutWindow(ctx *context.AppContext) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		logger := ctx.Logger
+		logger.Infof("Inside CreateBlackoutWindow function")
+		var window core.BlackoutWindow
+		payloadDecoder := json.NewDecoder(r.Body)
+		if err := payloadDecoder.Decode(&window); err != nil {
+			errMsg := "Invalid request. Failed to parse the JSON body. Error : " + err.Error()
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		window.ID = uuid.New().String()
+		logger.Infof("Generated the blackout window UUID - %v", window.ID)
+		if err := ctx.JobManager.SetBlackoutWindow(window); err != nil {
+			errMsg := "Failed to create the blackout window. Error : " + err.Error()
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		common.WriteOkResponse(w, window)
+	}
+}
+
+type updateBlackoutWindow struct {
+	Name     *string              `json:"Name"`
+	Start    *time.Time           `json:"Start"`
+	End      *time.Time           `json:"End"`
+	Selector *map[string]string   `json:"Selector"` // Replaces the whole selector
+	Action   *core.BlackoutAction `json:"Action"`
+}
+
+// apply sets the fields of the window which are present in the update payload.
+func (input *updateBlackoutWindow) apply(window *core.BlackoutWindow) {
+	if input.Name != nil {
+		window.Name = *input.Name
+	}
+	if input.Start != nil {
+		window.Start = *input.Start
+	}
+	if input.End != nil {
+		window.End = *input.End
+	}
+	if input.Selector != nil {
+		window.Selector = *input.Selector
+	}
+	if input.Action != nil {
+		window.Action = *input.Action
+	}
+}
+
+// UpdateBlackoutWindow changes the fields of a blackout window which are present in the payload.
+func UpdateBlackoutWindow(ctx *context.AppContext) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		logger := ctx.Logger
+		windowId := params.ByName("id")
+		logger.Infof("Inside UpdateBlackoutWindow function for the window - %v", windowId)
+		var updateWindowInput updateBlackoutWindow
+		payloadDecoder := json.NewDecoder(r.Body)
+		if err := payloadDecoder.Decode(&updateWindowInput); err != nil {
+			errMsg := "Invalid request. Failed to parse the JSON body. Error : " + err.Error()
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		window, found := ctx.JobManager.GetBlackoutWindow(windowId)
+		if !found {
+			errMsg := "Failed to get the blackout window with ID " + windowId + ". Window doesn't exist."
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Not Found", http.StatusNotFound)
+			return
+		}
+		updateWindowInput.apply(&window)
+		if err := ctx.JobManager.SetBlackoutWindow(window); err != nil {
+			errMsg := "Failed to update the blackout window with ID " + windowId + ". Error : " + err.Error()
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		common.WriteOkResponse(w, window)
+	}
+}
+
+// DeleteBlackoutWindow removes a blackout window. The runs it deferred are dispatched right away.
+func DeleteBlackoutWindow(ctx *context.AppContext) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		logger := ctx.Logger
+		windowId := params.ByName("id")
+		logger.Infof("Inside DeleteBlackoutWindow function for the window - %v", windowId)
+		if err := ctx.JobManager.RemoveBlackoutWindow(windowId); err != nil {
+			errMsg := "Failed to delete the blackout window with ID " + windowId + ". Error : " + err.Error()
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		common.WriteOkResponse(w, map[string]string{"ID": windowId})
+	}
+}
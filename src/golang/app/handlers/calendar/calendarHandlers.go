@@ -0,0 +1,81 @@
+package calendar
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/shreyasksrao/jobmanager/app/common"
+	"github.com/shreyasksrao/jobmanager/app/context"
+)
+
+// Largest iCalendar file which can be uploaded.
+const MAX_CALENDAR_SIZE = 1 << 20
+
+// GetAllCalendars returns the ID, name and number of events of all the uploaded calendars.
+func GetAllCalendars(ctx *context.AppContext) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		ctx.Logger.Infof("Inside GetAllCalendars function")
+		common.WriteOkResponse(w, ctx.JobManager.ListCalendars())
+	}
+}
+
+// GetCalendarById serves the iCalendar file of a calendar as it was uploaded.
+func GetCalendarById(ctx *context.AppContext) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		logger := ctx.Logger
+		calendarId := params.ByName("id")
+		logger.Infof("Inside GetCalendarById function for the calendar - %v", calendarId)
+		data, found := ctx.JobManager.GetCalendarData(calendarId)
+		if !found {
+			errMsg := "Failed to get the calendar with ID " + calendarId + ". Calendar doesn't exist."
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Not Found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write(data)
+	}
+}
+
+// UploadCalendar adds the iCalendar (.ics) file in the request body as the calendar with the given ID, or
+// replaces the existing calendar. The jobs using the calendar are rescheduled.
+func UploadCalendar(ctx *context.AppContext) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		logger := ctx.Logger
+		calendarId := params.ByName("id")
+		logger.Infof("Inside UploadCalendar function for the calendar - %v", calendarId)
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MAX_CALENDAR_SIZE))
+		if err != nil {
+			errMsg := "Invalid request. Failed to read the calendar. Error : " + err.Error()
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		info, err := ctx.JobManager.SetCalendar(calendarId, data)
+		if err != nil {
+			errMsg := "Failed to upload the calendar with ID " + calendarId + ". Error : " + err.Error()
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		common.WriteOkResponse(w, info)
+	}
+}
+
+// DeleteCalendar removes a calendar which is not used by any job.
+func DeleteCalendar(ctx *context.AppContext) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		logger := ctx.Logger
+		calendarId := params.ByName("id")
+		logger.Infof("Inside DeleteCalendar function for the calendar - %v", calendarId)
+		if err := ctx.JobManager.RemoveCalendar(calendarId); err != nil {
+			errMsg := "Failed to delete the calendar with ID " + calendarId + ". Error : " + err.Error()
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		common.WriteOkResponse(w, map[string]string{"ID": calendarId})
+	}
+}
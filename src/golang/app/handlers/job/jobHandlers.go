@@ -2,43 +2,38 @@ package job
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"os"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/julienschmidt/httprouter"
 	"github.com/shreyasksrao/jobmanager/app/common"
 	"github.com/shreyasksrao/jobmanager/app/context"
-	log "github.com/shreyasksrao/jobmanager/app/logger"
 	"github.com/shreyasksrao/jobmanager/lib/core"
 	"github.com/shreyasksrao/jobmanager/lib/jobs"
+	"github.com/shreyasksrao/jobmanager/lib/schedule"
 )
 
-func getAllJobsFromFile(logger core.Logger, filePath string) (commandJobsMap map[string]jobs.CommandJob, err error) {
-	fileContent, err := os.ReadFile(filePath)
-	if err != nil {
-		return
-	}
-	err = json.Unmarshal([]byte(fileContent), &commandJobsMap)
-	if err != nil {
-		errMsg := "Error parsing the JSON file : " + filePath + err.Error()
-		logger.Errorf(errMsg)
-		return
+// validateDependencyGraph checks the dependencies of the jobs in the JobManager after creating or
+// updating the job with the given one.
+func validateDependencyGraph(ctx *context.AppContext, job core.JobV2) (err error) {
+	allJobs := []core.JobV2{job}
+	for _, managedJob := range ctx.JobManager.ListJobs() {
+		if managedJob.GetCommonJobFields().ID != job.GetCommonJobFields().ID {
+			allJobs = append(allJobs, managedJob)
+		}
 	}
-	return
+	return core.ValidateDependencyGraph(allJobs)
 }
 
-func saveJobs(logger core.Logger, filePath string, commandJobsMap map[string]jobs.CommandJob) (err error) {
-	logger.Infof("Saving the Jobs to the JSON file - %v", filePath)
-	indentedJson, err := json.MarshalIndent(commandJobsMap, "", "")
+// encodeJob encodes the job along with its "Type" for the responses.
+func encodeJob(ctx *context.AppContext, job core.JobV2) (data json.RawMessage, err error) {
+	data, err = ctx.JobRegistry.Encode(job)
 	if err != nil {
-		logger.Errorf("Failed to encode command jobs map to a JSON. Error : %v", err.Error())
-		return
-	}
-	err = os.WriteFile(filePath, indentedJson, 0644)
-	if err != nil {
-		logger.Errorf("Failed to save the jobs to the JSON file - %v", filePath)
+		ctx.Logger.Errorf("Failed to encode the job - %v. Error : %v", job.GetCommonJobFields().ID, err)
 	}
 	return
 }
@@ -47,20 +42,17 @@ func GetAllJobs(ctx *context.AppContext) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 		logger := ctx.Logger
 		logger.Infof("Inside GetAllJobs function")
-		commandJobs, err := getAllJobsFromFile(logger, ctx.AppConfig.GetJobResourceFilePath())
-		if os.IsNotExist(err) {
-			logger.Infof("Job file not exist, returning empty map.")
-			emptyMap := map[string]interface{}{}
-			res, _ := json.Marshal(emptyMap)
-			common.WriteOkResponse(w, res)
-			return
-		}
-		if err != nil {
-			common.WriteErrorResponse(w, err.Error(), "Internal Error", http.StatusInternalServerError)
-			return
+		allJobs := make(map[string]json.RawMessage)
+		for _, job := range ctx.JobManager.ListJobs() {
+			data, err := encodeJob(ctx, job)
+			if err != nil {
+				common.WriteErrorResponse(w, err.Error(), "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			allJobs[string(job.GetCommonJobFields().ID)] = data
 		}
 		logger.Infof("Successfully fetched all the Jobs.")
-		common.WriteOkResponse(w, commandJobs)
+		common.WriteOkResponse(w, allJobs)
 	}
 }
 
@@ -69,35 +61,54 @@ func GetJobById(ctx *context.AppContext) httprouter.Handle {
 		logger := ctx.Logger
 		jobId := params.ByName("id")
 		logger.Infof("Inside GetJobById function for job with ID - %v", jobId)
-		commandJobs, err := getAllJobsFromFile(logger, ctx.AppConfig.GetJobResourceFilePath())
-		if os.IsNotExist(err) {
-			errMsg := "Failed to get the job with ID - " + jobId + ". Job file doesn't exist."
+		job, exists := ctx.JobManager.GetJob(core.JobId(jobId))
+		if !exists {
+			errMsg := "Failed to get the job with ID " + jobId + ". Job doesn't exist."
 			logger.Errorf(errMsg)
 			common.WriteErrorResponse(w, errMsg, "Bad Request", http.StatusBadRequest)
 			return
 		}
+		data, err := encodeJob(ctx, job)
 		if err != nil {
-			common.WriteErrorResponse(w, err.Error(), "Internal Error", http.StatusInternalServerError)
+			common.WriteErrorResponse(w, err.Error(), "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
-		commandJob, exists := commandJobs[jobId]
+		common.WriteOkResponse(w, data)
+	}
+}
+
+// GetJobStatus returns the schedule of the job and the number of its running and queued runs.
+func GetJobStatus(ctx *context.AppContext) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		logger := ctx.Logger
+		jobId := params.ByName("id")
+		logger.Infof("Inside GetJobStatus function for job with ID - %v", jobId)
+		status, exists := ctx.JobManager.JobStatus(core.JobId(jobId))
 		if !exists {
-			errMsg := "Failed to get the job with ID " + jobId + ". Job doesn't exist."
+			errMsg := "Failed to get the status of the job with ID " + jobId + ". Job doesn't exist."
 			logger.Errorf(errMsg)
 			common.WriteErrorResponse(w, errMsg, "Bad Request", http.StatusBadRequest)
 			return
 		}
-		common.WriteOkResponse(w, commandJob)
+		common.WriteOkResponse(w, status)
 	}
 }
 
+// CreateJob creates a job of the type given by the "Type" field of the payload. The jobs without a
+// "Type" are command jobs.
 func CreateJob(ctx *context.AppContext) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 		logger := ctx.Logger
 		logger.Infof("Inside CreateJob function")
-		var job jobs.CommandJob
-		payloadDecoder := json.NewDecoder(r.Body)
-		if err := payloadDecoder.Decode(&job); err != nil {
+		payload, err := io.ReadAll(r.Body)
+		if err != nil {
+			errMsg := "Invalid request. Failed to read the body. Error : " + err.Error()
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		job, err := ctx.JobRegistry.Decode(payload)
+		if err != nil {
 			errMsg := "Invalid request. Failed to parse the JSON body. Error : " + err.Error()
 			logger.Errorf(errMsg)
 			common.WriteErrorResponse(w, errMsg, "Bad Request", http.StatusBadRequest)
@@ -105,37 +116,163 @@ func CreateJob(ctx *context.AppContext) httprouter.Handle {
 		}
 		jobId := uuid.New()
 		logger.Infof("Generated the Job UUID - %v", jobId)
-		job.CommonJobFields.ID = core.JobId(jobId.String())
-		job.Logger = log.GetJobRunnerLogger()
-		job.SaveFile = ctx.AppConfig.GetJobResourceFilePath()
-		isValidRequest, err := jobs.ValidatePostPayload(logger, &job)
-		if !isValidRequest {
+		fields := job.GetCommonJobFields()
+		fields.ID = core.JobId(jobId.String())
+		if err = ctx.JobRegistry.Validate(job); err != nil {
 			errMsg := "Validation failed for the request. Error : " + err.Error()
 			logger.Errorf(errMsg)
 			common.WriteErrorResponse(w, err.Error(), "Bad Request", http.StatusBadRequest)
 			return
 		}
-		saved, err := job.Save()
-		if !saved {
+		if len(fields.DependsOn) > 0 {
+			if err = validateDependencyGraph(ctx, job); err != nil {
+				logger.Errorf("Validation failed for the request. Error : %v", err.Error())
+				common.WriteErrorResponse(w, err.Error(), "Bad Request", http.StatusBadRequest)
+				return
+			}
+		}
+		// The calendars of the job are checked by the JobManager along with the insert, so that they
+		// can't be deleted in between.
+		logger.Infof("Adding the job to the cron manager.")
+		jm := ctx.JobManager
+		if err = jm.CreateJob(job); errors.Is(err, core.ErrJobNotSaved) {
 			errMsg := "Error occurred while saving the Job to the file. Error : " + err.Error()
 			logger.Errorf(errMsg)
 			common.WriteErrorResponse(w, errMsg, "Internl Server Error", http.StatusInternalServerError)
 			return
+		} else if err != nil {
+			logger.Errorf("Validation failed for the request. Error : %v", err.Error())
+			common.WriteErrorResponse(w, err.Error(), "Bad Request", http.StatusBadRequest)
+			return
 		}
-		logger.Infof("Successfully svaed the Job.")
-		logger.Infof("Adding the job to the cron manager.")
-		jm := ctx.JobManager
-		jm.AddJob(&job)
 		logger.Infof("Successfully added the job to the cron manager.")
-		common.WriteOkResponse(w, job)
+		if err = ctx.JobStore.Flush(); err != nil {
+			errMsg := "Error occurred while saving the Job to the file. Error : " + err.Error()
+			logger.Errorf(errMsg)
+			jm.RemoveJob(string(fields.ID))
+			ctx.JobStore.DeleteJob(fields.ID)
+			common.WriteErrorResponse(w, errMsg, "Internl Server Error", http.StatusInternalServerError)
+			return
+		}
+		logger.Infof("Successfully svaed the Job.")
+		data, err := encodeJob(ctx, job)
+		if err != nil {
+			common.WriteErrorResponse(w, err.Error(), "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		common.WriteOkResponse(w, data)
 	}
 }
 
 type updateCommandJob struct {
-	Command   *string   `json:"Command"`   // Command to run
-	Args      *[]string `json:"Args"`      // Arguments for the command
-	CronExpr  *string   `json:"CronExpr"`  // Cron expression
-	RunAsUser *string   `json:"RunAsUser"` // Username under which the command will be run
+	CommonJobFields *updateCommonJobFields `json:"CommonJobFields"`
+	Command         *string                `json:"Command"`   // Command to run
+	Args            *[]string              `json:"Args"`      // Arguments for the command
+	CronExpr        *string                `json:"CronExpr"`  // Cron expression. Removes the Schedule of the job
+	RunAsUser       *string                `json:"RunAsUser"` // Username under which the command will be run
+}
+
+type updateCommonJobFields struct {
+	ConcurrencyPolicy *core.ConcurrencyPolicy      `json:"ConcurrencyPolicy"`
+	MaxInstances      *int                         `json:"MaxInstances"`
+	MisfirePolicy     *core.MisfirePolicy          `json:"MisfirePolicy"`
+	MaxCatchUpRuns    *int                         `json:"MaxCatchUpRuns"`
+	Timezone          *string                      `json:"Timezone"`
+	DSTSkippedTime    *schedule.SkippedTimePolicy  `json:"DSTSkippedTime"`
+	DSTRepeatedTime   *schedule.RepeatedTimePolicy `json:"DSTRepeatedTime"`
+	Timeout           *core.Duration               `json:"Timeout"`
+	KillGracePeriod   *core.Duration               `json:"KillGracePeriod"`
+	MaxJitter         *core.Duration               `json:"MaxJitter"`
+	RetryPolicy       *core.RetryPolicy            `json:"RetryPolicy"` // Replaces the whole retry policy
+	DependsOn         *[]core.JobDependency        `json:"DependsOn"`   // Replaces all the dependencies
+	Schedule          *core.JobSchedule            `json:"Schedule"`    // Replaces the schedule. Removes the CronExpr of the job
+	ExpiryAction      *core.ExpiryAction           `json:"ExpiryAction"`
+	StartAt           *time.Time                   `json:"StartAt"`
+	EndAt             *time.Time                   `json:"EndAt"`
+	MaxRuns           *int                         `json:"MaxRuns"`
+	Labels            *map[string]string           `json:"Labels"`    // Replaces all the labels
+	Calendars         *[]core.JobCalendar          `json:"Calendars"` // Replaces all the calendars
+	CalendarRoll      *schedule.RollPolicy         `json:"CalendarRoll"`
+}
+
+// apply sets the fields of the command job which are present in the update payload.
+func (input *updateCommandJob) apply(commandJob *jobs.CommandJob) {
+	if input.Command != nil && *input.Command != "" {
+		commandJob.Command = *input.Command
+	}
+	if input.Args != nil {
+		commandJob.Args = *input.Args
+	}
+	if input.CronExpr != nil && *input.CronExpr != "" {
+		commandJob.CronExpr = *input.CronExpr
+		commandJob.CommonJobFields.Schedule = nil
+	}
+	if input.RunAsUser != nil && *input.RunAsUser != "" {
+		commandJob.RunAsUser = *input.RunAsUser
+	}
+	if updateFields := input.CommonJobFields; updateFields != nil {
+		if updateFields.ConcurrencyPolicy != nil {
+			commandJob.CommonJobFields.ConcurrencyPolicy = *updateFields.ConcurrencyPolicy
+		}
+		if updateFields.MaxInstances != nil {
+			commandJob.CommonJobFields.MaxInstances = *updateFields.MaxInstances
+		}
+		if updateFields.MisfirePolicy != nil {
+			commandJob.CommonJobFields.MisfirePolicy = *updateFields.MisfirePolicy
+		}
+		if updateFields.MaxCatchUpRuns != nil {
+			commandJob.CommonJobFields.MaxCatchUpRuns = *updateFields.MaxCatchUpRuns
+		}
+		if updateFields.Timezone != nil {
+			commandJob.CommonJobFields.Timezone = *updateFields.Timezone
+		}
+		if updateFields.DSTSkippedTime != nil {
+			commandJob.CommonJobFields.DSTSkippedTime = *updateFields.DSTSkippedTime
+		}
+		if updateFields.DSTRepeatedTime != nil {
+			commandJob.CommonJobFields.DSTRepeatedTime = *updateFields.DSTRepeatedTime
+		}
+		if updateFields.Timeout != nil {
+			commandJob.CommonJobFields.Timeout = *updateFields.Timeout
+		}
+		if updateFields.KillGracePeriod != nil {
+			commandJob.CommonJobFields.KillGracePeriod = *updateFields.KillGracePeriod
+		}
+		if updateFields.MaxJitter != nil {
+			commandJob.CommonJobFields.MaxJitter = *updateFields.MaxJitter
+		}
+		if updateFields.RetryPolicy != nil {
+			commandJob.CommonJobFields.RetryPolicy = updateFields.RetryPolicy
+		}
+		if updateFields.DependsOn != nil {
+			commandJob.CommonJobFields.DependsOn = *updateFields.DependsOn
+		}
+		if updateFields.Schedule != nil {
+			commandJob.CommonJobFields.Schedule = updateFields.Schedule
+			commandJob.CronExpr = ""
+		}
+		if updateFields.ExpiryAction != nil {
+			commandJob.CommonJobFields.ExpiryAction = *updateFields.ExpiryAction
+		}
+		if updateFields.StartAt != nil {
+			commandJob.CommonJobFields.StartAt = updateFields.StartAt
+		}
+		if updateFields.EndAt != nil {
+			commandJob.CommonJobFields.EndAt = updateFields.EndAt
+		}
+		if updateFields.MaxRuns != nil {
+			commandJob.CommonJobFields.MaxRuns = *updateFields.MaxRuns
+		}
+		if updateFields.Labels != nil {
+			commandJob.CommonJobFields.Labels = *updateFields.Labels
+		}
+		if updateFields.Calendars != nil {
+			commandJob.CommonJobFields.Calendars = *updateFields.Calendars
+		}
+		if updateFields.CalendarRoll != nil {
+			commandJob.CommonJobFields.CalendarRoll = *updateFields.CalendarRoll
+		}
+	}
 }
 
 func UpdateJob(ctx *context.AppContext) httprouter.Handle {
@@ -151,51 +288,38 @@ func UpdateJob(ctx *context.AppContext) httprouter.Handle {
 			common.WriteErrorResponse(w, errMsg, "Bad Request", http.StatusBadRequest)
 			return
 		}
-		commandJobs, err := getAllJobsFromFile(logger, ctx.AppConfig.GetJobResourceFilePath())
+		// The job is changed and validated by the JobManager in one step, so that no run is dropped or
+		// repeated while the job is being updated.
+		updatedJob, err := ctx.JobManager.UpdateJob(core.JobId(jobId), func(job core.JobV2) (err error) {
+			commandJob, ok := job.(*jobs.CommandJob)
+			if !ok {
+				return fmt.Errorf("job with ID - %v is not a command job", jobId)
+			}
+			updateJobInput.apply(commandJob)
+			if isValidRequest, err := jobs.ValidatePostPayload(logger, commandJob); !isValidRequest {
+				return fmt.Errorf("validation failed for the request. Error : %v", err)
+			}
+			return ctx.JobManager.ValidateJobCalendars(&commandJob.CommonJobFields)
+		})
 		if err != nil {
-			common.WriteErrorResponse(w, err.Error(), "Internal Error", http.StatusInternalServerError)
-			return
-		}
-		commandJob, exists := commandJobs[jobId]
-		if !exists {
-			errMsg := "Failed to get the job with ID " + jobId + ". Job doesn't exist."
+			errMsg := "Failed to update the job with ID " + jobId + ". Error : " + err.Error()
 			logger.Errorf(errMsg)
 			common.WriteErrorResponse(w, errMsg, "Bad Request", http.StatusBadRequest)
 			return
 		}
-		if updateJobInput.Command != nil && *updateJobInput.Command != "" {
-			commandJob.Command = *updateJobInput.Command
-		}
-		if *updateJobInput.Args != nil {
-			commandJob.Args = *updateJobInput.Args
-		}
-		if updateJobInput.CronExpr != nil && *updateJobInput.CronExpr != "" {
-			commandJob.CronExpr = *updateJobInput.CronExpr
-		}
-		if updateJobInput.RunAsUser != nil && *updateJobInput.RunAsUser != "" {
-			commandJob.RunAsUser = *updateJobInput.RunAsUser
-		}
-		commandJob.Logger = log.GetJobRunnerLogger()
-		commandJob.SaveFile = ctx.AppConfig.GetJobResourceFilePath()
-		commandJob.Save()
-		jm := ctx.JobManager
-		jm.RemoveJob(jobId)
-		commandJobs, err = getAllJobsFromFile(logger, ctx.AppConfig.GetJobResourceFilePath())
-		if err != nil {
-			common.WriteErrorResponse(w, err.Error(), "Internal Error", http.StatusInternalServerError)
+		logger.Infof("Successfully updated the job in the cron manager.")
+		if err = ctx.JobStore.Flush(); err != nil {
+			errMsg := "Error occurred while saving the Job to the file. Error : " + err.Error()
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
-		updatedJob, exists := commandJobs[jobId]
-		if !exists {
-			err = fmt.Errorf("failed to get the job with ID - %v. Job doesn't exist", jobId)
-			common.WriteErrorResponse(w, err.Error(), "Internal Error", http.StatusInternalServerError)
+		data, err := encodeJob(ctx, updatedJob)
+		if err != nil {
+			common.WriteErrorResponse(w, err.Error(), "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
-		updatedJob.Logger = log.GetJobRunnerLogger()
-		updatedJob.SaveFile = ctx.AppConfig.GetJobResourceFilePath()
-		jm.AddJob(&updatedJob)
-		logger.Infof("Successfully updated the job in the cron manager.")
-		common.WriteOkResponse(w, updatedJob)
+		common.WriteOkResponse(w, data)
 	}
 }
 
@@ -205,20 +329,25 @@ func DeleteJob(ctx *context.AppContext) httprouter.Handle {
 		jobId := params.ByName("id")
 		jobFilePath := ctx.AppConfig.GetJobResourceFilePath()
 		logger.Infof("Inside DeleteJob function for the job - %v", jobId)
-		commandJobs, err := getAllJobsFromFile(logger, jobFilePath)
-		if err != nil {
-			common.WriteErrorResponse(w, err.Error(), "Internal Error", http.StatusInternalServerError)
-			return
-		}
-		_, exists := commandJobs[jobId]
-		if !exists {
+		if _, exists := ctx.JobManager.GetJob(core.JobId(jobId)); !exists {
 			errMsg := "Failed to get the job with ID " + jobId + ". Job doesn't exist."
 			logger.Errorf(errMsg)
 			common.WriteErrorResponse(w, errMsg, "Bad Request", http.StatusBadRequest)
 			return
 		}
-		delete(commandJobs, jobId)
-		err = saveJobs(logger, jobFilePath, commandJobs)
+		for _, job := range ctx.JobManager.ListJobs() {
+			if fields := job.GetCommonJobFields(); fields.DependsOnJob(core.JobId(jobId)) {
+				errMsg := "Failed to delete the job with ID " + jobId + ". Job - " + string(fields.ID) + " depends on it."
+				logger.Errorf(errMsg)
+				common.WriteErrorResponse(w, errMsg, "Bad Request", http.StatusBadRequest)
+				return
+			}
+		}
+		ctx.JobManager.RemoveJob(jobId)
+		err := ctx.JobStore.DeleteJob(core.JobId(jobId))
+		if err == nil {
+			err = ctx.JobStore.Flush()
+		}
 		if err != nil {
 			errMsg := "Failed to save the Jobs to the JSON file '" + jobFilePath + "'." + "Error : " + err.Error()
 			common.WriteErrorResponse(w, errMsg, "Internal Server Error", http.StatusInternalServerError)
@@ -229,3 +358,91 @@ func DeleteJob(ctx *context.AppContext) httprouter.Handle {
 		common.WriteOkResponse(w, statusMsg)
 	}
 }
+
+// GetSkippedJobRuns returns the recent runs of the job which were skipped as per its concurrency policy.
+func GetSkippedJobRuns(ctx *context.AppContext) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		logger := ctx.Logger
+		jobId := params.ByName("id")
+		logger.Infof("Inside GetSkippedJobRuns function for the job - %v", jobId)
+		skippedJobRuns := ctx.JobManager.SkippedJobRuns(core.JobId(jobId))
+		common.WriteOkResponse(w, skippedJobRuns)
+	}
+}
+
+// PauseJob stops the job from being scheduled until it is resumed.
+func PauseJob(ctx *context.AppContext) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		logger := ctx.Logger
+		jobId := params.ByName("id")
+		logger.Infof("Inside PauseJob function for the job - %v", jobId)
+		err := ctx.JobManager.PauseJob(core.JobId(jobId))
+		if err != nil {
+			errMsg := "Failed to pause the job with ID " + jobId + ". Error : " + err.Error()
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		statusMsg := "Successfully paused the job - " + jobId + "."
+		logger.Infof(statusMsg)
+		common.WriteOkResponse(w, statusMsg)
+	}
+}
+
+// ResumeJob resumes a paused job. The next run is recomputed as per the job's misfire policy.
+func ResumeJob(ctx *context.AppContext) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		logger := ctx.Logger
+		jobId := params.ByName("id")
+		logger.Infof("Inside ResumeJob function for the job - %v", jobId)
+		err := ctx.JobManager.ResumeJob(core.JobId(jobId))
+		if err != nil {
+			errMsg := "Failed to resume the job with ID " + jobId + ". Error : " + err.Error()
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		statusMsg := "Successfully resumed the job - " + jobId + "."
+		logger.Infof(statusMsg)
+		common.WriteOkResponse(w, statusMsg)
+	}
+}
+
+// RunJobNow triggers a run of the job immediately and returns the run ID. With "?wait=true"
+// the request blocks until the last attempt of the run (as per the job's RetryPolicy) completes and
+// returns the result of that attempt.
+func RunJobNow(ctx *context.AppContext) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		logger := ctx.Logger
+		jobId := params.ByName("id")
+		wait := r.URL.Query().Get("wait") == "true"
+		logger.Infof("Inside RunJobNow function for the job - %v, wait - %v", jobId, wait)
+		jobRun, err := ctx.JobManager.RunNow(core.JobId(jobId))
+		if err != nil {
+			errMsg := "Failed to run the job with ID " + jobId + ". Error : " + err.Error()
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		if !wait {
+			common.WriteOkResponse(w, map[string]string{"RunID": jobRun.ID})
+			return
+		}
+		lastAttempt, err := jobRun.LastAttempt(r.Context())
+		if err != nil {
+			logger.Warnf("Client went away while waiting for the run - %v of the job - %v", jobRun.ID, jobId)
+			return
+		}
+		logger.Infof("Manual run - %v of the job - %v completed. Last attempt - %v", jobRun.ID, jobId, lastAttempt.ID)
+		common.WriteOkResponse(w, lastAttempt.Record())
+	}
+}
+
+// GetJobGraph returns the dependency graph of all the jobs.
+func GetJobGraph(ctx *context.AppContext) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		logger := ctx.Logger
+		logger.Infof("Inside GetJobGraph function")
+		common.WriteOkResponse(w, core.BuildDependencyGraph(ctx.JobManager.ListJobs()))
+	}
+}
@@ -0,0 +1,92 @@
+package run
+
+import (
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/shreyasksrao/jobmanager/app/common"
+	"github.com/shreyasksrao/jobmanager/app/context"
+	"github.com/shreyasksrao/jobmanager/lib/core"
+)
+
+// GetJobRuns returns the run history of the job, latest run first.
+func GetJobRuns(ctx *context.AppContext) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		logger := ctx.Logger
+		jobId := params.ByName("id")
+		logger.Infof("Inside GetJobRuns function for the job - %v", jobId)
+		records, err := ctx.JobManager.GetJobRuns(core.JobId(jobId))
+		if err != nil {
+			errMsg := "Failed to get the runs of the job with ID " + jobId + ". Error : " + err.Error()
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Internal Error", http.StatusInternalServerError)
+			return
+		}
+		common.WriteOkResponse(w, records)
+	}
+}
+
+// GetRunById returns the record of a single job run.
+func GetRunById(ctx *context.AppContext) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		logger := ctx.Logger
+		runId := params.ByName("runId")
+		logger.Infof("Inside GetRunById function for the run - %v", runId)
+		record, found, err := ctx.JobManager.GetRun(runId)
+		if err != nil {
+			errMsg := "Failed to get the run with ID " + runId + ". Error : " + err.Error()
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Internal Error", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			errMsg := "Failed to get the run with ID " + runId + ". Run doesn't exist."
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Not Found", http.StatusNotFound)
+			return
+		}
+		common.WriteOkResponse(w, record)
+	}
+}
+
+// GetRunOutput serves the captured output of a job run. The stream is selected with
+// "?stream=stdout|stderr" (default stdout). HTTP Range requests are supported.
+func GetRunOutput(ctx *context.AppContext) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		logger := ctx.Logger
+		runId := params.ByName("runId")
+		stream := r.URL.Query().Get("stream")
+		if stream == "" {
+			stream = core.OUTPUT_STREAM_STDOUT
+		}
+		logger.Infof("Inside GetRunOutput function for the run - %v, stream - %v", runId, stream)
+		filePath, err := ctx.JobManager.GetRunOutputFilePath(runId, stream)
+		if err != nil {
+			errMsg := "Failed to get the output of the run with ID " + runId + ". Error : " + err.Error()
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		outputFile, err := os.Open(filePath)
+		if errors.Is(err, os.ErrNotExist) {
+			errMsg := "Failed to get the output of the run with ID " + runId + ". Output doesn't exist."
+			logger.Errorf(errMsg)
+			common.WriteErrorResponse(w, errMsg, "Not Found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			common.WriteErrorResponse(w, err.Error(), "Internal Error", http.StatusInternalServerError)
+			return
+		}
+		defer outputFile.Close()
+		fileInfo, err := outputFile.Stat()
+		if err != nil {
+			common.WriteErrorResponse(w, err.Error(), "Internal Error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		http.ServeContent(w, r, fileInfo.Name(), fileInfo.ModTime(), outputFile)
+	}
+}
@@ -0,0 +1,19 @@
+package runner
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/shreyasksrao/jobmanager/app/common"
+	"github.com/shreyasksrao/jobmanager/app/context"
+)
+
+// GetRunnerStats returns the running job count, queue depth and queue wait times of the job runner.
+func GetRunnerStats(ctx *context.AppContext) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		logger := ctx.Logger
+		logger.Infof("Inside GetRunnerStats function")
+		stats := ctx.JobManager.RunnerStats()
+		common.WriteOkResponse(w, stats)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/shreyasksrao/jobmanager/app/rest"
 	"github.com/shreyasksrao/jobmanager/lib/core"
 	"github.com/shreyasksrao/jobmanager/lib/jobs"
+	"github.com/shreyasksrao/jobmanager/lib/utils"
 )
 
 func main() {
@@ -52,32 +53,81 @@ func main() {
 	// Server initializing
 	appLogger.Infof("Starting the application - JOB MANAGER")
 
+	// Load the run history of the jobs.
+	runHistory, err := core.NewFileRunHistory(
+		logger.GetJobRunnerLogger(),
+		appConfig.GetRunHistoryFilePath(),
+		core.RunRetention{
+			MaxRunsPerJob: appConfig.RunHistoryMaxRunsPerJob,
+			MaxAge:        time.Duration(appConfig.RunHistoryMaxAgeDays) * 24 * time.Hour,
+		},
+	)
+	if err != nil {
+		appLogger.Errorf("Failed to load the run history. Error - %v", err)
+		return
+	}
+	// Output of the job runs is captured in the run output directory.
+	_, err = utils.CreateDirIfNotExist(appLogger, appConfig.GetRunOutputDirectory())
+	if err != nil {
+		appLogger.Errorf("Failed to create the run output directory. Error - %v", err)
+		return
+	}
+	runOutput := core.NewRunOutput(logger.GetJobRunnerLogger(), appConfig.GetRunOutputDirectory(), appConfig.RunOutputMaxBytes)
+	runHistory.OnRemove = func(record core.RunRecord) {
+		runOutput.Remove(record.RunID)
+	}
+	// Load the blackout windows.
+	blackoutStore, err := core.NewFileBlackoutStore(logger.GetJobManagerLogger(), appConfig.GetBlackoutFilePath())
+	if err != nil {
+		appLogger.Errorf("Failed to load the blackout windows. Error - %v", err)
+		return
+	}
+	// Calendars attached to the jobs are kept in the calendar directory.
+	calendarStore, err := core.NewFileCalendarStore(logger.GetJobManagerLogger(), appConfig.GetCalendarDirectory())
+	if err != nil {
+		appLogger.Errorf("Failed to create the calendar store. Error - %v", err)
+		return
+	}
+
 	// Create the new instance of CronManager and start the Cron scheduler.
 	jmConfig := core.JobManagerConfig{
 		Location:            time.Local,
 		JobManagerLogger:    logger.GetJobManagerLogger(),
 		JobRunnerLogger:     logger.GetJobRunnerLogger(),
-		MaxRunningJobsCount: 100,
+		MaxRunningJobsCount: appConfig.GetMaxRunningJobs(),
+		RunHistory:          runHistory,
+		RunOutput:           runOutput,
+		BlackoutStore:       blackoutStore,
+		CalendarStore:       calendarStore,
 	}
 	manager := core.NewJobManager(&jmConfig)
 	manager.Start()
-	defer manager.Stop()
+
+	// Job types which can be persisted and created through the REST API. The jobs saved without a type
+	// are command jobs.
+	jobRegistry := core.NewJobRegistry(jobs.COMMAND_JOB_TYPE)
+	jobStore := core.NewFileJobStore(logger.GetJobManagerLogger(), appConfig.GetJobResourceFilePath(), jobRegistry)
+	err = jobRegistry.Register(jobs.NewCommandJobType(logger.GetJobRunnerLogger(), jobStore))
+	if err != nil {
+		appLogger.Errorf("Failed to register the command job type. Error - %v", err)
+		return
+	}
 
 	// Load the existing Jobs from the jobs.json file.
 	appLogger.Infof("Getting the existing jobs from the resource file - %v", appConfig.GetJobResourceFilePath())
-	commandJobs, err := jobs.LoadCommandJobsFromJsonFile(
-		appLogger,
-		appConfig.GetJobResourceFilePath(),
-		logger.GetJobRunnerLogger(),
-	)
-	for _, commandJob := range commandJobs {
-		appLogger.Infof("Adding the Job - %v to the Job manager.", commandJob.CommonJobFields.ID)
-		manager.AddJob(commandJob)
+	savedJobs, err := jobStore.LoadJobs()
+	if err != nil {
+		appLogger.Errorf("Failed to load the jobs. Error - %v", err)
+	}
+	for _, savedJob := range savedJobs {
+		appLogger.Infof("Adding the Job - %v to the Job manager.", savedJob.GetCommonJobFields().ID)
+		manager.AddJobV2(savedJob)
 		time.Sleep(2 * time.Second)
 	}
 
 	ctx := appContext.NewContext(appLogger, &appConfig)
 	ctx.SetCronManager(manager)
+	ctx.SetJobStore(jobRegistry, jobStore)
 
 	server := rest.CreateRestServer(ctx, *restServerPort)
 	go func() {
@@ -108,4 +158,17 @@ func main() {
 	if err := server.Shutdown(timeoutCtx); err != nil {
 		appLogger.Errorf("Server forced to shutdown : %v", err)
 	}
+	// Let the running jobs complete before stopping the job manager.
+	drainTimeout := appConfig.GetShutdownDrainTimeout()
+	appLogger.Infof("Stopping the job manager. Running jobs are given %v to complete.", drainTimeout)
+	if err := manager.Shutdown(drainTimeout); err != nil {
+		appLogger.Errorf("Failed to stop the job manager gracefully. Error - %v", err)
+	}
+	// Write the jobs saved during the shutdown and the records of the last runs.
+	if err := jobStore.Close(); err != nil {
+		appLogger.Errorf("Failed to save the jobs. Error - %v", err)
+	}
+	if err := runHistory.Close(); err != nil {
+		appLogger.Errorf("Failed to save the run history. Error - %v", err)
+	}
 }
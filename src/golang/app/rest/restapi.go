@@ -6,7 +6,11 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/shreyasksrao/jobmanager/app/context"
+	"github.com/shreyasksrao/jobmanager/app/handlers/blackout"
+	"github.com/shreyasksrao/jobmanager/app/handlers/calendar"
 	"github.com/shreyasksrao/jobmanager/app/handlers/job"
+	"github.com/shreyasksrao/jobmanager/app/handlers/run"
+	"github.com/shreyasksrao/jobmanager/app/handlers/runner"
 )
 
 const (
@@ -42,5 +46,24 @@ func registerRoutes(ctx *context.AppContext) (router *httprouter.Router) {
 	router.POST(API_PREFIX+"/job", job.CreateJob(ctx))
 	router.PATCH(API_PREFIX+"/job/:id", job.UpdateJob(ctx))
 	router.DELETE(API_PREFIX+"/job/:id", job.DeleteJob(ctx))
+	router.GET(API_PREFIX+"/job/:id/status", job.GetJobStatus(ctx))
+	router.GET(API_PREFIX+"/job/:id/skipped", job.GetSkippedJobRuns(ctx))
+	router.POST(API_PREFIX+"/job/:id/pause", job.PauseJob(ctx))
+	router.POST(API_PREFIX+"/job/:id/resume", job.ResumeJob(ctx))
+	router.POST(API_PREFIX+"/job/:id/run", job.RunJobNow(ctx))
+	router.GET(API_PREFIX+"/job/:id/runs", run.GetJobRuns(ctx))
+	router.GET(API_PREFIX+"/runs/:runId", run.GetRunById(ctx))
+	router.GET(API_PREFIX+"/runs/:runId/output", run.GetRunOutput(ctx))
+	router.GET(API_PREFIX+"/runner/stats", runner.GetRunnerStats(ctx))
+	router.GET(API_PREFIX+"/graph", job.GetJobGraph(ctx))
+	router.GET(API_PREFIX+"/blackout", blackout.GetAllBlackoutWindows(ctx))
+	router.GET(API_PREFIX+"/blackout/:id", blackout.GetBlackoutWindowById(ctx))
+	router.POST(API_PREFIX+"/blackout", blackout.CreateBlackoutWindow(ctx))
+	router.PATCH(API_PREFIX+"/blackout/:id", blackout.UpdateBlackoutWindow(ctx))
+	router.DELETE(API_PREFIX+"/blackout/:id", blackout.DeleteBlackoutWindow(ctx))
+	router.GET(API_PREFIX+"/calendar", calendar.GetAllCalendars(ctx))
+	router.GET(API_PREFIX+"/calendar/:id", calendar.GetCalendarById(ctx))
+	router.PUT(API_PREFIX+"/calendar/:id", calendar.UploadCalendar(ctx))
+	router.DELETE(API_PREFIX+"/calendar/:id", calendar.DeleteCalendar(ctx))
 	return
 }
@@ -8,7 +8,4 @@ require (
 	go.uber.org/zap v1.27.0
 )
 
-require (
-	github.com/robfig/cron/v3 v3.0.1
-	go.uber.org/multierr v1.11.0 // indirect
-)
+require go.uber.org/multierr v1.11.0 // indirect
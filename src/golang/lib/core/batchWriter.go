@@ -0,0 +1,82 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// Time for which the changes of the file backed stores are collected before the file is written.
+const DEFAULT_BATCH_WRITE_DELAY = 200 * time.Millisecond
+
+// batchWriter writes the changes of a file backed store from a background go-routine, so that the callers
+// don't wait for the file to be written. The changes requested within the delay of each other are written
+// with one call of the write function.
+type batchWriter struct {
+	delay   time.Duration
+	write   func() (err error)
+	pending chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	stopped sync.Once
+	// writeMu serializes the writes of the background go-routine and Flush().
+	writeMu sync.Mutex
+}
+
+func newBatchWriter(delay time.Duration, write func() (err error)) (writer *batchWriter) {
+	writer = &batchWriter{
+		delay:   delay,
+		write:   write,
+		pending: make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go writer.run()
+	return
+}
+
+// schedule asks for a write of the changes. It doesn't block.
+func (writer *batchWriter) schedule() {
+	select {
+	case writer.pending <- struct{}{}:
+	default:
+	}
+}
+
+func (writer *batchWriter) run() {
+	defer close(writer.done)
+	for {
+		select {
+		case <-writer.pending:
+		case <-writer.stop:
+			return
+		}
+		timer := time.NewTimer(writer.delay)
+		select {
+		case <-timer.C:
+		case <-writer.stop:
+			timer.Stop()
+			return
+		}
+		// Errors are logged by the write function.
+		writer.flush()
+	}
+}
+
+// flush writes the changes now.
+func (writer *batchWriter) flush() (err error) {
+	writer.writeMu.Lock()
+	defer writer.writeMu.Unlock()
+	// The changes scheduled so far are covered by this write.
+	select {
+	case <-writer.pending:
+	default:
+	}
+	return writer.write()
+}
+
+// close stops the background go-routine and writes the changes.
+func (writer *batchWriter) close() (err error) {
+	writer.stopped.Do(func() { close(writer.stop) })
+	<-writer.done
+	return writer.flush()
+}
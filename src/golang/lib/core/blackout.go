@@ -0,0 +1,279 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// BlackoutAction decides what happens to the runs suppressed by a blackout window.
+type BlackoutAction string
+
+const (
+	// Drop the suppressed runs (default). They are recorded as skipped.
+	BLACKOUT_ACTION_SKIP BlackoutAction = "Skip"
+	// Run the job once when the window ends, in place of all its suppressed runs.
+	BLACKOUT_ACTION_DEFER BlackoutAction = "Defer"
+)
+
+// BlackoutWindow stops the scheduled, catch-up and dependency triggered runs of the matching jobs
+// between Start and End, e.g. during a deploy or a database maintenance. Manual runs are not affected.
+type BlackoutWindow struct {
+	ID    string    `json:"ID"`
+	Name  string    `json:"Name,omitempty"`
+	Start time.Time `json:"Start"`
+	End   time.Time `json:"End"`
+	// Labels a job must have to be suppressed by the window. The window applies to every job if it is empty.
+	Selector map[string]string `json:"Selector,omitempty"`
+	Action   BlackoutAction    `json:"Action,omitempty"` // Skip (default) or Defer
+}
+
+// Covers tells whether "t" falls in the window. The window includes its Start, but not its End.
+func (window *BlackoutWindow) Covers(t time.Time) bool {
+	return !t.Before(window.Start) && t.Before(window.End)
+}
+
+// Matches tells whether the window applies to the job, i.e. the job has all the labels of the Selector.
+func (window *BlackoutWindow) Matches(fields *CommonJobFields) bool {
+	for key, value := range window.Selector {
+		if label, found := fields.Labels[key]; !found || label != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Copy returns a deep copy of the window.
+func (window *BlackoutWindow) Copy() (copied BlackoutWindow) {
+	copied = *window
+	if window.Selector != nil {
+		copied.Selector = make(map[string]string, len(window.Selector))
+		for key, value := range window.Selector {
+			copied.Selector[key] = value
+		}
+	}
+	return
+}
+
+// ValidateBlackoutWindow checks the fields of the window.
+func ValidateBlackoutWindow(window *BlackoutWindow) (err error) {
+	if window.ID == "" {
+		return fmt.Errorf("invalid blackout window. ID is not set")
+	}
+	if window.Start.IsZero() || window.End.IsZero() {
+		return fmt.Errorf("invalid blackout window. Start and End should be set")
+	}
+	if !window.Start.Before(window.End) {
+		return fmt.Errorf("invalid blackout window. Start - %v should be before End - %v", window.Start, window.End)
+	}
+	switch window.Action {
+	case "", BLACKOUT_ACTION_SKIP, BLACKOUT_ACTION_DEFER:
+	default:
+		return fmt.Errorf("invalid blackout Action - %v. Supported values are Skip and Defer", window.Action)
+	}
+	return nil
+}
+
+// BlackoutStore persists the blackout windows of the JobManager.
+type BlackoutStore interface {
+	// GetBlackoutWindows returns the windows loaded when the store was created.
+	GetBlackoutWindows() (windows []BlackoutWindow)
+	// SaveBlackoutWindows replaces all the persisted windows.
+	SaveBlackoutWindows(windows []BlackoutWindow) (err error)
+}
+
+// FileBlackoutStore is a BlackoutStore persisted as a JSON file (resources/blackouts.json).
+type FileBlackoutStore struct {
+	Logger   Logger
+	FilePath string
+	windows  []BlackoutWindow
+	mu       sync.Mutex
+}
+
+// NewFileBlackoutStore creates the FileBlackoutStore and loads the existing windows from the file.
+func NewFileBlackoutStore(logger Logger, filePath string) (store *FileBlackoutStore, err error) {
+	logger.Infof("Creating the blackout window store with the file - %v", filePath)
+	store = &FileBlackoutStore{Logger: logger, FilePath: filePath}
+	fileContent, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		logger.Infof("Blackout window file - %v doesn't exist. Starting without blackout windows.", filePath)
+		return store, nil
+	}
+	if err != nil {
+		logger.Errorf("Error reading the blackout window file - %v. Error - %v", filePath, err)
+		return nil, err
+	}
+	if len(fileContent) > 0 {
+		if err = json.Unmarshal(fileContent, &store.windows); err != nil {
+			logger.Errorf("Error parsing the blackout window file - %v. Error - %v", filePath, err)
+			return nil, err
+		}
+	}
+	logger.Infof("Loaded %v blackout windows from the file - %v", len(store.windows), filePath)
+	return store, nil
+}
+
+func (store *FileBlackoutStore) GetBlackoutWindows() (windows []BlackoutWindow) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return append([]BlackoutWindow(nil), store.windows...)
+}
+
+func (store *FileBlackoutStore) SaveBlackoutWindows(windows []BlackoutWindow) (err error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if windows == nil {
+		windows = make([]BlackoutWindow, 0)
+	}
+	jsonData, err := json.MarshalIndent(windows, "", "  ")
+	if err != nil {
+		store.Logger.Errorf("Error marshaling the blackout windows. Error - %v", err)
+		return err
+	}
+	if err = os.WriteFile(store.FilePath, jsonData, 0644); err != nil {
+		store.Logger.Errorf("Error writing the blackout window file - %v. Error - %v", store.FilePath, err)
+		return fmt.Errorf("failed to write the blackout window file - %v. Error - %v", store.FilePath, err)
+	}
+	store.windows = append([]BlackoutWindow(nil), windows...)
+	return nil
+}
+
+type blackoutRequest struct {
+	window   *BlackoutWindow // Window to add or replace. The window with the removeId is removed if it is nil.
+	removeId string
+	reply    chan error
+}
+
+// ListBlackoutWindows returns copies of all the blackout windows.
+func (manager *JobManager) ListBlackoutWindows() (windows []BlackoutWindow) {
+	manager.blackoutMu.RLock()
+	defer manager.blackoutMu.RUnlock()
+	windows = make([]BlackoutWindow, 0, len(manager.blackouts))
+	for i := range manager.blackouts {
+		windows = append(windows, manager.blackouts[i].Copy())
+	}
+	return
+}
+
+// GetBlackoutWindow returns a copy of the blackout window with the given ID.
+func (manager *JobManager) GetBlackoutWindow(id string) (window BlackoutWindow, found bool) {
+	manager.blackoutMu.RLock()
+	defer manager.blackoutMu.RUnlock()
+	for i := range manager.blackouts {
+		if manager.blackouts[i].ID == id {
+			return manager.blackouts[i].Copy(), true
+		}
+	}
+	return
+}
+
+// SetBlackoutWindow adds the blackout window, or replaces the window with the same ID, and persists
+// the windows in the BlackoutStore.
+func (manager *JobManager) SetBlackoutWindow(window BlackoutWindow) (err error) {
+	manager.Logger.Infof("Setting the blackout window - %v", window.ID)
+	copied := window.Copy()
+	return manager.sendBlackoutRequest(blackoutRequest{window: &copied, reply: make(chan error, 1)})
+}
+
+// RemoveBlackoutWindow removes the blackout window with the given ID. The runs deferred by the window
+// are dispatched right away unless another window still covers them.
+func (manager *JobManager) RemoveBlackoutWindow(id string) (err error) {
+	manager.Logger.Infof("Removing the blackout window - %v", id)
+	return manager.sendBlackoutRequest(blackoutRequest{removeId: id, reply: make(chan error, 1)})
+}
+
+func (manager *JobManager) sendBlackoutRequest(request blackoutRequest) (err error) {
+	sendRequest(manager, manager.blackoutChan, request, func(request blackoutRequest) {
+		manager.Logger.Infof("Job manager is not running, simply updating the blackout windows.")
+		request.reply <- manager.updateBlackouts(request, manager.now(), false)
+	})
+	return <-request.reply
+}
+
+// updateBlackouts applies the change to the blackout windows and persists them. The deferred runs are
+// re-evaluated right away if the scheduler is running, as their window may have changed.
+func (manager *JobManager) updateBlackouts(request blackoutRequest, now time.Time, schedulerRunning bool) (err error) {
+	windows := append([]BlackoutWindow(nil), manager.blackouts...)
+	if request.window != nil {
+		if err = ValidateBlackoutWindow(request.window); err != nil {
+			return err
+		}
+		replaced := false
+		for i := range windows {
+			if windows[i].ID == request.window.ID {
+				windows[i], replaced = *request.window, true
+			}
+		}
+		if !replaced {
+			windows = append(windows, *request.window)
+		}
+	} else {
+		removed := false
+		for i := range windows {
+			if windows[i].ID == request.removeId {
+				windows, removed = append(windows[:i], windows[i+1:]...), true
+				break
+			}
+		}
+		if !removed {
+			return fmt.Errorf("blackout window with ID - %v doesn't exist", request.removeId)
+		}
+	}
+	if manager.blackoutStore != nil {
+		if err = manager.blackoutStore.SaveBlackoutWindows(windows); err != nil {
+			return err
+		}
+	}
+	manager.blackoutMu.Lock()
+	manager.blackouts = windows
+	manager.blackoutMu.Unlock()
+	if !schedulerRunning {
+		return nil
+	}
+	for jobId := range manager.deferredRuns {
+		if job := manager.jobs.get(jobId); job != nil {
+			job.GetCommonJobFields().NextRun = now
+			manager.jobs.fix(jobId)
+		}
+	}
+	return nil
+}
+
+// activeBlackout returns the blackout window suppressing the runs of the job at "now", or nil if the job can
+// run. A Defer window takes precedence over a Skip window, and the one ending last among the Defer windows.
+// Called from the scheduler go-routine.
+func (manager *JobManager) activeBlackout(fields *CommonJobFields, now time.Time) (active *BlackoutWindow) {
+	for i := range manager.blackouts {
+		window := &manager.blackouts[i]
+		if !window.Covers(now) || !window.Matches(fields) {
+			continue
+		}
+		if active == nil || (window.Action == BLACKOUT_ACTION_DEFER &&
+			(active.Action != BLACKOUT_ACTION_DEFER || window.End.After(active.End))) {
+			active = window
+		}
+	}
+	return
+}
+
+// deferRun moves the NextRun of the job to the end of the window if the window defers the runs. The runs of
+// a Once job are always deferred, as skipping them leaves the job with no run. Only the trigger of the first
+// deferred run is kept, as all the deferred runs are replaced by a single run.
+func (manager *JobManager) deferRun(job JobV2, window *BlackoutWindow, trigger JobRunTrigger) (deferred bool) {
+	fields := job.GetCommonJobFields()
+	if window.Action != BLACKOUT_ACTION_DEFER && !fields.Schedule.isType(SCHEDULE_TYPE_ONCE) {
+		return false
+	}
+	if _, found := manager.deferredRuns[fields.ID]; !found {
+		manager.deferredRuns[fields.ID] = trigger
+	}
+	// A later scheduled run is computed when the deferred run is dispatched.
+	fields.NextRun = window.End
+	manager.jobs.fix(fields.ID)
+	job.Save()
+	manager.Logger.Infof("Deferred the %v run of the job - %v to %v. Blackout window - %v", trigger, fields.ID, window.End, window.ID)
+	return true
+}
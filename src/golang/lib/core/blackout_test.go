@@ -0,0 +1,83 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJobManagerBlackoutWindows(t *testing.T) {
+	clock := newFakeClock(mustParseTime(t, "2026-01-01T10:00:00Z"))
+	manager := newTestJobManager(clock, time.UTC)
+	for _, team := range []string{"skipped", "deferred"} {
+		job := &testJobV2{testJob: *newTestJob(t, JobId(team), "0 * * * *")}
+		job.CommonJobFields.Labels = map[string]string{"team": team}
+		manager.AddJobV2(job)
+	}
+	start, end := mustParseTime(t, "2026-01-01T10:30:00Z"), mustParseTime(t, "2026-01-01T12:30:00Z")
+	windows := []BlackoutWindow{
+		{ID: "skip", Start: start, End: end, Selector: map[string]string{"team": "skipped"}},
+		{ID: "defer", Start: start, End: end, Selector: map[string]string{"team": "deferred"}, Action: BLACKOUT_ACTION_DEFER},
+	}
+	for _, window := range windows {
+		if err := manager.SetBlackoutWindow(window); err != nil {
+			t.Fatalf("got the error %v while setting the window - %v", err, window.ID)
+		}
+	}
+	startTestScheduler(t, manager)
+
+	// The runs at 11:00 and 12:00 are skipped or deferred to the end of the window.
+	assertScheduledRuns(t, collectScheduledRuns(t, manager, clock, 3), []string{
+		"2026-01-01T12:30:00Z", "2026-01-01T13:00:00Z", "2026-01-01T13:00:00Z",
+	})
+	skipped := manager.jobRunner.GetSkippedJobRuns("skipped")
+	if len(skipped) != 2 {
+		t.Fatalf("got %v skipped runs %+v, want 2", len(skipped), skipped)
+	}
+
+	// Removing a window dispatches the runs it deferred right away.
+	err := manager.SetBlackoutWindow(BlackoutWindow{
+		ID: "all", Start: mustParseTime(t, "2026-01-01T13:30:00Z"), End: mustParseTime(t, "2026-01-01T18:00:00Z"), Action: BLACKOUT_ACTION_DEFER,
+	})
+	if err != nil {
+		t.Fatalf("got the error %v while adding the window", err)
+	}
+	if fired := clock.fireNextTimer(t); !fired.Equal(mustParseTime(t, "2026-01-01T14:00:00Z")) {
+		t.Fatalf("got the timer at %v, want it at 14:00", fired)
+	}
+	waitForJob(t, manager, "skipped", func(job JobV2, found bool) bool {
+		return found && job.GetCommonJobFields().NextRun.Equal(mustParseTime(t, "2026-01-01T18:00:00Z"))
+	})
+	if err = manager.RemoveBlackoutWindow("all"); err != nil {
+		t.Fatalf("got the error %v while removing the window", err)
+	}
+	assertScheduledRuns(t, collectScheduledRuns(t, manager, clock, 2), []string{"2026-01-01T14:00:00Z", "2026-01-01T14:00:00Z"})
+	if err = manager.RemoveBlackoutWindow("all"); err == nil {
+		t.Fatalf("got no error while removing a window which doesn't exist")
+	}
+}
+
+func TestBlackoutWindow(t *testing.T) {
+	start, end := mustParseTime(t, "2026-01-01T10:00:00Z"), mustParseTime(t, "2026-01-01T11:00:00Z")
+	window := BlackoutWindow{ID: "w", Start: start, End: end, Selector: map[string]string{"env": "prod"}}
+	if !window.Covers(start) || window.Covers(end) || window.Covers(start.Add(-time.Second)) {
+		t.Fatalf("window should cover its Start but not its End")
+	}
+	if window.Matches(&CommonJobFields{}) || window.Matches(&CommonJobFields{Labels: map[string]string{"env": "dev"}}) ||
+		!window.Matches(&CommonJobFields{Labels: map[string]string{"env": "prod", "team": "a"}}) {
+		t.Fatalf("window should match only the jobs with all the selector labels")
+	}
+	if err := ValidateBlackoutWindow(&window); err != nil {
+		t.Fatalf("got the error %v for a valid window", err)
+	}
+	invalid := []BlackoutWindow{
+		{Start: start, End: end},
+		{ID: "w", End: end},
+		{ID: "w", Start: end, End: start},
+		{ID: "w", Start: start, End: end, Action: "Cancel"},
+	}
+	for i, window := range invalid {
+		if err := ValidateBlackoutWindow(&window); err == nil {
+			t.Fatalf("invalid #%v: got no error", i)
+		}
+	}
+}
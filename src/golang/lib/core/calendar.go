@@ -0,0 +1,319 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shreyasksrao/jobmanager/lib/schedule"
+)
+
+// CalendarMode tells how a calendar attached to a job filters its schedule times.
+type CalendarMode string
+
+const (
+	// The job runs only at the times falling in an event of the calendar.
+	CALENDAR_MODE_INCLUDE CalendarMode = "Include"
+	// The job doesn't run at the times falling in an event of the calendar, e.g. the holidays (default).
+	CALENDAR_MODE_EXCLUDE CalendarMode = "Exclude"
+
+	// Extension of the calendar files of the FileCalendarStore.
+	CALENDAR_FILE_EXTENSION = ".ics"
+)
+
+// calendarIdRegex matches the valid calendar IDs. The IDs are used as the file names of the FileCalendarStore.
+var calendarIdRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// JobCalendar attaches an uploaded calendar to a job. The schedule of the job (CronExpr or Schedule) stays the
+// base pattern, and its times are filtered by the calendars. See schedule.CalendarSchedule.
+type JobCalendar struct {
+	ID   string       `json:"ID"`
+	Mode CalendarMode `json:"Mode,omitempty"` // Include or Exclude (default)
+}
+
+// CalendarInfo describes an uploaded calendar.
+type CalendarInfo struct {
+	ID     string `json:"ID"`
+	Name   string `json:"Name,omitempty"` // X-WR-CALNAME of the calendar
+	Events int    `json:"Events"`
+}
+
+// CalendarStore persists the iCalendar data of the calendars of the JobManager.
+type CalendarStore interface {
+	// LoadCalendars returns the iCalendar data of all the calendars keyed by the calendar ID.
+	LoadCalendars() (calendars map[string][]byte, err error)
+	SaveCalendar(id string, data []byte) (err error)
+	DeleteCalendar(id string) (err error)
+}
+
+// FileCalendarStore is a CalendarStore keeping every calendar in a <ID>.ics file of a directory
+// (resources/calendars).
+type FileCalendarStore struct {
+	Logger    Logger
+	Directory string
+}
+
+// NewFileCalendarStore creates the FileCalendarStore and its directory if it doesn't exist.
+func NewFileCalendarStore(logger Logger, directory string) (store *FileCalendarStore, err error) {
+	logger.Infof("Creating the calendar store with the directory - %v", directory)
+	if err = os.MkdirAll(directory, 0755); err != nil {
+		logger.Errorf("Error creating the calendar directory - %v. Error - %v", directory, err)
+		return nil, err
+	}
+	return &FileCalendarStore{Logger: logger, Directory: directory}, nil
+}
+
+func (store *FileCalendarStore) LoadCalendars() (calendars map[string][]byte, err error) {
+	entries, err := os.ReadDir(store.Directory)
+	if err != nil {
+		store.Logger.Errorf("Error reading the calendar directory - %v. Error - %v", store.Directory, err)
+		return nil, err
+	}
+	calendars = make(map[string][]byte)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != CALENDAR_FILE_EXTENSION {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(store.Directory, entry.Name()))
+		if err != nil {
+			store.Logger.Errorf("Error reading the calendar file - %v. Error - %v", entry.Name(), err)
+			return nil, err
+		}
+		calendars[strings.TrimSuffix(entry.Name(), CALENDAR_FILE_EXTENSION)] = data
+	}
+	store.Logger.Infof("Loaded %v calendars from the directory - %v", len(calendars), store.Directory)
+	return calendars, nil
+}
+
+func (store *FileCalendarStore) SaveCalendar(id string, data []byte) (err error) {
+	filePath := filepath.Join(store.Directory, id+CALENDAR_FILE_EXTENSION)
+	if err = os.WriteFile(filePath, data, 0644); err != nil {
+		store.Logger.Errorf("Error writing the calendar file - %v. Error - %v", filePath, err)
+		return fmt.Errorf("failed to write the calendar file - %v. Error - %v", filePath, err)
+	}
+	return nil
+}
+
+func (store *FileCalendarStore) DeleteCalendar(id string) (err error) {
+	filePath := filepath.Join(store.Directory, id+CALENDAR_FILE_EXTENSION)
+	if err = os.Remove(filePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		store.Logger.Errorf("Error deleting the calendar file - %v. Error - %v", filePath, err)
+		return err
+	}
+	return nil
+}
+
+// storedCalendar is an uploaded calendar along with its original iCalendar data.
+type storedCalendar struct {
+	data     []byte
+	calendar *schedule.Calendar
+}
+
+type calendarRequest struct {
+	id     string
+	stored *storedCalendar // Calendar to add or replace. The calendar is removed if it is nil.
+	reply  chan error
+}
+
+// loadCalendars parses the calendars of the store. Invalid calendars are logged and dropped, so the jobs
+// using them are not scheduled.
+func (manager *JobManager) loadCalendars(store CalendarStore) {
+	calendars, err := store.LoadCalendars()
+	if err != nil {
+		manager.Logger.Errorf("Failed to load the calendars. Error - %v", err)
+		return
+	}
+	for id, data := range calendars {
+		calendar, err := schedule.ParseICalendar(data)
+		if err != nil {
+			manager.Logger.Errorf("Failed to parse the calendar - %v. Error - %v", id, err)
+			continue
+		}
+		manager.calendars[id] = &storedCalendar{data: data, calendar: calendar}
+	}
+}
+
+// ListCalendars returns the uploaded calendars, ordered by the calendar ID.
+func (manager *JobManager) ListCalendars() (calendars []CalendarInfo) {
+	manager.calendarMu.RLock()
+	defer manager.calendarMu.RUnlock()
+	calendars = make([]CalendarInfo, 0, len(manager.calendars))
+	for id, stored := range manager.calendars {
+		calendars = append(calendars, CalendarInfo{ID: id, Name: stored.calendar.Name, Events: len(stored.calendar.Events)})
+	}
+	sort.Slice(calendars, func(i, j int) bool { return calendars[i].ID < calendars[j].ID })
+	return
+}
+
+// GetCalendarData returns the iCalendar data of the calendar with the given ID.
+func (manager *JobManager) GetCalendarData(id string) (data []byte, found bool) {
+	manager.calendarMu.RLock()
+	defer manager.calendarMu.RUnlock()
+	if stored, found := manager.calendars[id]; found {
+		return stored.data, true
+	}
+	return nil, false
+}
+
+// SetCalendar parses the iCalendar data and adds the calendar, or replaces the calendar with the same ID.
+// The jobs using the calendar are rescheduled.
+func (manager *JobManager) SetCalendar(id string, data []byte) (info CalendarInfo, err error) {
+	manager.Logger.Infof("Setting the calendar - %v", id)
+	if !calendarIdRegex.MatchString(id) {
+		return info, fmt.Errorf("invalid calendar ID - %v. It should have up to 64 letters, digits, '_', '.' or '-'", id)
+	}
+	calendar, err := schedule.ParseICalendar(data)
+	if err != nil {
+		return info, err
+	}
+	request := calendarRequest{id: id, stored: &storedCalendar{data: data, calendar: calendar}, reply: make(chan error, 1)}
+	if err = manager.sendCalendarRequest(request); err != nil {
+		return info, err
+	}
+	return CalendarInfo{ID: id, Name: calendar.Name, Events: len(calendar.Events)}, nil
+}
+
+// RemoveCalendar removes the calendar with the given ID. A calendar used by a job can not be removed.
+func (manager *JobManager) RemoveCalendar(id string) (err error) {
+	manager.Logger.Infof("Removing the calendar - %v", id)
+	return manager.sendCalendarRequest(calendarRequest{id: id, reply: make(chan error, 1)})
+}
+
+func (manager *JobManager) sendCalendarRequest(request calendarRequest) (err error) {
+	sendRequest(manager, manager.calendarChan, request, func(request calendarRequest) {
+		manager.Logger.Infof("Job manager is not running, simply updating the calendars.")
+		request.reply <- manager.updateCalendars(request, manager.now(), false)
+	})
+	return <-request.reply
+}
+
+// updateCalendars applies the change to the calendars and persists it. The jobs using a changed calendar are
+// rescheduled if the scheduler is running, except the Interval jobs as their next run depends on the previous one.
+func (manager *JobManager) updateCalendars(request calendarRequest, now time.Time, schedulerRunning bool) (err error) {
+	if request.stored == nil {
+		if _, found := manager.calendars[request.id]; !found {
+			return fmt.Errorf("calendar with ID - %v doesn't exist", request.id)
+		}
+		for _, job := range manager.jobs.all() {
+			if fields := job.GetCommonJobFields(); fields.usesCalendar(request.id) {
+				return fmt.Errorf("calendar with ID - %v is used by the job - %v", request.id, fields.ID)
+			}
+		}
+		if manager.calendarStore != nil {
+			if err = manager.calendarStore.DeleteCalendar(request.id); err != nil {
+				return err
+			}
+		}
+		manager.calendarMu.Lock()
+		delete(manager.calendars, request.id)
+		manager.calendarMu.Unlock()
+		return nil
+	}
+	if manager.calendarStore != nil {
+		if err = manager.calendarStore.SaveCalendar(request.id, request.stored.data); err != nil {
+			return err
+		}
+	}
+	manager.calendarMu.Lock()
+	manager.calendars[request.id] = request.stored
+	manager.calendarMu.Unlock()
+	if !schedulerRunning {
+		return nil
+	}
+	for _, job := range manager.jobs.all() {
+		fields := job.GetCommonJobFields()
+		_, deferred := manager.deferredRuns[fields.ID]
+		if !fields.usesCalendar(request.id) || fields.Paused || deferred || fields.Schedule.isType(SCHEDULE_TYPE_INTERVAL) {
+			continue
+		}
+		nextRun, err := manager.getNextScheduleTime(job, now)
+		if err != nil {
+			manager.Logger.Errorf("Failed to compute the next run of the job - %v. Error - %v", fields.ID, err)
+		}
+		fields.NextRun = nextRun
+		manager.jobs.fix(fields.ID)
+		job.Save()
+		manager.Logger.Infof("Rescheduled the job - %v after the change of the calendar - %v. Next run at - %v", fields.ID, request.id, nextRun)
+	}
+	return nil
+}
+
+// ValidateJobCalendars checks that the calendars of the job exist and are attached once.
+func (manager *JobManager) ValidateJobCalendars(fields *CommonJobFields) (err error) {
+	manager.calendarMu.RLock()
+	defer manager.calendarMu.RUnlock()
+	attached := make(map[string]bool)
+	for _, jobCalendar := range fields.Calendars {
+		if _, found := manager.calendars[jobCalendar.ID]; !found {
+			return fmt.Errorf("calendar with ID - %v doesn't exist", jobCalendar.ID)
+		}
+		if attached[jobCalendar.ID] {
+			return fmt.Errorf("calendar with ID - %v is attached more than once", jobCalendar.ID)
+		}
+		attached[jobCalendar.ID] = true
+	}
+	return nil
+}
+
+// usesCalendar tells whether the calendar is attached to the job.
+func (fields *CommonJobFields) usesCalendar(id string) bool {
+	for _, jobCalendar := range fields.Calendars {
+		if jobCalendar.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// jobBaseSchedule is the schedule of a job (its Schedule, or GetNextScheduleTime()) filtered by the calendars
+// of the job. The first error of the job is kept in err.
+type jobBaseSchedule struct {
+	job JobV2
+	err error
+}
+
+func (base *jobBaseSchedule) Next(after time.Time) (next time.Time) {
+	var err error
+	if jobSchedule := base.job.GetCommonJobFields().Schedule; jobSchedule != nil {
+		next, err = jobSchedule.Next(after)
+	} else {
+		next, err = base.job.GetNextScheduleTime(after)
+	}
+	if err != nil && base.err == nil {
+		base.err = err
+	}
+	return
+}
+
+// getCalendarScheduleTime returns the next schedule time of the job after "t" which is allowed by its calendars.
+// The floating events of the calendars (e.g. the holidays) are matched in the time zone of the job.
+func (manager *JobManager) getCalendarScheduleTime(job JobV2, t time.Time) (nextRun time.Time, err error) {
+	fields := job.GetCommonJobFields()
+	location, err := fields.GetLocation(manager.Location)
+	if err != nil {
+		return
+	}
+	base := &jobBaseSchedule{job: job}
+	calendarSchedule := &schedule.CalendarSchedule{Base: base, Roll: fields.CalendarRoll, Location: location}
+	manager.calendarMu.RLock()
+	for _, jobCalendar := range fields.Calendars {
+		stored, found := manager.calendars[jobCalendar.ID]
+		if !found {
+			manager.calendarMu.RUnlock()
+			return time.Time{}, fmt.Errorf("calendar with ID - %v doesn't exist", jobCalendar.ID)
+		}
+		if jobCalendar.Mode == CALENDAR_MODE_INCLUDE {
+			calendarSchedule.Include = append(calendarSchedule.Include, stored.calendar)
+		} else {
+			calendarSchedule.Exclude = append(calendarSchedule.Exclude, stored.calendar)
+		}
+	}
+	manager.calendarMu.RUnlock()
+	nextRun = calendarSchedule.Next(t)
+	return nextRun, base.err
+}
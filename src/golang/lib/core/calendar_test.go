@@ -0,0 +1,108 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+const testHolidayCalendar = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+SUMMARY:Weekend
+DTSTART;VALUE=DATE:20260103
+RRULE:FREQ=WEEKLY;BYDAY=SA,SU
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Christmas
+DTSTART;VALUE=DATE:20261225
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestJobManagerCalendars(t *testing.T) {
+	clock := newFakeClock(mustParseTime(t, "2026-12-24T10:00:00Z"))
+	manager := newTestJobManager(clock, time.UTC)
+	if _, err := manager.SetCalendar("holidays", []byte(testHolidayCalendar)); err != nil {
+		t.Fatalf("got the error %v while setting the calendar", err)
+	}
+	job := &testJobV2{testJob: *newTestJob(t, "business-days", "0 9 * * *")}
+	job.CommonJobFields.Calendars = []JobCalendar{{ID: "holidays", Mode: CALENDAR_MODE_EXCLUDE}}
+	if err := manager.ValidateJobCalendars(&job.CommonJobFields); err != nil {
+		t.Fatalf("got the error %v while validating the calendars", err)
+	}
+	manager.AddJobV2(job)
+	startTestScheduler(t, manager)
+
+	// 25 December 2026 is a Friday.
+	assertScheduledRuns(t, collectScheduledRuns(t, manager, clock, 1), []string{"2026-12-28T09:00:00Z"})
+	// Replacing the calendar reschedules the job.
+	_, err := manager.SetCalendar("holidays", []byte("BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;VALUE=DATE:20261229\nDTEND;VALUE=DATE:20270101\nEND:VEVENT\nEND:VCALENDAR\n"))
+	if err != nil {
+		t.Fatalf("got the error %v while replacing the calendar", err)
+	}
+	waitForJob(t, manager, "business-days", func(job JobV2, found bool) bool {
+		return found && job.GetCommonJobFields().NextRun.Equal(mustParseTime(t, "2027-01-01T09:00:00Z"))
+	})
+	if err = manager.RemoveCalendar("holidays"); err == nil {
+		t.Fatalf("got no error while removing the calendar used by a job")
+	}
+	missing := CommonJobFields{Calendars: []JobCalendar{{ID: "missing"}}}
+	if err = manager.ValidateJobCalendars(&missing); err == nil {
+		t.Fatalf("got no error for a calendar which doesn't exist")
+	}
+	if _, err = manager.SetCalendar("../holidays", []byte(testHolidayCalendar)); err == nil {
+		t.Fatalf("got no error for an invalid calendar ID")
+	}
+}
+
+// unsavableJob is a JobV2 which fails to be saved.
+type unsavableJob struct {
+	testJobV2
+}
+
+func (job *unsavableJob) Save() (saved bool, err error) {
+	return false, fmt.Errorf("disk full")
+}
+
+// TestJobManagerCreateJob checks that a job is created only if its calendars exist and it is saved.
+func TestJobManagerCreateJob(t *testing.T) {
+	manager := newTestJobManager(newFakeClock(mustParseTime(t, "2026-12-24T10:00:00Z")), time.UTC)
+	for _, id := range []string{"holidays", "spare"} {
+		if _, err := manager.SetCalendar(id, []byte(testHolidayCalendar)); err != nil {
+			t.Fatalf("got the error %v while setting the calendar %v", err, id)
+		}
+	}
+	newCalendarJob := func(id JobId, calendarId string) *testJobV2 {
+		job := &testJobV2{testJob: *newTestJob(t, id, "0 9 * * *")}
+		job.CommonJobFields.Calendars = []JobCalendar{{ID: calendarId, Mode: CALENDAR_MODE_EXCLUDE}}
+		return job
+	}
+	assertCreated := func(job JobV2, wantErr bool) {
+		t.Helper()
+		jobId := job.GetCommonJobFields().ID
+		if err := manager.CreateJob(job); (err != nil) != wantErr {
+			t.Fatalf("got the error %v while creating the job %v, want error %v", err, jobId, wantErr)
+		}
+		if _, found := manager.GetJob(jobId); found == wantErr {
+			t.Fatalf("got the job %v found %v, want %v", jobId, found, !wantErr)
+		}
+	}
+
+	assertCreated(newCalendarJob("stopped-missing", "missing"), true)
+	assertCreated(newCalendarJob("stopped-holidays", "holidays"), false)
+	startTestScheduler(t, manager)
+	assertCreated(newCalendarJob("holidays-job", "holidays"), false)
+	if err := manager.RemoveCalendar("spare"); err != nil {
+		t.Fatalf("got the error %v while removing the unused calendar", err)
+	}
+	assertCreated(newCalendarJob("spare-job", "spare"), true)
+
+	unsavable := &unsavableJob{testJobV2: *newCalendarJob("unsavable-job", "holidays")}
+	if err := manager.CreateJob(unsavable); !errors.Is(err, ErrJobNotSaved) {
+		t.Fatalf("got the error %v while creating the unsavable job, want %v", err, ErrJobNotSaved)
+	}
+	if _, found := manager.GetJob("unsavable-job"); found {
+		t.Fatalf("got the unsavable job added to the job manager")
+	}
+}
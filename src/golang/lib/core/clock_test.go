@@ -0,0 +1,80 @@
+package core
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock whose time moves only when a test fires its timers.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock    *fakeClock
+	deadline time.Time
+	c        chan time.Time
+	done     bool
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (clock *fakeClock) Now() time.Time {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	return clock.now
+}
+
+func (clock *fakeClock) NewTimer(d time.Duration) Timer {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	timer := &fakeTimer{clock: clock, deadline: clock.now.Add(d), c: make(chan time.Time, 1)}
+	clock.timers = append(clock.timers, timer)
+	return timer
+}
+
+func (timer *fakeTimer) C() <-chan time.Time {
+	return timer.c
+}
+
+func (timer *fakeTimer) Stop() bool {
+	timer.clock.mu.Lock()
+	defer timer.clock.mu.Unlock()
+	wasActive := !timer.done
+	timer.done = true
+	return wasActive
+}
+
+// fireNextTimer waits for the earliest active timer, moves the clock to its deadline and fires it.
+func (clock *fakeClock) fireNextTimer(t *testing.T) time.Time {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		clock.mu.Lock()
+		var next *fakeTimer
+		for _, timer := range clock.timers {
+			if !timer.done && (next == nil || timer.deadline.Before(next.deadline)) {
+				next = timer
+			}
+		}
+		if next != nil {
+			next.done = true
+			if next.deadline.After(clock.now) {
+				clock.now = next.deadline
+			}
+			now := clock.now
+			clock.mu.Unlock()
+			next.c <- now
+			return now
+		}
+		clock.mu.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("no active timer on the fake clock")
+	return time.Time{}
+}
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DependencyCondition decides which outcome of the upstream job's run triggers the dependent job.
+type DependencyCondition string
+
+const (
+	// Trigger when the upstream run succeeded (default).
+	DEPENDENCY_ON_SUCCESS DependencyCondition = "on_success"
+	// Trigger when the upstream run failed or timed out.
+	DEPENDENCY_ON_FAILURE DependencyCondition = "on_failure"
+	// Trigger when the upstream run completed, irrespective of its result.
+	DEPENDENCY_ON_COMPLETE DependencyCondition = "on_complete"
+)
+
+// JobDependency is an upstream job of a job along with the condition on its runs.
+type JobDependency struct {
+	JobID     JobId               `json:"JobID"`
+	Condition DependencyCondition `json:"Condition,omitempty"` // on_success (default), on_failure or on_complete
+}
+
+// IsMetBy tells whether a completed run with the status satisfies the condition.
+func (condition DependencyCondition) IsMetBy(status RunStatus) bool {
+	switch condition {
+	case DEPENDENCY_ON_FAILURE:
+		return status == RUN_STATUS_FAILED || status == RUN_STATUS_TIMED_OUT
+	case DEPENDENCY_ON_COMPLETE:
+		return status == RUN_STATUS_SUCCEEDED || status == RUN_STATUS_FAILED || status == RUN_STATUS_TIMED_OUT
+	default:
+		return status == RUN_STATUS_SUCCEEDED
+	}
+}
+
+// DependsOnJob tells whether the job has the given upstream job.
+func (fields *CommonJobFields) DependsOnJob(jobId JobId) bool {
+	for _, dependency := range fields.DependsOn {
+		if dependency.JobID == jobId {
+			return true
+		}
+	}
+	return false
+}
+
+// DependencyGraph is the view of the jobs and their dependencies. An edge points from
+// the upstream job to the dependent (downstream) job.
+type DependencyGraph struct {
+	Nodes []JobId          `json:"Nodes"`
+	Edges []DependencyEdge `json:"Edges"`
+}
+
+type DependencyEdge struct {
+	Upstream   JobId               `json:"Upstream"`
+	Downstream JobId               `json:"Downstream"`
+	Condition  DependencyCondition `json:"Condition"`
+}
+
+// BuildDependencyGraph returns the dependency graph of the jobs, sorted by the job IDs.
+func BuildDependencyGraph(jobs []JobV2) (graph DependencyGraph) {
+	graph.Nodes = make([]JobId, 0, len(jobs))
+	graph.Edges = make([]DependencyEdge, 0)
+	for _, job := range jobs {
+		fields := job.GetCommonJobFields()
+		graph.Nodes = append(graph.Nodes, fields.ID)
+		for _, dependency := range fields.DependsOn {
+			condition := dependency.Condition
+			if condition == "" {
+				condition = DEPENDENCY_ON_SUCCESS
+			}
+			graph.Edges = append(graph.Edges, DependencyEdge{Upstream: dependency.JobID, Downstream: fields.ID, Condition: condition})
+		}
+	}
+	sort.Slice(graph.Nodes, func(a, b int) bool { return graph.Nodes[a] < graph.Nodes[b] })
+	sort.Slice(graph.Edges, func(a, b int) bool {
+		if graph.Edges[a].Upstream != graph.Edges[b].Upstream {
+			return graph.Edges[a].Upstream < graph.Edges[b].Upstream
+		}
+		return graph.Edges[a].Downstream < graph.Edges[b].Downstream
+	})
+	return
+}
+
+// ValidateDependencies checks the DependsOn field of the job on its own.
+func ValidateDependencies(fields *CommonJobFields) (err error) {
+	seen := make(map[JobId]bool)
+	for _, dependency := range fields.DependsOn {
+		if dependency.JobID == "" {
+			return fmt.Errorf("invalid DependsOn. JobID of the upstream job is not specified")
+		}
+		if dependency.JobID == fields.ID {
+			return fmt.Errorf("invalid DependsOn. Job - %v can not depend on itself", fields.ID)
+		}
+		if seen[dependency.JobID] {
+			return fmt.Errorf("invalid DependsOn. Upstream job - %v is specified more than once", dependency.JobID)
+		}
+		seen[dependency.JobID] = true
+		switch dependency.Condition {
+		case "", DEPENDENCY_ON_SUCCESS, DEPENDENCY_ON_FAILURE, DEPENDENCY_ON_COMPLETE:
+		default:
+			return fmt.Errorf("invalid DependsOn Condition - %v. Supported values are on_success, on_failure and on_complete", dependency.Condition)
+		}
+	}
+	return nil
+}
+
+// ValidateDependencyGraph checks that all the upstream jobs exist and the dependencies don't form a cycle.
+func ValidateDependencyGraph(jobs []JobV2) (err error) {
+	jobsById := make(map[JobId]JobV2, len(jobs))
+	for _, job := range jobs {
+		jobsById[job.GetCommonJobFields().ID] = job
+	}
+	for _, job := range jobs {
+		fields := job.GetCommonJobFields()
+		for _, dependency := range fields.DependsOn {
+			if _, exists := jobsById[dependency.JobID]; !exists {
+				return fmt.Errorf("invalid DependsOn. Upstream job - %v of the job - %v doesn't exist", dependency.JobID, fields.ID)
+			}
+		}
+	}
+	if cycle := findDependencyCycle(jobsById); len(cycle) > 0 {
+		path := make([]string, 0, len(cycle))
+		for _, jobId := range cycle {
+			path = append(path, string(jobId))
+		}
+		return fmt.Errorf("invalid DependsOn. Dependencies form a cycle - %v", strings.Join(path, " -> "))
+	}
+	return nil
+}
+
+// findDependencyCycle does a depth first search over the dependencies and returns the first cycle
+// found as a path of job IDs which starts and ends with the same job. Nil is returned if there is no cycle.
+func findDependencyCycle(jobsById map[JobId]JobV2) (cycle []JobId) {
+	const (
+		unvisited = iota
+		inProgress
+		visited
+	)
+	state := make(map[JobId]int, len(jobsById))
+	var path []JobId
+	var visit func(jobId JobId) bool
+	visit = func(jobId JobId) bool {
+		state[jobId] = inProgress
+		path = append(path, jobId)
+		for _, dependency := range jobsById[jobId].GetCommonJobFields().DependsOn {
+			switch state[dependency.JobID] {
+			case inProgress:
+				for i, onPath := range path {
+					if onPath == dependency.JobID {
+						cycle = append(append(cycle, path[i:]...), dependency.JobID)
+						return true
+					}
+				}
+			case unvisited:
+				if _, exists := jobsById[dependency.JobID]; exists && visit(dependency.JobID) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[jobId] = visited
+		return false
+	}
+	// Visit the jobs in a fixed order so that the reported cycle is stable.
+	jobIds := make([]JobId, 0, len(jobsById))
+	for jobId := range jobsById {
+		jobIds = append(jobIds, jobId)
+	}
+	sort.Slice(jobIds, func(a, b int) bool { return jobIds[a] < jobIds[b] })
+	for _, jobId := range jobIds {
+		if state[jobId] == unvisited && visit(jobId) {
+			return cycle
+		}
+	}
+	return nil
+}
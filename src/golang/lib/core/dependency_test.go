@@ -0,0 +1,225 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newDependentTestJob(t *testing.T, id JobId, upstreamJobIds ...JobId) JobV2 {
+	job := &testJobV2{testJob: *newTestJob(t, id, "0 0 1 1 *")}
+	for _, upstreamJobId := range upstreamJobIds {
+		job.CommonJobFields.DependsOn = append(job.CommonJobFields.DependsOn, JobDependency{JobID: upstreamJobId})
+	}
+	return job
+}
+
+func TestDependencyConditionIsMetBy(t *testing.T) {
+	statuses := []RunStatus{
+		RUN_STATUS_SUCCEEDED, RUN_STATUS_FAILED, RUN_STATUS_TIMED_OUT, RUN_STATUS_CANCELLED,
+		RUN_STATUS_INTERRUPTED, RUN_STATUS_SKIPPED,
+	}
+	tests := []struct {
+		condition DependencyCondition
+		metBy     []RunStatus
+	}{
+		{condition: "", metBy: []RunStatus{RUN_STATUS_SUCCEEDED}},
+		{condition: DEPENDENCY_ON_SUCCESS, metBy: []RunStatus{RUN_STATUS_SUCCEEDED}},
+		{condition: DEPENDENCY_ON_FAILURE, metBy: []RunStatus{RUN_STATUS_FAILED, RUN_STATUS_TIMED_OUT}},
+		{condition: DEPENDENCY_ON_COMPLETE, metBy: []RunStatus{RUN_STATUS_SUCCEEDED, RUN_STATUS_FAILED, RUN_STATUS_TIMED_OUT}},
+	}
+	for _, test := range tests {
+		for _, status := range statuses {
+			want := false
+			for _, metBy := range test.metBy {
+				want = want || metBy == status
+			}
+			if got := test.condition.IsMetBy(status); got != want {
+				t.Errorf("condition %q with the status %v: got %v, want %v", test.condition, status, got, want)
+			}
+		}
+	}
+}
+
+func TestValidateDependencies(t *testing.T) {
+	tests := []struct {
+		name      string
+		dependsOn []JobDependency
+		wantErr   string
+	}{
+		{name: "no dependencies"},
+		{
+			name:      "valid",
+			dependsOn: []JobDependency{{JobID: "a"}, {JobID: "b", Condition: DEPENDENCY_ON_FAILURE}, {JobID: "c", Condition: DEPENDENCY_ON_COMPLETE}},
+		},
+		{name: "empty JobID", dependsOn: []JobDependency{{JobID: ""}}, wantErr: "JobID of the upstream job is not specified"},
+		{name: "self", dependsOn: []JobDependency{{JobID: "job"}}, wantErr: "can not depend on itself"},
+		{name: "duplicate", dependsOn: []JobDependency{{JobID: "a"}, {JobID: "a", Condition: DEPENDENCY_ON_FAILURE}}, wantErr: "specified more than once"},
+		{name: "unknown condition", dependsOn: []JobDependency{{JobID: "a", Condition: "on_skip"}}, wantErr: "invalid DependsOn Condition - on_skip"},
+	}
+	for _, test := range tests {
+		err := ValidateDependencies(&CommonJobFields{ID: "job", DependsOn: test.dependsOn})
+		if (err == nil) != (test.wantErr == "") || (err != nil && !strings.Contains(err.Error(), test.wantErr)) {
+			t.Errorf("%v: got the error %v, want %q", test.name, err, test.wantErr)
+		}
+	}
+}
+
+func TestValidateDependencyGraph(t *testing.T) {
+	tests := []struct {
+		name    string
+		jobs    map[JobId][]JobId // Upstream jobs of every job
+		wantErr string
+	}{
+		{name: "no dependencies", jobs: map[JobId][]JobId{"a": nil, "b": nil}},
+		{name: "chain", jobs: map[JobId][]JobId{"a": nil, "b": {"a"}, "c": {"b"}}},
+		{name: "diamond", jobs: map[JobId][]JobId{"a": nil, "b": {"a"}, "c": {"a"}, "d": {"b", "c"}}},
+		{name: "missing upstream job", jobs: map[JobId][]JobId{"a": nil, "b": {"a", "x"}}, wantErr: "Upstream job - x of the job - b doesn't exist"},
+		{name: "two jobs cycle", jobs: map[JobId][]JobId{"a": {"b"}, "b": {"a"}}, wantErr: "form a cycle - a -> b -> a"},
+		{name: "three jobs cycle", jobs: map[JobId][]JobId{"a": {"c"}, "b": {"a"}, "c": {"b"}}, wantErr: "form a cycle - a -> c -> b -> a"},
+		{name: "cycle below a job", jobs: map[JobId][]JobId{"a": {"b"}, "b": {"c"}, "c": {"b"}}, wantErr: "form a cycle - b -> c -> b"},
+		{name: "cycle beside a chain", jobs: map[JobId][]JobId{"a": nil, "b": {"a"}, "x": {"y"}, "y": {"z"}, "z": {"x", "b"}}, wantErr: "form a cycle - x -> y -> z -> x"},
+	}
+	for _, test := range tests {
+		var jobs []JobV2
+		for jobId, upstreamJobIds := range test.jobs {
+			jobs = append(jobs, newDependentTestJob(t, jobId, upstreamJobIds...))
+		}
+		err := ValidateDependencyGraph(jobs)
+		if (err == nil) != (test.wantErr == "") || (err != nil && !strings.Contains(err.Error(), test.wantErr)) {
+			t.Errorf("%v: got the error %v, want %q", test.name, err, test.wantErr)
+		}
+	}
+}
+
+func TestBuildDependencyGraph(t *testing.T) {
+	upstream := newDependentTestJob(t, "b")
+	dependent := newDependentTestJob(t, "a")
+	dependent.GetCommonJobFields().DependsOn = []JobDependency{{JobID: "c", Condition: DEPENDENCY_ON_FAILURE}, {JobID: "b"}}
+	graph := BuildDependencyGraph([]JobV2{upstream, dependent})
+	if len(graph.Nodes) != 2 || graph.Nodes[0] != "a" || graph.Nodes[1] != "b" {
+		t.Errorf("got the nodes %v, want [a b]", graph.Nodes)
+	}
+	want := []DependencyEdge{
+		{Upstream: "b", Downstream: "a", Condition: DEPENDENCY_ON_SUCCESS},
+		{Upstream: "c", Downstream: "a", Condition: DEPENDENCY_ON_FAILURE},
+	}
+	if len(graph.Edges) != len(want) || graph.Edges[0] != want[0] || graph.Edges[1] != want[1] {
+		t.Errorf("got the edges %+v, want %+v", graph.Edges, want)
+	}
+}
+
+// finishUpstreamRun completes a run of the upstream job at "completedAt" with the status and hands it to
+// the scheduler. The runs dispatched as a result are returned.
+func finishUpstreamRun(t *testing.T, manager *JobManager, upstreamId JobId, status RunStatus, completedAt time.Time) (upstreamRun *JobRun, dispatched []*JobRun) {
+	t.Helper()
+	upstream := manager.jobs.get(upstreamId)
+	if upstream == nil {
+		t.Fatalf("upstream job %v is missing", upstreamId)
+	}
+	upstreamRun = manager.jobRunner.CreateJobRun(upstream, completedAt, TRIGGER_SCHEDULE)
+	upstreamRun.RanAt, upstreamRun.CompletedAt = completedAt, completedAt
+	switch status {
+	case RUN_STATUS_FAILED:
+		upstreamRun.Err = fmt.Errorf("exit status 1")
+	case RUN_STATUS_TIMED_OUT:
+		upstreamRun.Err, upstreamRun.TimedOut = context.DeadlineExceeded, true
+	case RUN_STATUS_CANCELLED:
+		upstreamRun.Err, upstreamRun.Cancelled = context.Canceled, true
+	}
+	return upstreamRun, handleUpstreamRun(manager, upstreamRun, completedAt)
+}
+
+// handleUpstreamRun passes the finished run to handleFinishedRun and returns the runs dispatched as a result.
+func handleUpstreamRun(manager *JobManager, upstreamRun *JobRun, now time.Time) (dispatched []*JobRun) {
+	manager.handleFinishedRun(upstreamRun, now)
+	for {
+		select {
+		case jobRun := <-manager.jobRunChan:
+			dispatched = append(dispatched, jobRun)
+		default:
+			return
+		}
+	}
+}
+
+// assertDependencyRun checks that only a dependency triggered run of the job was dispatched after the upstream run.
+func assertDependencyRun(t *testing.T, dispatched []*JobRun, jobId JobId, upstreamRun *JobRun) {
+	t.Helper()
+	if len(dispatched) != 1 {
+		t.Fatalf("got %v dispatched runs after the upstream run %v, want one run of %v", len(dispatched), upstreamRun.ID, jobId)
+	}
+	if jobRun := dispatched[0]; jobRun.Fields.ID != jobId || jobRun.Trigger != TRIGGER_DEPENDENCY || jobRun.UpstreamRunID != upstreamRun.ID {
+		t.Fatalf("got the run of %v with the trigger %v and the upstream run %q, want the run of %v triggered by the run %v",
+			jobRun.Fields.ID, jobRun.Trigger, jobRun.UpstreamRunID, jobId, upstreamRun.ID)
+	}
+}
+
+func TestDependentJobConditions(t *testing.T) {
+	now := mustParseTime(t, "2026-03-01T10:00:00Z")
+	tests := []struct {
+		condition     DependencyCondition
+		triggeredWith []RunStatus
+	}{
+		{condition: "", triggeredWith: []RunStatus{RUN_STATUS_SUCCEEDED}},
+		{condition: DEPENDENCY_ON_SUCCESS, triggeredWith: []RunStatus{RUN_STATUS_SUCCEEDED}},
+		{condition: DEPENDENCY_ON_FAILURE, triggeredWith: []RunStatus{RUN_STATUS_FAILED, RUN_STATUS_TIMED_OUT}},
+		{condition: DEPENDENCY_ON_COMPLETE, triggeredWith: []RunStatus{RUN_STATUS_SUCCEEDED, RUN_STATUS_FAILED, RUN_STATUS_TIMED_OUT}},
+	}
+	for _, test := range tests {
+		for _, status := range []RunStatus{RUN_STATUS_SUCCEEDED, RUN_STATUS_FAILED, RUN_STATUS_TIMED_OUT, RUN_STATUS_CANCELLED} {
+			t.Run(fmt.Sprintf("%v/%v", test.condition, status), func(t *testing.T) {
+				manager := newTestJobManager(newFakeClock(now), time.UTC)
+				manager.AddJobV2(&testJobV2{testJob: *newTestJob(t, "upstream", "0 0 1 1 *")})
+				downstream := newDependentTestJob(t, "downstream", "upstream")
+				downstream.GetCommonJobFields().DependsOn[0].Condition = test.condition
+				manager.AddJobV2(downstream)
+
+				upstreamRun, dispatched := finishUpstreamRun(t, manager, "upstream", status, now)
+				if !slices.Contains(test.triggeredWith, status) {
+					if len(dispatched) > 0 {
+						t.Fatalf("got %v dispatched runs, want none", len(dispatched))
+					}
+					return
+				}
+				assertDependencyRun(t, dispatched, "downstream", upstreamRun)
+			})
+		}
+	}
+}
+
+// TestDependentJobUpstreams checks that a job with several upstream jobs runs once all of them finish, and
+// that every upstream run triggers the job at most once.
+func TestDependentJobUpstreams(t *testing.T) {
+	now := mustParseTime(t, "2026-03-01T10:00:00Z")
+	manager := newTestJobManager(newFakeClock(now), time.UTC)
+	for _, upstreamId := range []JobId{"upstream-a", "upstream-b"} {
+		manager.AddJobV2(&testJobV2{testJob: *newTestJob(t, upstreamId, "0 0 1 1 *")})
+	}
+	manager.AddJobV2(newDependentTestJob(t, "downstream", "upstream-a", "upstream-b"))
+	manager.AddJobV2(newDependentTestJob(t, "single-downstream", "upstream-a"))
+
+	runA, dispatched := finishUpstreamRun(t, manager, "upstream-a", RUN_STATUS_SUCCEEDED, now)
+	assertDependencyRun(t, dispatched, "single-downstream", runA)
+	// The same completion of the upstream job doesn't trigger the jobs again.
+	if dispatched := handleUpstreamRun(manager, runA, now.Add(time.Minute)); len(dispatched) > 0 {
+		t.Fatalf("got %v dispatched runs after the same upstream run, want none", len(dispatched))
+	}
+	runB, dispatched := finishUpstreamRun(t, manager, "upstream-b", RUN_STATUS_SUCCEEDED, now.Add(2*time.Minute))
+	assertDependencyRun(t, dispatched, "downstream", runB)
+
+	// After the trigger, a new run of only one of the upstream jobs isn't enough.
+	if _, dispatched := finishUpstreamRun(t, manager, "upstream-b", RUN_STATUS_SUCCEEDED, now.Add(3*time.Minute)); len(dispatched) > 0 {
+		t.Fatalf("got %v dispatched runs after a run of only upstream-b, want none", len(dispatched))
+	}
+	runA, dispatched = finishUpstreamRun(t, manager, "upstream-a", RUN_STATUS_SUCCEEDED, now.Add(4*time.Minute))
+	if len(dispatched) != 2 {
+		t.Fatalf("got %v dispatched runs after the new runs of both the upstream jobs, want 2", len(dispatched))
+	}
+	slices.SortFunc(dispatched, func(a, b *JobRun) int { return strings.Compare(string(a.Fields.ID), string(b.Fields.ID)) })
+	assertDependencyRun(t, dispatched[:1], "downstream", runA)
+	assertDependencyRun(t, dispatched[1:], "single-downstream", runA)
+}
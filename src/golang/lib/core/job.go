@@ -1,25 +1,112 @@
 package core
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
 	"time"
+
+	"github.com/shreyasksrao/jobmanager/lib/schedule"
 )
 
 type JobId string
 
+// ConcurrencyPolicy decides what the JobRunner does with a new JobRun when
+// the previous runs of the same job are still running or queued.
+type ConcurrencyPolicy string
+
+const (
+	// Always start the new run (default).
+	CONCURRENCY_POLICY_ALLOW ConcurrencyPolicy = "Allow"
+	// Skip the new run if any run of the job is active.
+	CONCURRENCY_POLICY_FORBID ConcurrencyPolicy = "Forbid"
+	// Stop the active runs of the job and start the new run.
+	CONCURRENCY_POLICY_REPLACE ConcurrencyPolicy = "Replace"
+	// Skip the new run if MaxInstances runs of the job are already active.
+	CONCURRENCY_POLICY_MAX_INSTANCES ConcurrencyPolicy = "MaxInstances"
+)
+
+// MisfirePolicy decides what the JobManager does with the runs which were missed
+// (e.g. while the daemon was down) between the job's LastRun and now.
+type MisfirePolicy string
+
+const (
+	// Ignore the missed runs and continue from the next schedule time (default).
+	MISFIRE_POLICY_SKIP MisfirePolicy = "Skip"
+	// Run the job once for the latest missed schedule time.
+	MISFIRE_POLICY_RUN_ONCE MisfirePolicy = "RunOnce"
+	// Run the job for every missed schedule time, up to MaxCatchUpRuns runs.
+	MISFIRE_POLICY_RUN_ALL MisfirePolicy = "RunAll"
+
+	// Default cap of the catch-up runs for the RunAll misfire policy.
+	DEFAULT_MAX_CATCH_UP_RUNS = 10
+	// Number of missed schedule times counted when a job is scheduled. More missed runs are reported as "≥N".
+	MAX_MISSED_RUN_COUNT = 1000
+
+	// Default time between SIGTERM and SIGKILL when a run times out.
+	DEFAULT_KILL_GRACE_PERIOD = 10 * time.Second
+)
+
 // All the implementations of Job interface should contain these fields.
 // These fields are used in the JobManager to set the next run and last run.
 type CommonJobFields struct {
-	ID      JobId     `json:"ID"`      // Unique job identifier
-	NextRun time.Time `json:"NextRun"` // NextRun at which this job will run
-	LastRun time.Time `json:"LastRun"` // Command execution start time in Epoch millis
+	ID                JobId             `json:"ID"`                          // Unique job identifier
+	NextRun           time.Time         `json:"NextRun"`                     // NextRun at which this job will run
+	LastRun           time.Time         `json:"LastRun"`                     // Command execution start time in Epoch millis
+	ConcurrencyPolicy ConcurrencyPolicy `json:"ConcurrencyPolicy,omitempty"` // Allow (default), Forbid, Replace or MaxInstances
+	MaxInstances      int               `json:"MaxInstances,omitempty"`      // Used only with the MaxInstances policy
+	MisfirePolicy     MisfirePolicy     `json:"MisfirePolicy,omitempty"`     // Skip (default), RunOnce or RunAll
+	MaxCatchUpRuns    int               `json:"MaxCatchUpRuns,omitempty"`    // Cap of catch-up runs for the RunAll policy
+	Timezone          string            `json:"Timezone,omitempty"`          // IANA time zone of the schedule. JobManager's location is used if empty
+	// Daylight saving behaviour of the schedule. See schedule.CronSchedule for the semantics.
+	DSTSkippedTime  schedule.SkippedTimePolicy  `json:"DSTSkippedTime,omitempty"`  // RunAtNextValid (default) or Skip
+	DSTRepeatedTime schedule.RepeatedTimePolicy `json:"DSTRepeatedTime,omitempty"` // RunOnce (default) or RunTwice
+	// Paused jobs are kept by the JobManager but never dispatched. See JobManager.PauseJob().
+	Paused bool `json:"Paused,omitempty"`
+	// Maximum duration of a run. The run is cancelled (SIGTERM for the commands) when it expires and
+	// forcibly killed after KillGracePeriod (default DEFAULT_KILL_GRACE_PERIOD). Zero means no timeout.
+	Timeout         Duration `json:"Timeout,omitempty"`
+	KillGracePeriod Duration `json:"KillGracePeriod,omitempty"`
+	// The scheduled runs are delayed by a random duration below MaxJitter to spread the load. Zero means no delay.
+	MaxJitter Duration `json:"MaxJitter,omitempty"`
+	// Retries of the failed runs. Failed runs are not retried if it is nil.
+	RetryPolicy *RetryPolicy `json:"RetryPolicy,omitempty"`
+	// Upstream jobs of the job. The job is triggered once the latest runs of all the upstream jobs
+	// since its previous dependency triggered run satisfy the conditions.
+	DependsOn []JobDependency `json:"DependsOn,omitempty"`
+	// Once, Interval or Rate schedule of the job. The JobManager uses it instead of GetNextScheduleTime() if it is set.
+	Schedule *JobSchedule `json:"Schedule,omitempty"`
+	// Active window of the job. The scheduled and dependency triggered runs start only between StartAt and EndAt.
+	StartAt *time.Time `json:"StartAt,omitempty"`
+	EndAt   *time.Time `json:"EndAt,omitempty"`
+	// Number of scheduled, catch-up and dependency triggered runs after which the job expires. Zero means no limit.
+	MaxRuns  int `json:"MaxRuns,omitempty"`
+	RunCount int `json:"RunCount,omitempty"` // Scheduled, catch-up and dependency triggered runs dispatched so far
+	// What happens to the job when it expires, i.e. its schedule, EndAt or MaxRuns allow no more runs.
+	// Disable (default) or Delete.
+	ExpiryAction ExpiryAction `json:"ExpiryAction,omitempty"`
+	// Time and reason of the expiry of a disabled job.
+	ExpiredAt    *time.Time `json:"ExpiredAt,omitempty"`
+	ExpiryReason string     `json:"ExpiryReason,omitempty"`
+	// Labels of the job, e.g. {"team": "finance"}. Used to select the jobs of a BlackoutWindow.
+	Labels map[string]string `json:"Labels,omitempty"`
+	// Calendars filtering the schedule times of the job, e.g. the holidays on which it must not run. A time
+	// falling on an excluded date is dropped or moved to the previous or next allowed date as per CalendarRoll.
+	Calendars    []JobCalendar       `json:"Calendars,omitempty"`
+	CalendarRoll schedule.RollPolicy `json:"CalendarRoll,omitempty"` // None (default), Previous or Next
 }
 
+// Job is the original job interface. It has no notion of a run, so all the runs of the job are
+// stopped together through Stop(). New jobs should implement JobV2. Jobs implementing this
+// interface are run through the adapter returned by AdaptJob().
 type Job interface {
 	// Execute() function will be called inside a separate go routine in the Job runner's Run().
 	// Implementation should handle the cleanup of the resources otherwise
 	// resource leak may happen.
 	Execute() (err error)
 	// Stop() will be called on all the running Jobs when the JobManager recieves Stop signal.
+	// It is also called when a run of the job is replaced as per the Replace concurrency policy.
 	Stop()
 	Save() (saved bool, err error)
 	// GetNextScheduleTime() should return the next run of a job wrt "now"
@@ -29,3 +116,164 @@ type Job interface {
 	// should return the pointer to CommonJobFields
 	GetCommonJobFields() (commonFields *CommonJobFields)
 }
+
+// JobV2 is the context aware job interface. Every call of Execute() is a separate run, which should
+// stop as soon as its context is done (the run timed out, was replaced or the JobManager is stopping).
+type JobV2 interface {
+	// Execute() is called inside a separate go routine for every run of the job.
+	Execute(ctx context.Context, info RunInfo) (result Result, err error)
+	Save() (saved bool, err error)
+	// GetNextScheduleTime() should return the next run of a job wrt "now"
+	GetNextScheduleTime(now time.Time) (nextRun time.Time, err error)
+	// GetCommonJobFields() should return the pointer to the CommonJobFields of the job.
+	GetCommonJobFields() (commonFields *CommonJobFields)
+	// Clone() should return a deep copy of the job. The JobManager hands out copies of its jobs,
+	// so that they can be read while the scheduler updates the originals.
+	Clone() (job JobV2)
+}
+
+// RunInfo describes the run being executed to the job.
+type RunInfo struct {
+	RunID       string
+	JobID       JobId
+	Trigger     JobRunTrigger
+	Attempt     int
+	ScheduledAt time.Time
+	// Writers to which the job should write its output. Nil if the output is not captured.
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Result is the outcome of a run returned by the job.
+type Result struct {
+	ExitCode *int   // Exit code of the process run by the job, if any
+	Signal   string // Signal which terminated the process, if any
+}
+
+// Copy returns a deep copy of the fields.
+func (fields *CommonJobFields) Copy() (copied CommonJobFields) {
+	copied = *fields
+	if fields.DependsOn != nil {
+		copied.DependsOn = append([]JobDependency(nil), fields.DependsOn...)
+	}
+	if fields.RetryPolicy != nil {
+		retryPolicy := *fields.RetryPolicy
+		if fields.RetryPolicy.RetryableExitCodes != nil {
+			retryPolicy.RetryableExitCodes = append([]int(nil), fields.RetryPolicy.RetryableExitCodes...)
+		}
+		copied.RetryPolicy = &retryPolicy
+	}
+	if fields.Schedule != nil {
+		jobSchedule := *fields.Schedule
+		copied.Schedule = &jobSchedule
+	}
+	if fields.Calendars != nil {
+		copied.Calendars = append([]JobCalendar(nil), fields.Calendars...)
+	}
+	if fields.Labels != nil {
+		copied.Labels = make(map[string]string, len(fields.Labels))
+		for key, value := range fields.Labels {
+			copied.Labels[key] = value
+		}
+	}
+	return
+}
+
+// locationCache holds the time zones loaded by LoadLocation, keyed by the zone name.
+var locationCache sync.Map
+
+// LoadLocation loads the time zone with the given IANA name (e.g. "Asia/Kolkata").
+// The loaded locations are cached as the schedule is computed on every scheduler tick.
+func LoadLocation(name string) (location *time.Location, err error) {
+	if cached, ok := locationCache.Load(name); ok {
+		return cached.(*time.Location), nil
+	}
+	location, err = time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone - %v. Error - %v", name, err)
+	}
+	locationCache.Store(name, location)
+	return location, nil
+}
+
+// GetLocation returns the location of the job's Timezone. "defaultLocation" is
+// returned if the Timezone is not set.
+func (fields *CommonJobFields) GetLocation(defaultLocation *time.Location) (location *time.Location, err error) {
+	if fields.Timezone == "" {
+		return defaultLocation, nil
+	}
+	return LoadLocation(fields.Timezone)
+}
+
+// GetKillGracePeriod returns the KillGracePeriod of the job or the default if it is not set.
+func (fields *CommonJobFields) GetKillGracePeriod() time.Duration {
+	if fields.KillGracePeriod <= 0 {
+		return DEFAULT_KILL_GRACE_PERIOD
+	}
+	return fields.KillGracePeriod.Duration()
+}
+
+// ValidateConcurrencyPolicy checks the concurrency policy fields of the job.
+// Empty policy is valid and treated as Allow.
+func ValidateConcurrencyPolicy(fields *CommonJobFields) (err error) {
+	switch fields.ConcurrencyPolicy {
+	case "", CONCURRENCY_POLICY_ALLOW, CONCURRENCY_POLICY_FORBID, CONCURRENCY_POLICY_REPLACE:
+		return nil
+	case CONCURRENCY_POLICY_MAX_INSTANCES:
+		if fields.MaxInstances < 1 {
+			return fmt.Errorf("invalid MaxInstances - %v. MaxInstances should be at least 1 for the MaxInstances concurrency policy", fields.MaxInstances)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid ConcurrencyPolicy - %v. Supported values are Allow, Forbid, Replace and MaxInstances", fields.ConcurrencyPolicy)
+	}
+}
+
+// ValidateMisfirePolicy checks the misfire policy fields of the job.
+// Empty policy is valid and treated as Skip.
+func ValidateMisfirePolicy(fields *CommonJobFields) (err error) {
+	switch fields.MisfirePolicy {
+	case "", MISFIRE_POLICY_SKIP, MISFIRE_POLICY_RUN_ONCE, MISFIRE_POLICY_RUN_ALL:
+	default:
+		return fmt.Errorf("invalid MisfirePolicy - %v. Supported values are Skip, RunOnce and RunAll", fields.MisfirePolicy)
+	}
+	if fields.MaxCatchUpRuns < 0 {
+		return fmt.Errorf("invalid MaxCatchUpRuns - %v. MaxCatchUpRuns can not be negative", fields.MaxCatchUpRuns)
+	}
+	return nil
+}
+
+// ValidateDSTPolicy checks the daylight saving policy fields of the job.
+// Empty policies are valid and treated as RunAtNextValid and RunOnce.
+func ValidateDSTPolicy(fields *CommonJobFields) (err error) {
+	switch fields.DSTSkippedTime {
+	case "", schedule.SKIPPED_TIME_RUN_AT_NEXT_VALID, schedule.SKIPPED_TIME_SKIP:
+	default:
+		return fmt.Errorf("invalid DSTSkippedTime - %v. Supported values are RunAtNextValid and Skip", fields.DSTSkippedTime)
+	}
+	switch fields.DSTRepeatedTime {
+	case "", schedule.REPEATED_TIME_RUN_ONCE, schedule.REPEATED_TIME_RUN_TWICE:
+	default:
+		return fmt.Errorf("invalid DSTRepeatedTime - %v. Supported values are RunOnce and RunTwice", fields.DSTRepeatedTime)
+	}
+	return nil
+}
+
+// ValidateTimeout checks the Timeout and KillGracePeriod of the job.
+func ValidateTimeout(fields *CommonJobFields) (err error) {
+	if fields.Timeout < 0 {
+		return fmt.Errorf("invalid Timeout - %v. Timeout can not be negative", fields.Timeout.Duration())
+	}
+	if fields.KillGracePeriod < 0 {
+		return fmt.Errorf("invalid KillGracePeriod - %v. KillGracePeriod can not be negative", fields.KillGracePeriod.Duration())
+	}
+	return nil
+}
+
+// ValidateMaxJitter checks the MaxJitter of the job.
+func ValidateMaxJitter(fields *CommonJobFields) (err error) {
+	if fields.MaxJitter < 0 {
+		return fmt.Errorf("invalid MaxJitter - %v. MaxJitter can not be negative", fields.MaxJitter.Duration())
+	}
+	return nil
+}
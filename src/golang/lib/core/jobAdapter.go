@@ -0,0 +1,56 @@
+package core
+
+import "context"
+
+// jobAdapter runs a Job as a JobV2.
+type jobAdapter struct {
+	Job
+	// fields is set for the clones, which hold a copy of the CommonJobFields of the Job.
+	fields *CommonJobFields
+}
+
+// AdaptJob wraps a Job implementing the original interface as a JobV2. As the Job can only be stopped
+// as a whole, the Job's Stop() is called when the context of any of its runs is done before it finishes.
+func AdaptJob(job Job) JobV2 {
+	return &jobAdapter{Job: job}
+}
+
+// UnwrapJob returns the original Job of a JobV2 created by AdaptJob(). Nil is returned for the other jobs.
+func UnwrapJob(job JobV2) Job {
+	if adapter, ok := job.(*jobAdapter); ok {
+		return adapter.Job
+	}
+	return nil
+}
+
+func (adapter *jobAdapter) GetCommonJobFields() (commonFields *CommonJobFields) {
+	if adapter.fields != nil {
+		return adapter.fields
+	}
+	return adapter.Job.GetCommonJobFields()
+}
+
+// Clone copies only the CommonJobFields of the Job, as the rest of the Job is not known.
+// The clone shares the other state with the original Job.
+func (adapter *jobAdapter) Clone() (job JobV2) {
+	fields := adapter.GetCommonJobFields().Copy()
+	return &jobAdapter{Job: adapter.Job, fields: &fields}
+}
+
+func (adapter *jobAdapter) Execute(ctx context.Context, info RunInfo) (result Result, err error) {
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-ctx.Done():
+			select {
+			case <-finished:
+				return
+			default:
+			}
+			adapter.Job.Stop()
+		case <-finished:
+		}
+	}()
+	return Result{}, adapter.Job.Execute()
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stoppableJob is a Job whose Execute() blocks until Stop() is called, unless it is not blocking.
+type stoppableJob struct {
+	testJob
+	blocking  bool
+	stopped   chan struct{}
+	stopOnce  sync.Once
+	stopCount atomic.Int32
+}
+
+func (job *stoppableJob) Execute() (err error) {
+	if job.blocking {
+		<-job.stopped
+	}
+	return nil
+}
+
+func (job *stoppableJob) Stop() {
+	job.stopCount.Add(1)
+	job.stopOnce.Do(func() { close(job.stopped) })
+}
+
+// TestAdaptJobCancellation checks that the adapted Job is stopped only when the context of its run is done
+// before the run finishes.
+func TestAdaptJobCancellation(t *testing.T) {
+	tests := []struct {
+		name          string
+		blocking      bool
+		cancelAfter   bool // Cancel the context after the run finished instead of while it is running
+		wantStopCount int32
+	}{
+		{name: "cancelled run", blocking: true, wantStopCount: 1},
+		{name: "finished run", cancelAfter: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			job := &stoppableJob{testJob: *newTestJob(t, "legacy-job", "0 0 1 1 *"), blocking: test.blocking, stopped: make(chan struct{})}
+			adapted := AdaptJob(job)
+			ctx, cancel := context.WithCancel(context.Background())
+			if !test.cancelAfter {
+				time.AfterFunc(20*time.Millisecond, cancel)
+			}
+			finished := make(chan struct{})
+			go func() {
+				defer close(finished)
+				adapted.Execute(ctx, RunInfo{RunID: "run-1", JobID: "legacy-job"})
+			}()
+			select {
+			case <-finished:
+			case <-time.After(2 * time.Second):
+				t.Fatalf("the run of the adapted job didn't finish")
+			}
+			cancel()
+			// Give the watcher go-routine a chance to call Stop() by mistake.
+			time.Sleep(20 * time.Millisecond)
+			if got := job.stopCount.Load(); got != test.wantStopCount {
+				t.Errorf("got %v calls of Stop(), want %v", got, test.wantStopCount)
+			}
+		})
+	}
+}
+
+func TestAdaptJobClone(t *testing.T) {
+	job := newTestJob(t, "legacy-job", "0 0 1 1 *")
+	adapted := AdaptJob(job)
+	if UnwrapJob(adapted) != Job(job) {
+		t.Errorf("got the unwrapped job %v, want the original job", UnwrapJob(adapted))
+	}
+	if UnwrapJob(&testJobV2{}) != nil {
+		t.Errorf("got an unwrapped job of a JobV2 which is not adapted")
+	}
+	clone := adapted.Clone()
+	clone.GetCommonJobFields().Timezone = "Asia/Kolkata"
+	if job.CommonJobFields.Timezone != "" || adapted.GetCommonJobFields().Timezone != "" {
+		t.Errorf("got the change of the clone in the original job")
+	}
+	if UnwrapJob(clone) != Job(job) {
+		t.Errorf("got the unwrapped clone %v, want the original job", UnwrapJob(clone))
+	}
+}
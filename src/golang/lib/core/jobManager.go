@@ -1,23 +1,59 @@
 package core
 
 import (
+	"errors"
+	"fmt"
 	"sort"
 	"sync"
 	"time"
 )
 
 type JobManager struct {
-	Jobs       []Job
-	stopChan   chan struct{}
-	addChan    chan Job
-	removeChan chan JobId
-	running    bool
-	runningMu  sync.Mutex
-	Logger     Logger
-	jobLock    sync.Mutex
-	Location   *time.Location
-	jobRunner  *JobRunner
-	jobRunChan chan *JobRun
+	// Jobs of the JobManager ordered by the next schedule time. Owned by the scheduler go-routine once started.
+	jobs         *jobQueue
+	stopChan     chan struct{}
+	addChan      chan addRequest
+	removeChan   chan JobId
+	pauseChan    chan pauseRequest
+	runNowChan   chan runNowRequest
+	updateChan   chan updateRequest
+	snapshotChan chan snapshotRequest
+	blackoutChan chan blackoutRequest
+	calendarChan chan calendarRequest
+	// startedChan receives the started JobRuns from the JobRunner to update the LastRun of the jobs.
+	// finishedChan receives the finished (or skipped) JobRuns from the JobRunner to trigger the dependent jobs
+	// and to schedule the jobs whose next run depends on the completion.
+	startedChan  chan *JobRun
+	finishedChan chan *JobRun
+	// running and schedulerDone are guarded by runningMu. When the JobManager is not running, the
+	// jobs are also accessed with runningMu held.
+	running       bool
+	schedulerDone chan struct{}
+	runningMu     sync.Mutex
+	Logger        Logger
+	Location      *time.Location
+	jobRunner     *JobRunner
+	jobRunChan    chan *JobRun
+	clock         Clock
+	observers     *observerList
+	runHistory    RunHistory
+	runOutput     *RunOutput
+	// Latest finished run of every job and the time at which each job was last triggered by its dependencies.
+	// Owned by the scheduler go-routine.
+	finishedRuns          map[JobId]*JobRun
+	dependencyTriggeredAt map[JobId]time.Time
+	// Trigger of the first run of every job deferred by a blackout window. The NextRun of such a job is the
+	// end of the window. Owned by the scheduler go-routine.
+	deferredRuns map[JobId]JobRunTrigger
+	// Blackout windows are changed only by the scheduler go-routine (or with runningMu held when it is not
+	// running) with blackoutMu held. Other go-routines read them with blackoutMu held.
+	blackouts     []BlackoutWindow
+	blackoutMu    sync.RWMutex
+	blackoutStore BlackoutStore
+	// Calendars keyed by the calendar ID. They are changed like the blackout windows, with calendarMu held.
+	calendars     map[string]*storedCalendar
+	calendarMu    sync.RWMutex
+	calendarStore CalendarStore
 }
 
 type JobManagerConfig struct {
@@ -26,7 +62,19 @@ type JobManagerConfig struct {
 	JobRunnerLogger  Logger
 	// Maximum number of Jobs the runner can handle in parallel.
 	// Each Job run will spawn a new go-routine and call the Job's Execute() function.
+	// JobRuns exceeding this limit are queued and started as the running jobs complete.
+	// Defaults to DEFAULT_MAX_RUNNING_JOBS when not set.
 	MaxRunningJobsCount int16
+	// Source of the current time and timers. Defaults to the system clock.
+	Clock Clock
+	// Store of the job run records. Runs are not recorded if it is nil.
+	RunHistory RunHistory
+	// Captures the output of the job runs. Output is not captured if it is nil.
+	RunOutput *RunOutput
+	// Store of the blackout windows. The windows are not persisted if it is nil.
+	BlackoutStore BlackoutStore
+	// Store of the calendars attached to the jobs. The calendars are not persisted if it is nil.
+	CalendarStore CalendarStore
 }
 
 func NewJobManager(config *JobManagerConfig) (jobManager *JobManager) {
@@ -35,18 +83,52 @@ func NewJobManager(config *JobManagerConfig) (jobManager *JobManager) {
 	if location == nil {
 		location = time.Local
 	}
+	clock := config.Clock
+	if clock == nil {
+		clock = realClock{}
+	}
 	jobRunChan := make(chan *JobRun, DEFAULT_JOB_RUN_CHAN_BUFFER)
 	stopChan := make(chan struct{})
 	jobManager = &JobManager{
-		Jobs:       nil,
-		Logger:     config.JobManagerLogger,
-		stopChan:   stopChan,
-		addChan:    make(chan Job),
-		removeChan: make(chan JobId),
-		running:    false,
-		Location:   location,
-		jobRunner:  NewJobRunner(config.JobRunnerLogger, config.MaxRunningJobsCount, jobRunChan),
-		jobRunChan: jobRunChan,
+		jobs:                  newJobQueue(),
+		Logger:                config.JobManagerLogger,
+		stopChan:              stopChan,
+		addChan:               make(chan addRequest),
+		removeChan:            make(chan JobId),
+		pauseChan:             make(chan pauseRequest),
+		runNowChan:            make(chan runNowRequest),
+		updateChan:            make(chan updateRequest),
+		snapshotChan:          make(chan snapshotRequest),
+		blackoutChan:          make(chan blackoutRequest),
+		calendarChan:          make(chan calendarRequest),
+		calendars:             make(map[string]*storedCalendar),
+		startedChan:           make(chan *JobRun, DEFAULT_JOB_RUN_CHAN_BUFFER),
+		finishedChan:          make(chan *JobRun, DEFAULT_JOB_RUN_CHAN_BUFFER),
+		finishedRuns:          make(map[JobId]*JobRun),
+		dependencyTriggeredAt: make(map[JobId]time.Time),
+		deferredRuns:          make(map[JobId]JobRunTrigger),
+		running:               false,
+		Location:              location,
+		jobRunner:             NewJobRunner(config.JobRunnerLogger, config.MaxRunningJobsCount, jobRunChan),
+		jobRunChan:            jobRunChan,
+		clock:                 clock,
+	}
+	jobManager.jobRunner.clock = clock
+	jobManager.observers = newObserverList(config.JobManagerLogger, clock)
+	jobManager.jobRunner.observers = jobManager.observers
+	jobManager.jobRunner.startedChan = jobManager.startedChan
+	jobManager.jobRunner.finishedChan = jobManager.finishedChan
+	jobManager.jobRunner.history = config.RunHistory
+	jobManager.runHistory = config.RunHistory
+	jobManager.jobRunner.output = config.RunOutput
+	jobManager.runOutput = config.RunOutput
+	if config.BlackoutStore != nil {
+		jobManager.blackoutStore = config.BlackoutStore
+		jobManager.blackouts = config.BlackoutStore.GetBlackoutWindows()
+	}
+	if config.CalendarStore != nil {
+		jobManager.calendarStore = config.CalendarStore
+		jobManager.loadCalendars(config.CalendarStore)
 	}
 	config.JobManagerLogger.Infof("Successfully created the JobManager instance.")
 	return
@@ -57,134 +139,911 @@ func (manager *JobManager) Start() (err error) {
 	defer manager.Logger.Infof("============================ STARTED Job manager ============================")
 	manager.runningMu.Lock()
 	defer manager.runningMu.Unlock()
+	if manager.running {
+		return fmt.Errorf("job manager is already running")
+	}
+	if err = manager.jobRunner.Start(); err != nil {
+		return err
+	}
 	manager.running = true
-	go manager.runScheduler()
-	go manager.jobRunner.Start()
+	manager.schedulerDone = make(chan struct{})
+	go manager.runScheduler(manager.schedulerDone)
 	return
 }
 
+// Stop stops the scheduler and interrupts the running jobs right away. See Shutdown().
 func (manager *JobManager) Stop() (err error) {
+	return manager.Shutdown(0)
+}
+
+// Shutdown stops the scheduler, so that no new run is dispatched, and waits for up to "drainTimeout" for the
+// running jobs to complete before interrupting them. The interrupted runs are recorded with the status
+// "interrupted".
+func (manager *JobManager) Shutdown(drainTimeout time.Duration) (err error) {
 	manager.Logger.Infof("============================ STOPPING Job manager ============================")
 	defer manager.Logger.Infof("============================ STOPPED Job manager ============================")
-	if manager.running {
+	manager.runningMu.Lock()
+	if !manager.running {
+		manager.runningMu.Unlock()
+		return
+	}
+	manager.running = false
+	schedulerDone := manager.schedulerDone
+	manager.runningMu.Unlock()
+	// The requests sent after this point are handled directly, as the scheduler is gone.
+	manager.stopChan <- struct{}{}
+	<-schedulerDone
+	return manager.jobRunner.Drain(drainTimeout)
+}
+
+// AddObserver registers the observer for the events of the jobs and their runs. The returned function
+// unregisters the observer.
+func (manager *JobManager) AddObserver(observer Observer) (remove func()) {
+	return manager.observers.add(observer)
+}
+
+// sendRequest hands the request over to the scheduler go-routine, which owns the jobs while the
+// JobManager is running. When the JobManager is not running, handle() is called directly with the
+// runningMu held instead. The caller never blocks while holding a lock.
+func sendRequest[T any](manager *JobManager, requests chan T, request T, handle func(request T)) {
+	for {
 		manager.runningMu.Lock()
-		defer manager.runningMu.Unlock()
-		manager.running = false
-		manager.stopChan <- struct{}{}
-		manager.jobRunner.Stop()
+		if !manager.running {
+			handle(request)
+			manager.runningMu.Unlock()
+			return
+		}
+		schedulerDone := manager.schedulerDone
+		manager.runningMu.Unlock()
+		select {
+		case requests <- request:
+			return
+		case <-schedulerDone:
+			// The JobManager stopped before the scheduler picked the request. Try again.
+		}
 	}
-	return
 }
 
+// AddJob adds a job implementing the original Job interface. It is run through AdaptJob().
 func (manager *JobManager) AddJob(j Job) (jobId JobId) {
+	return manager.AddJobV2(AdaptJob(j))
+}
+
+// ErrJobNotSaved is returned by CreateJob() when the job is valid but can't be saved.
+var ErrJobNotSaved = errors.New("failed to save the job")
+
+// addRequest asks the scheduler to add a job. The references of the job are checked first if "validate" is
+// set, and the result is sent on the reply channel if it isn't nil.
+type addRequest struct {
+	job      JobV2
+	validate bool
+	reply    chan error
+}
+
+// AddJobV2 adds a context aware job to the JobManager.
+func (manager *JobManager) AddJobV2(j JobV2) (jobId JobId) {
 	manager.Logger.Infof("Adding the job to the job manager.")
 	jobId = j.GetCommonJobFields().ID
-	manager.jobLock.Lock()
-	defer manager.jobLock.Unlock()
-	if !manager.running {
+	sendRequest(manager, manager.addChan, addRequest{job: j}, func(request addRequest) {
 		manager.Logger.Infof("Job manager is not running, simply adding the job to the entry list.")
-		manager.Jobs = append(manager.Jobs, j)
-	} else {
-		manager.addChan <- j
-	}
+		manager.addEntry(request, manager.now(), false)
+	})
 	return
 }
 
+// CreateJob checks that the calendars of a new job exist, saves the job and adds it to the JobManager. The check
+// and the insert are done in one step, so that the calendars can't be deleted in between.
+func (manager *JobManager) CreateJob(j JobV2) (err error) {
+	manager.Logger.Infof("Creating the job - %v", j.GetCommonJobFields().ID)
+	request := addRequest{job: j, validate: true, reply: make(chan error, 1)}
+	sendRequest(manager, manager.addChan, request, func(request addRequest) {
+		manager.Logger.Infof("Job manager is not running, simply adding the job to the entry list.")
+		request.reply <- manager.addEntry(request, manager.now(), false)
+	})
+	return <-request.reply
+}
+
+// addEntry adds the job of the request to the queue. A job added while the scheduler is running is scheduled
+// right away. The job of a validated request is added only if it is saved.
+func (manager *JobManager) addEntry(request addRequest, now time.Time, schedulerRunning bool) (err error) {
+	fields := request.job.GetCommonJobFields()
+	if request.validate {
+		if err = manager.ValidateJobCalendars(fields); err != nil {
+			manager.Logger.Errorf("Failed to add the job - %v. Error - %v", fields.ID, err)
+			return err
+		}
+	}
+	if schedulerRunning {
+		manager.scheduleJob(request.job, now)
+	}
+	if request.validate {
+		if _, err = request.job.Save(); err != nil {
+			manager.Logger.Errorf("Failed to save the job - %v. Error - %v", fields.ID, err)
+			return fmt.Errorf("%w - %v. Error - %w", ErrJobNotSaved, fields.ID, err)
+		}
+	}
+	manager.jobs.add(request.job)
+	manager.observers.publish(EVENT_JOB_ADDED, fields.ID, nil)
+	manager.Logger.Infof("Added the job with ID - %v. Current time - %v, Next run at - %v", fields.ID, now, fields.NextRun)
+	return nil
+}
+
 func (manager *JobManager) RemoveJob(jobId string) {
-	manager.Logger.Infof("Adding the job to the job manager.")
-	manager.jobLock.Lock()
-	defer manager.jobLock.Unlock()
-	if !manager.running {
+	manager.Logger.Infof("Removing the job - %v from the job manager.", jobId)
+	sendRequest(manager, manager.removeChan, JobId(jobId), func(jobId JobId) {
 		manager.Logger.Infof("Job manager is not running, simply removing the job from the entry list.")
-		manager.removeEntry(JobId(jobId))
+		manager.removeEntry(jobId)
+	})
+}
+
+// pauseRequest asks the scheduler to pause or resume a job. The result is sent on the reply channel.
+type pauseRequest struct {
+	jobId  JobId
+	paused bool
+	reply  chan error
+}
+
+// PauseJob stops the scheduler from dispatching the job until ResumeJob() is called.
+// The job definition is kept and the paused state is persisted with the job.
+func (manager *JobManager) PauseJob(jobId JobId) (err error) {
+	manager.Logger.Infof("Pausing the job - %v", jobId)
+	return manager.setJobPaused(jobId, true)
+}
+
+// ResumeJob resumes a paused job. The NextRun is recomputed and the runs missed while
+// the job was paused are dispatched as per the job's misfire policy.
+func (manager *JobManager) ResumeJob(jobId JobId) (err error) {
+	manager.Logger.Infof("Resuming the job - %v", jobId)
+	return manager.setJobPaused(jobId, false)
+}
+
+func (manager *JobManager) setJobPaused(jobId JobId, paused bool) (err error) {
+	request := pauseRequest{jobId: jobId, paused: paused, reply: make(chan error, 1)}
+	sendRequest(manager, manager.pauseChan, request, func(request pauseRequest) {
+		manager.Logger.Infof("Job manager is not running, simply updating the paused state of the job.")
+		request.reply <- manager.updatePausedState(request.jobId, request.paused, manager.now(), false)
+	})
+	return <-request.reply
+}
+
+// updatePausedState sets the paused state of the job and persists it. A paused job has no NextRun.
+// A resumed job is scheduled right away only if the scheduler is running.
+func (manager *JobManager) updatePausedState(jobId JobId, paused bool, now time.Time, schedulerRunning bool) (err error) {
+	job := manager.jobs.get(jobId)
+	if job == nil {
+		manager.Logger.Errorf("Failed to update the paused state. Job with ID - %v doesn't exist.", jobId)
+		return fmt.Errorf("job with ID - %v doesn't exist", jobId)
+	}
+	defer manager.jobs.fix(jobId)
+	fields := job.GetCommonJobFields()
+	if fields.Paused == paused {
+		manager.Logger.Infof("Job - %v is already in the paused state - %v", jobId, paused)
+		return nil
+	}
+	if !paused {
+		if reason := manager.expiryReason(job, now); reason != "" {
+			return fmt.Errorf("job with ID - %v has expired. %v. Change its schedule, EndAt or MaxRuns before resuming it", jobId, reason)
+		}
+		fields.ExpiredAt = nil
+		fields.ExpiryReason = ""
+	}
+	fields.Paused = paused
+	delete(manager.deferredRuns, jobId)
+	if paused {
+		fields.NextRun = time.Time{}
+		_, err = job.Save()
+		manager.Logger.Infof("Paused the job - %v", jobId)
+		return
+	}
+	if schedulerRunning {
+		manager.scheduleJob(job, now)
+	} else {
+		// The NextRun is computed when the scheduler starts.
+		_, err = job.Save()
+	}
+	manager.Logger.Infof("Resumed the job - %v. Next run at - %v", jobId, fields.NextRun)
+	return
+}
+
+// runNowRequest asks the scheduler to dispatch a manual run of a job.
+type runNowRequest struct {
+	jobId JobId
+	reply chan runNowReply
+}
+
+type runNowReply struct {
+	jobRun *JobRun
+	err    error
+}
+
+// RunNow dispatches a run of the job immediately, irrespective of its schedule. The JobRun goes
+// through the JobRunner like the scheduled runs, so the concurrency policy and the running job limit
+// apply. Use JobRun.Wait() to wait for the completion of the run.
+func (manager *JobManager) RunNow(jobId JobId) (jobRun *JobRun, err error) {
+	manager.Logger.Infof("Triggering a manual run of the job - %v", jobId)
+	request := runNowRequest{jobId: jobId, reply: make(chan runNowReply, 1)}
+	sendRequest(manager, manager.runNowChan, request, func(request runNowRequest) {
+		manager.Logger.Errorf("Failed to run the job - %v. Job manager is not running.", request.jobId)
+		request.reply <- runNowReply{err: fmt.Errorf("job manager is not running")}
+	})
+	reply := <-request.reply
+	return reply.jobRun, reply.err
+}
+
+// dispatchManualRun sends a manual JobRun of the job to the JobRunner.
+func (manager *JobManager) dispatchManualRun(jobId JobId, now time.Time) (jobRun *JobRun, err error) {
+	if job := manager.jobs.get(jobId); job != nil {
+		jobRun = manager.jobRunner.CreateJobRun(job, now, TRIGGER_MANUAL)
+		manager.jobRunChan <- jobRun
+		manager.Logger.Infof("Dispatched the manual run - %v of the job - %v", jobRun.ID, jobId)
+		return jobRun, nil
+	}
+	manager.Logger.Errorf("Failed to run the job. Job with ID - %v doesn't exist.", jobId)
+	return nil, fmt.Errorf("job with ID - %v doesn't exist", jobId)
+}
+
+// updateRequest asks the scheduler to replace the definition of a job with a changed copy.
+type updateRequest struct {
+	jobId  JobId
+	mutate func(job JobV2) (err error)
+	reply  chan updateReply
+}
+
+type updateReply struct {
+	job JobV2
+	err error
+}
+
+// UpdateJob changes the definition of the job in one step. "mutate" is called with a copy of the job and
+// the copy replaces the job only if mutate returns no error and the dependencies are valid. The NextRun is
+// recomputed as per the changed definition. The runs in flight continue with the old definition.
+// A copy of the updated job is returned. Only the CommonJobFields of the jobs added through AddJob()
+// can be changed, see jobAdapter.Clone().
+func (manager *JobManager) UpdateJob(jobId JobId, mutate func(job JobV2) (err error)) (updated JobV2, err error) {
+	manager.Logger.Infof("Updating the job - %v", jobId)
+	request := updateRequest{jobId: jobId, mutate: mutate, reply: make(chan updateReply, 1)}
+	sendRequest(manager, manager.updateChan, request, func(request updateRequest) {
+		manager.Logger.Infof("Job manager is not running, simply replacing the job in the entry list.")
+		manager.handleUpdate(request, manager.now(), false)
+	})
+	reply := <-request.reply
+	return reply.job, reply.err
+}
+
+func (manager *JobManager) handleUpdate(request updateRequest, now time.Time, schedulerRunning bool) {
+	updated, err := manager.replaceJob(request.jobId, request.mutate, now, schedulerRunning)
+	if err != nil {
+		manager.Logger.Errorf("Failed to update the job - %v. Error - %v", request.jobId, err)
+		request.reply <- updateReply{err: err}
+		return
+	}
+	manager.Logger.Infof("Updated the job - %v. Next run at - %v", request.jobId, updated.GetCommonJobFields().NextRun)
+	manager.observers.publish(EVENT_JOB_UPDATED, request.jobId, nil)
+	request.reply <- updateReply{job: updated.Clone()}
+}
+
+// replaceJob replaces the job with a copy changed by "mutate" and computes its NextRun.
+// The catch-up runs are not dispatched, as changing the job doesn't miss any run.
+func (manager *JobManager) replaceJob(jobId JobId, mutate func(job JobV2) (err error), now time.Time, schedulerRunning bool) (updated JobV2, err error) {
+	job := manager.jobs.get(jobId)
+	if job == nil {
+		return nil, fmt.Errorf("job with ID - %v doesn't exist", jobId)
+	}
+	updated = job.Clone()
+	if err = mutate(updated); err != nil {
+		return nil, err
+	}
+	fields := updated.GetCommonJobFields()
+	if fields.ID != jobId {
+		return nil, fmt.Errorf("job ID can not be changed from %v to %v", jobId, fields.ID)
+	}
+	allJobs := []JobV2{updated}
+	for _, other := range manager.jobs.all() {
+		if other.GetCommonJobFields().ID != jobId {
+			allJobs = append(allJobs, other)
+		}
+	}
+	if err = ValidateDependencyGraph(allJobs); err != nil {
+		return nil, err
+	}
+	// The NextRun is computed when the scheduler starts if it is not running.
+	fields.NextRun = time.Time{}
+	delete(manager.deferredRuns, jobId)
+	if schedulerRunning && !fields.Paused {
+		nextRun, nextRunErr := manager.getNextScheduleTime(updated, now)
+		if nextRunErr != nil {
+			manager.Logger.Errorf("Failed to compute the next run of the job - %v. Error - %v", jobId, nextRunErr)
+		}
+		fields.NextRun = nextRun
+	}
+	manager.jobs.add(updated)
+	updated.Save()
+	if schedulerRunning {
+		manager.expireJob(updated, now)
+	}
+	return updated, nil
+}
+
+// JobStatus is a point in time view of a job in the JobManager.
+type JobStatus struct {
+	CommonJobFields CommonJobFields `json:"CommonJobFields"` // Copy of the job's fields, including NextRun and LastRun
+	RunningRuns     int             `json:"RunningRuns"`     // Runs of the job being executed
+	QueuedRuns      int             `json:"QueuedRuns"`      // Runs of the job waiting for a runner slot
+}
+
+// snapshotRequest asks the scheduler for copies of a job, or of all the jobs if the jobId is empty.
+type snapshotRequest struct {
+	jobId JobId
+	reply chan []JobV2
+}
+
+// ListJobs returns copies of all the jobs, ordered by the job ID. The copies don't change with the jobs.
+func (manager *JobManager) ListJobs() (jobs []JobV2) {
+	jobs = manager.snapshot("")
+	sort.Slice(jobs, func(a, b int) bool {
+		return jobs[a].GetCommonJobFields().ID < jobs[b].GetCommonJobFields().ID
+	})
+	return
+}
+
+// GetJob returns a copy of the job.
+func (manager *JobManager) GetJob(jobId JobId) (job JobV2, found bool) {
+	jobs := manager.snapshot(jobId)
+	if len(jobs) == 0 {
+		return nil, false
+	}
+	return jobs[0], true
+}
+
+// JobStatus returns the schedule and the run state of the job.
+func (manager *JobManager) JobStatus(jobId JobId) (status JobStatus, found bool) {
+	job, found := manager.GetJob(jobId)
+	if !found {
+		return status, false
+	}
+	status.CommonJobFields = *job.GetCommonJobFields()
+	status.RunningRuns = manager.jobRunner.countRunningJobRuns(jobId)
+	status.QueuedRuns = manager.jobRunner.countPendingJobRuns(jobId)
+	return status, true
+}
+
+func (manager *JobManager) snapshot(jobId JobId) (jobs []JobV2) {
+	request := snapshotRequest{jobId: jobId, reply: make(chan []JobV2, 1)}
+	sendRequest(manager, manager.snapshotChan, request, manager.handleSnapshot)
+	return <-request.reply
+}
+
+func (manager *JobManager) handleSnapshot(request snapshotRequest) {
+	jobs := make([]JobV2, 0)
+	if request.jobId != "" {
+		if job := manager.jobs.get(request.jobId); job != nil {
+			jobs = append(jobs, job.Clone())
+		}
 	} else {
-		manager.removeChan <- JobId(jobId)
+		for _, job := range manager.jobs.all() {
+			jobs = append(jobs, job.Clone())
+		}
+	}
+	request.reply <- jobs
+}
+
+// recordLastRun sets the LastRun of the job of the started JobRun and persists it.
+func (manager *JobManager) recordLastRun(jobRun *JobRun) {
+	job := manager.jobs.get(jobRun.Fields.ID)
+	if job == nil {
+		return
+	}
+	fields := job.GetCommonJobFields()
+	if jobRun.RanAt.After(fields.LastRun) {
+		fields.LastRun = jobRun.RanAt
+		job.Save()
+	}
+}
+
+// GetJobRuns returns the run records of the job from the run history, latest first.
+func (manager *JobManager) GetJobRuns(jobId JobId) (records []RunRecord, err error) {
+	if manager.runHistory == nil {
+		return nil, fmt.Errorf("run history is not configured")
+	}
+	return manager.runHistory.GetJobRuns(jobId)
+}
+
+// GetRun returns the run record with the given run ID from the run history.
+func (manager *JobManager) GetRun(runId string) (record RunRecord, found bool, err error) {
+	if manager.runHistory == nil {
+		return RunRecord{}, false, fmt.Errorf("run history is not configured")
+	}
+	return manager.runHistory.GetRun(runId)
+}
+
+// GetRunOutputFilePath returns the file which holds the captured output stream (stdout or stderr) of the run.
+func (manager *JobManager) GetRunOutputFilePath(runId string, stream string) (filePath string, err error) {
+	if manager.runOutput == nil {
+		return "", fmt.Errorf("run output capture is not configured")
 	}
+	return manager.runOutput.GetFilePath(runId, stream)
 }
 
-func (manager *JobManager) runScheduler() {
+// RunnerStats returns the running job count and the pending queue statistics of the JobRunner.
+func (manager *JobManager) RunnerStats() (stats JobRunnerStats) {
+	return manager.jobRunner.Stats()
+}
+
+// SkippedJobRuns returns the recent runs of the job skipped because of its concurrency policy.
+// Skipped runs of all the jobs are returned if the jobId is empty.
+func (manager *JobManager) SkippedJobRuns(jobId JobId) (skippedJobRuns []SkippedJobRun) {
+	return manager.jobRunner.GetSkippedJobRuns(jobId)
+}
+
+// runScheduler is the scheduler go-routine. It is the only owner of the jobs while the JobManager
+// is running; everyone else reaches the jobs through the request channels. "done" is closed on return.
+func (manager *JobManager) runScheduler(done chan struct{}) {
+	defer close(done)
 	manager.Logger.Infof("Running the scheduler.")
-	now := time.Now()
+	now := manager.now()
+	// The NextRun of the jobs is recomputed, so the runs deferred before a restart are dispatched as catch-up runs.
+	clear(manager.deferredRuns)
 	manager.Logger.Infof("Populatinng the next job run ffor all the jobs.")
-	for _, job := range manager.Jobs {
-		nextRun, _ := job.GetNextScheduleTime(now)
-		job.GetCommonJobFields().NextRun = nextRun
-		job.Save()
+	for _, job := range manager.jobs.all() {
+		manager.scheduleJob(job, now)
+	}
+	manager.jobs.init()
+	for _, job := range manager.jobs.all() {
+		manager.expireJob(job, now)
 	}
 	for {
-		// Sort the Jobs based on the next schedule time.
-		sortByNextScheduleTime := func(a, b int) bool {
-			aNext := manager.Jobs[a].GetCommonJobFields().NextRun
-			bNext := manager.Jobs[b].GetCommonJobFields().NextRun
-			if aNext.IsZero() {
-				return false
-			}
-			if bNext.IsZero() {
-				return true
+		var timer Timer
+		now = manager.now()
+		// The queue is ordered by the next schedule time, so only the first job decides the timer.
+		nextJob := manager.jobs.peek()
+		if nextJob == nil || nextJob.GetCommonJobFields().NextRun.IsZero() {
+			// If there are no jobs yet, just sleep - it still handles new jobs and stop requests.
+			timer = manager.clock.NewTimer(100000 * time.Hour)
+		} else {
+			timer = manager.clock.NewTimer(nextJob.GetCommonJobFields().NextRun.Sub(now))
+		}
+		// Listen for any requests on the channels...
+		select {
+		case now = <-timer.C():
+			now = now.In(manager.Location)
+			manager.Logger.Infof("Timer expired at - %v.", now)
+			manager.dispatchDueJobs(now)
+
+		case request := <-manager.addChan:
+			timer.Stop()
+			err := manager.addEntry(request, manager.now(), true)
+			if request.reply != nil {
+				request.reply <- err
 			}
-			return aNext.Before(bNext)
+
+		case <-manager.stopChan:
+			timer.Stop()
+			manager.Logger.Infof("Recieved signal on Stop channel. Stopped the scheduler...")
+			return
+
+		case request := <-manager.pauseChan:
+			timer.Stop()
+			request.reply <- manager.updatePausedState(request.jobId, request.paused, manager.now(), true)
+
+		case request := <-manager.runNowChan:
+			timer.Stop()
+			jobRun, err := manager.dispatchManualRun(request.jobId, manager.now())
+			request.reply <- runNowReply{jobRun: jobRun, err: err}
+
+		case request := <-manager.updateChan:
+			timer.Stop()
+			manager.handleUpdate(request, manager.now(), true)
+
+		case request := <-manager.snapshotChan:
+			timer.Stop()
+			manager.handleSnapshot(request)
+
+		case request := <-manager.blackoutChan:
+			timer.Stop()
+			request.reply <- manager.updateBlackouts(request, manager.now(), true)
+
+		case request := <-manager.calendarChan:
+			timer.Stop()
+			request.reply <- manager.updateCalendars(request, manager.now(), true)
+
+		case jobRun := <-manager.startedChan:
+			timer.Stop()
+			manager.recordLastRun(jobRun)
+
+		case jobRun := <-manager.finishedChan:
+			timer.Stop()
+			manager.handleFinishedRun(jobRun, manager.now())
+
+		case id := <-manager.removeChan:
+			timer.Stop()
+			manager.removeEntry(id)
+			manager.Logger.Infof("Removed the job with ID - %v", id)
 		}
-		sort.Slice(manager.Jobs, sortByNextScheduleTime)
-		manager.Logger.Debugf("Joobs after sort by next schedule time - %v", manager.Jobs)
+	}
+}
 
-		var timer *time.Timer
-		if len(manager.Jobs) == 0 || manager.Jobs[0].GetCommonJobFields().NextRun.IsZero() {
-			// If there are no jobs yet, just sleep - it still handles new jobs and stop requests.
-			timer = time.NewTimer(100000 * time.Hour)
+// dispatchDueJobs sends a JobRun of every job whose NextRun is not after "now" to the JobRunner,
+// and moves the job in the queue as per its new NextRun.
+func (manager *JobManager) dispatchDueJobs(now time.Time) {
+	for job := manager.jobs.peek(); job != nil; job = manager.jobs.peek() {
+		fields := job.GetCommonJobFields()
+		manager.Logger.Infof("Next run - %v", fields.NextRun)
+		if fields.NextRun.After(now) || fields.NextRun.IsZero() {
+			return
+		}
+		scheduledAt := fields.NextRun
+		trigger, deferred := manager.deferredRuns[fields.ID]
+		if !deferred {
+			trigger = TRIGGER_SCHEDULE
+		}
+		window := manager.activeBlackout(fields, now)
+		if window != nil && manager.deferRun(job, window, trigger) {
+			continue
+		}
+		delete(manager.deferredRuns, fields.ID)
+		if window == nil {
+			// The run is counted before computing the next one, which is dropped once MaxRuns is reached.
+			fields.RunCount++
+		}
+		nextRun, err := manager.getNextScheduleTime(job, now)
+		if err != nil || (!nextRun.IsZero() && !nextRun.After(now)) {
+			// Keeping the job at the head of the queue would dispatch it again and again.
+			manager.Logger.Errorf("Invalid next run - %v of the job - %v after %v. The job will not be scheduled. Error - %v",
+				nextRun, fields.ID, now, err)
+			nextRun = time.Time{}
+		}
+		if fields.Schedule.isType(SCHEDULE_TYPE_INTERVAL) && window == nil && trigger != TRIGGER_DEPENDENCY {
+			// The next run is scheduled when this run finishes.
+			nextRun = time.Time{}
+		}
+		fields.NextRun = nextRun
+		manager.jobs.fix(fields.ID)
+		// The JobRun is created after updating the NextRun, so that its copy of the fields has the next
+		// schedule time (e.g. for the retries).
+		jobRun := manager.jobRunner.CreateJobRun(job, scheduledAt, trigger)
+		if window != nil {
+			manager.jobRunner.skipJobRun(jobRun, "Blackout window - "+window.ID)
 		} else {
-			timer = time.NewTimer(manager.Jobs[0].GetCommonJobFields().NextRun.Sub(now))
+			manager.jobRunChan <- jobRun
 		}
-		// Listen for any requests on the channels...
-		for {
-			select {
-			case now = <-timer.C:
-				now = now.In(manager.Location)
-				manager.Logger.Infof("Timer expired at - %v.", now)
-				// Run every entry whose next time was less than now
-				for _, job := range manager.Jobs {
-					manager.Logger.Infof("Next run - %v", job.GetCommonJobFields().NextRun)
-					if job.GetCommonJobFields().NextRun.After(now) || job.GetCommonJobFields().NextRun.IsZero() {
-						break
-					}
-					// send the job to the JobRunner as JobRun object.
-					jobRun := manager.jobRunner.CreateJobRun(job)
-					manager.jobRunChan <- jobRun
-					job.GetCommonJobFields().NextRun, _ = job.GetNextScheduleTime(now)
+		job.Save()
+	}
+}
+
+// now returns the current time of the JobManager's clock in the JobManager's location.
+func (manager *JobManager) now() time.Time {
+	return manager.clock.Now().In(manager.Location)
+}
+
+// getNextScheduleTime returns the next schedule time of the job after "t" within its active window. Zero
+// time is returned if the job has no more runs as per its schedule, EndAt or MaxRuns.
+func (manager *JobManager) getNextScheduleTime(job JobV2, t time.Time) (nextRun time.Time, err error) {
+	fields := job.GetCommonJobFields()
+	if fields.MaxRuns > 0 && fields.RunCount >= fields.MaxRuns {
+		return time.Time{}, nil
+	}
+	nextRun, err = manager.getScheduleTime(job, t)
+	if fields.EndAt != nil && nextRun.After(*fields.EndAt) {
+		return time.Time{}, err
+	}
+	return
+}
+
+// getScheduleTime returns the next schedule time of the job after "t" and not before its StartAt, as per
+// the job's Schedule if it has one, and allowed by the job's calendars. The time is always converted to the JobManager's location first, so
+// the jobs without a time zone are evaluated in that location irrespective of where "t" came from (e.g.
+// LastRun read from the resource file has a fixed offset).
+func (manager *JobManager) getScheduleTime(job JobV2, t time.Time) (nextRun time.Time, err error) {
+	fields := job.GetCommonJobFields()
+	if fields.StartAt != nil && t.Before(*fields.StartAt) {
+		// A run at StartAt itself is in the window.
+		t = fields.StartAt.Add(-time.Nanosecond)
+	}
+	if len(fields.Calendars) > 0 {
+		return manager.getCalendarScheduleTime(job, t.In(manager.Location))
+	}
+	if jobSchedule := fields.Schedule; jobSchedule != nil {
+		return jobSchedule.Next(t.In(manager.Location))
+	}
+	return job.GetNextScheduleTime(t.In(manager.Location))
+}
+
+// scheduleJob sets the NextRun of the job wrt "now" and persists it. Paused jobs get a zero NextRun. Before that, the runs missed
+// since the persisted LastRun of the job are dispatched as per the job's misfire policy.
+func (manager *JobManager) scheduleJob(job JobV2, now time.Time) {
+	fields := job.GetCommonJobFields()
+	if fields.Paused {
+		manager.Logger.Infof("Job - %v is paused. It will not be scheduled until it is resumed.", fields.ID)
+		fields.NextRun = time.Time{}
+		job.Save()
+		return
+	}
+	nextRun, err := manager.getNextScheduleTime(job, now)
+	if err != nil {
+		manager.Logger.Errorf("Failed to compute the next run of the job - %v. Error - %v", fields.ID, err)
+	}
+	if fields.Schedule.isType(SCHEDULE_TYPE_ONCE) && nextRun.IsZero() && fields.LastRun.Before(fields.Schedule.At) {
+		// The run of the Once schedule was missed (e.g. the daemon was down at the time). It runs right away.
+		nextRun = fields.Schedule.At
+	}
+	fields.NextRun = nextRun
+	manager.dispatchMissedRuns(job, now)
+	if fields.MaxRuns > 0 && fields.RunCount >= fields.MaxRuns {
+		// The catch-up runs used up the MaxRuns.
+		fields.NextRun = time.Time{}
+	}
+	job.Save()
+}
+
+// dispatchMissedRuns finds the schedule times of the job between its LastRun and "now" and sends
+// the catch-up runs to the JobRunner as per the job's MisfirePolicy.
+func (manager *JobManager) dispatchMissedRuns(job JobV2, now time.Time) {
+	fields := job.GetCommonJobFields()
+	// A missed run of the Once schedule is run through the NextRun.
+	if fields.LastRun.IsZero() || fields.MisfirePolicy == "" || fields.MisfirePolicy == MISFIRE_POLICY_SKIP ||
+		fields.Schedule.isType(SCHEDULE_TYPE_ONCE) {
+		return
+	}
+	maxCatchUpRuns := 1
+	if fields.MisfirePolicy == MISFIRE_POLICY_RUN_ALL {
+		maxCatchUpRuns = fields.MaxCatchUpRuns
+		if maxCatchUpRuns == 0 {
+			maxCatchUpRuns = DEFAULT_MAX_CATCH_UP_RUNS
+		}
+	}
+	if fields.MaxRuns > 0 {
+		maxCatchUpRuns = min(maxCatchUpRuns, fields.MaxRuns-fields.RunCount)
+	}
+	if maxCatchUpRuns <= 0 {
+		return
+	}
+	missedRuns, missedCount, countCapped := manager.getMissedRunTimes(job, now, maxCatchUpRuns)
+	if missedCount == 0 {
+		return
+	}
+	missedCountText := fmt.Sprint(missedCount)
+	if countCapped {
+		missedCountText = "≥" + missedCountText
+	}
+	if window := manager.activeBlackout(fields, now); window != nil {
+		if !manager.deferRun(job, window, TRIGGER_CATCH_UP) {
+			manager.Logger.Warnf("Job - %v missed %v run(s) since the last run at %v. Not dispatching the catch-up runs in the blackout window - %v.",
+				fields.ID, missedCountText, fields.LastRun, window.ID)
+		}
+		return
+	}
+	manager.Logger.Warnf("Job - %v missed %v run(s) since the last run at %v. Misfire policy - %v, dispatching %v catch-up run(s).",
+		fields.ID, missedCountText, fields.LastRun, fields.MisfirePolicy, len(missedRuns))
+	for _, scheduledAt := range missedRuns {
+		fields.RunCount++
+		jobRun := manager.jobRunner.CreateJobRun(job, scheduledAt, TRIGGER_CATCH_UP)
+		manager.jobRunChan <- jobRun
+	}
+}
+
+// getMissedRunTimes returns the latest "limit" schedule times of the job which fall in (LastRun, now], in chronological
+// order, along with the number of missed schedule times. At most MAX_MISSED_RUN_COUNT schedule times are counted, beyond
+// which countCapped is set and the count is a lower bound.
+func (manager *JobManager) getMissedRunTimes(job JobV2, now time.Time, limit int) (missedRuns []time.Time, missedCount int, countCapped bool) {
+	lastRun := job.GetCommonJobFields().LastRun
+	scheduleTimes, complete := manager.walkScheduleTimes(job, lastRun, now, max(limit, MAX_MISSED_RUN_COUNT))
+	missedCount = len(scheduleTimes)
+	if complete {
+		return scheduleTimes[max(0, len(scheduleTimes)-limit):], missedCount, false
+	}
+	// Too many runs were missed to walk all of them. Search for the latest start of the walk which still has "limit"
+	// schedule times up to "now", the walk from there gives the latest ones.
+	from, to := lastRun, now
+	for to.Sub(from) > time.Nanosecond {
+		middle := from.Add(to.Sub(from) / 2)
+		if scheduleTimes, _ = manager.walkScheduleTimes(job, middle, now, limit); len(scheduleTimes) == limit {
+			from = middle
+		} else {
+			to = middle
+		}
+	}
+	missedRuns, _ = manager.walkScheduleTimes(job, from, now, limit)
+	return missedRuns, missedCount, true
+}
+
+// walkScheduleTimes returns up to "maxCount" schedule times of the job which fall in (from, now], in chronological order.
+// complete tells whether there are no more schedule times in the range.
+func (manager *JobManager) walkScheduleTimes(job JobV2, from time.Time, now time.Time, maxCount int) (scheduleTimes []time.Time, complete bool) {
+	scheduledAt := from
+	for {
+		next, err := manager.getNextScheduleTime(job, scheduledAt)
+		if err != nil || next.IsZero() || next.After(now) || !next.After(scheduledAt) {
+			return scheduleTimes, true
+		}
+		if len(scheduleTimes) == maxCount {
+			return scheduleTimes, false
+		}
+		scheduledAt = next
+		scheduleTimes = append(scheduleTimes, scheduledAt)
+	}
+}
+
+// handleFinishedRun triggers the dependent jobs of the finished run. The next run of a job with the Interval
+// schedule is scheduled from the completion of its scheduled run (or of its retries), and a job whose
+// schedule has no more runs is expired.
+func (manager *JobManager) handleFinishedRun(jobRun *JobRun, now time.Time) {
+	// The start of the run may not be recorded yet, as the started and finished runs come on different channels.
+	manager.recordLastRun(jobRun)
+	// Skipped runs neither satisfy nor reset the dependencies.
+	if jobRun.Status() != RUN_STATUS_SKIPPED {
+		manager.dispatchDependentRuns(jobRun, now)
+	}
+	job := manager.jobs.get(jobRun.Fields.ID)
+	if job == nil {
+		return
+	}
+	fields := job.GetCommonJobFields()
+	if fields.Schedule.isType(SCHEDULE_TYPE_INTERVAL) && !fields.Paused && fields.NextRun.IsZero() &&
+		jobRun.OriginTrigger != TRIGGER_MANUAL && jobRun.OriginTrigger != TRIGGER_DEPENDENCY {
+		nextRun, err := manager.getNextScheduleTime(job, now)
+		if err != nil {
+			manager.Logger.Errorf("Failed to compute the next run of the job - %v. Error - %v", fields.ID, err)
+		}
+		fields.NextRun = nextRun
+		manager.jobs.fix(fields.ID)
+		job.Save()
+		manager.Logger.Infof("Run - %v of the job - %v finished. Next run at - %v", jobRun.ID, fields.ID, nextRun)
+	}
+	manager.expireJob(job, now)
+}
+
+// inActiveWindow tells whether a scheduler triggered run of the job can start at "now" as per its StartAt,
+// EndAt and MaxRuns.
+func (manager *JobManager) inActiveWindow(job JobV2, now time.Time) bool {
+	fields := job.GetCommonJobFields()
+	return (fields.StartAt == nil || !now.Before(*fields.StartAt)) && (fields.EndAt == nil || !now.After(*fields.EndAt)) &&
+		(fields.MaxRuns == 0 || fields.RunCount < fields.MaxRuns)
+}
+
+// expiryReason tells why the job can't run anymore as per its schedule, EndAt or MaxRuns. Empty reason is
+// returned if the job can still run.
+func (manager *JobManager) expiryReason(job JobV2, now time.Time) (reason string) {
+	fields := job.GetCommonJobFields()
+	if fields.Schedule.isType(SCHEDULE_TYPE_ONCE) && !fields.LastRun.IsZero() && !fields.LastRun.Before(fields.Schedule.At) {
+		return "Once schedule ran at " + fields.LastRun.String()
+	}
+	if fields.MaxRuns > 0 && fields.RunCount >= fields.MaxRuns {
+		return fmt.Sprintf("Reached MaxRuns - %v", fields.MaxRuns)
+	}
+	if fields.EndAt == nil {
+		return ""
+	}
+	if !fields.EndAt.After(now) {
+		return "Reached EndAt - " + fields.EndAt.String()
+	}
+	// A scheduled job expires as soon as its next schedule time is after EndAt. The dependency triggered
+	// jobs (without a schedule time) expire only at EndAt.
+	if nextRun, err := manager.getScheduleTime(job, now); err == nil && nextRun.After(*fields.EndAt) {
+		return "No schedule time before EndAt - " + fields.EndAt.String()
+	}
+	return ""
+}
+
+// expireJob disables or deletes the job as per its ExpiryAction if it can't run anymore. A job with
+// active runs is expired when the last of them finishes.
+func (manager *JobManager) expireJob(job JobV2, now time.Time) {
+	fields := job.GetCommonJobFields()
+	if fields.Paused {
+		return
+	}
+	reason := manager.expiryReason(job, now)
+	if reason == "" || manager.jobRunner.countRunningJobRuns(fields.ID)+manager.jobRunner.countPendingJobRuns(fields.ID) > 0 {
+		return
+	}
+	manager.observers.publish(EVENT_JOB_EXPIRED, fields.ID, nil)
+	if fields.ExpiryAction == EXPIRY_ACTION_DELETE {
+		// The jobs depending on the expired job would refer to a missing job, so it is disabled instead.
+		if dependents := manager.getDependentJobs(fields.ID); len(dependents) > 0 {
+			manager.Logger.Warnf("Job - %v expired. %v. Disabling the job instead of deleting it, as the jobs - %v depend on it.",
+				fields.ID, reason, dependents)
+		} else {
+			manager.Logger.Infof("Job - %v expired. %v. Deleting the job.", fields.ID, reason)
+			manager.removeEntry(fields.ID)
+			if deletable, ok := job.(DeletableJob); ok {
+				if err := deletable.Delete(); err != nil {
+					manager.Logger.Errorf("Failed to delete the expired job - %v. Error - %v", fields.ID, err)
 				}
+			}
+			return
+		}
+	} else {
+		manager.Logger.Infof("Job - %v expired. %v. Disabling the job.", fields.ID, reason)
+	}
+	fields.Paused = true
+	fields.NextRun = time.Time{}
+	fields.ExpiredAt = &now
+	fields.ExpiryReason = reason
+	manager.jobs.fix(fields.ID)
+	job.Save()
+	manager.observers.publish(EVENT_JOB_UPDATED, fields.ID, nil)
+}
 
-			case newEntry := <-manager.addChan:
-				timer.Stop()
-				now = time.Now().In(manager.Location)
-				newEntry.GetCommonJobFields().NextRun, _ = newEntry.GetNextScheduleTime(now)
-				manager.Jobs = append(manager.Jobs, newEntry)
-				manager.Logger.Infof("Added the job with ID - %v. Current time - %v, Next run at - %v",
-					newEntry.GetCommonJobFields().ID, now, newEntry.GetCommonJobFields().NextRun)
-
-			case <-manager.stopChan:
-				timer.Stop()
-				manager.Logger.Infof("Recieved signal on Stop channel. Stopped the scheduler...")
-				return
-
-			case id := <-manager.removeChan:
-				timer.Stop()
-				now = now.In(manager.Location)
-				manager.removeEntry(id)
-				manager.Logger.Infof("Removed the job with ID - %v", id)
+// getDependentJobs returns the IDs of the jobs which depend on the given job.
+func (manager *JobManager) getDependentJobs(jobId JobId) (dependents []JobId) {
+	for _, job := range manager.jobs.all() {
+		if fields := job.GetCommonJobFields(); fields.DependsOnJob(jobId) {
+			dependents = append(dependents, fields.ID)
+		}
+	}
+	return
+}
+
+// dispatchDependentRuns records the finished run and triggers the jobs which depend on its job, when
+// the latest runs of all their upstream jobs satisfy the dependency conditions.
+func (manager *JobManager) dispatchDependentRuns(upstreamRun *JobRun, now time.Time) {
+	upstreamId := upstreamRun.Job.GetCommonJobFields().ID
+	manager.finishedRuns[upstreamId] = upstreamRun
+	for _, job := range manager.jobs.all() {
+		fields := job.GetCommonJobFields()
+		if !fields.DependsOnJob(upstreamId) {
+			continue
+		}
+		if fields.Paused {
+			manager.Logger.Infof("Job - %v is paused. Not triggering it after the run - %v of the upstream job - %v",
+				fields.ID, upstreamRun.ID, upstreamId)
+			continue
+		}
+		if !manager.dependenciesSatisfied(job) {
+			continue
+		}
+		if !manager.inActiveWindow(job, now) {
+			manager.Logger.Infof("Job - %v is outside its active window or reached its MaxRuns. Not triggering it after the run - %v of the upstream job - %v",
+				fields.ID, upstreamRun.ID, upstreamId)
+			manager.expireJob(job, now)
+			continue
+		}
+		manager.dependencyTriggeredAt[fields.ID] = now
+		if window := manager.activeBlackout(fields, now); window != nil {
+			if !manager.deferRun(job, window, TRIGGER_DEPENDENCY) {
+				jobRun := manager.jobRunner.CreateJobRun(job, now, TRIGGER_DEPENDENCY)
+				jobRun.UpstreamRunID = upstreamRun.ID
+				manager.jobRunner.skipJobRun(jobRun, "Blackout window - "+window.ID)
 			}
-			break
+			continue
 		}
+		fields.RunCount++
+		job.Save()
+		jobRun := manager.jobRunner.CreateJobRun(job, now, TRIGGER_DEPENDENCY)
+		jobRun.UpstreamRunID = upstreamRun.ID
+		manager.jobRunChan <- jobRun
+		manager.Logger.Infof("Dispatched the run - %v of the job - %v after the run - %v of the upstream job - %v",
+			jobRun.ID, fields.ID, upstreamRun.ID, upstreamId)
 	}
 }
 
-func (manager *JobManager) removeEntry(id JobId) {
-	for i, job := range manager.Jobs {
-		if job.GetCommonJobFields().ID == id {
-			manager.Logger.Infof("Found the element to remove at the index - %v", i)
-			manager.jobLock.Lock()
-			defer manager.jobLock.Unlock()
-			manager.Jobs = append(manager.Jobs[:i], manager.Jobs[i+1:]...)
-			manager.Logger.Infof("Successfully removed the job with ID - %v", id)
-			break
+// dependenciesSatisfied tells whether every upstream job of the job has finished a run after the
+// job was last triggered by its dependencies, and the run satisfies the dependency condition.
+func (manager *JobManager) dependenciesSatisfied(job JobV2) bool {
+	fields := job.GetCommonJobFields()
+	triggeredAt := manager.dependencyTriggeredAt[fields.ID]
+	for _, dependency := range fields.DependsOn {
+		upstreamRun, found := manager.finishedRuns[dependency.JobID]
+		if !found || (!triggeredAt.IsZero() && !upstreamRun.CompletedAt.After(triggeredAt)) {
+			manager.Logger.Infof("Job - %v is waiting for a run of the upstream job - %v", fields.ID, dependency.JobID)
+			return false
 		}
+		if !dependency.Condition.IsMetBy(upstreamRun.Status()) {
+			manager.Logger.Infof("Job - %v is not triggered. Run - %v of the upstream job - %v has the status - %v, condition - %v",
+				fields.ID, upstreamRun.ID, dependency.JobID, upstreamRun.Status(), dependency.Condition)
+			return false
+		}
+	}
+	return true
+}
+
+func (manager *JobManager) removeEntry(id JobId) {
+	if manager.jobs.remove(id) == nil {
+		manager.Logger.Infof("Job with ID - %v is not in the job manager", id)
+		return
 	}
+	delete(manager.finishedRuns, id)
+	delete(manager.dependencyTriggeredAt, id)
+	delete(manager.deferredRuns, id)
+	manager.observers.publish(EVENT_JOB_REMOVED, id, nil)
+	manager.Logger.Infof("Successfully removed the job with ID - %v", id)
 }
@@ -0,0 +1,574 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/shreyasksrao/jobmanager/lib/schedule"
+)
+
+type testLogger struct{}
+
+func (testLogger) Errorf(template string, args ...any) {}
+func (testLogger) Warnf(template string, args ...any)  {}
+func (testLogger) Infof(template string, args ...any)  {}
+func (testLogger) Debugf(template string, args ...any) {}
+
+// testJob is a Job scheduled by a cron expression which doesn't run anything.
+type testJob struct {
+	CommonJobFields CommonJobFields
+	cronSchedule    *schedule.CronSchedule
+}
+
+func newTestJob(t testing.TB, id JobId, cronExpr string) *testJob {
+	t.Helper()
+	cronSchedule, err := schedule.ParseCron(cronExpr)
+	if err != nil {
+		t.Fatalf("failed to parse the cron expression %v: %v", cronExpr, err)
+	}
+	return &testJob{CommonJobFields: CommonJobFields{ID: id}, cronSchedule: cronSchedule}
+}
+
+func (job *testJob) Execute() (err error)          { return nil }
+func (job *testJob) Stop()                         {}
+func (job *testJob) Save() (saved bool, err error) { return true, nil }
+func (job *testJob) GetCommonJobFields() *CommonJobFields {
+	return &job.CommonJobFields
+}
+
+func (job *testJob) GetNextScheduleTime(now time.Time) (nextRun time.Time, err error) {
+	location, err := job.CommonJobFields.GetLocation(now.Location())
+	if err != nil {
+		return
+	}
+	job.cronSchedule.SkippedTime = job.CommonJobFields.DSTSkippedTime
+	job.cronSchedule.RepeatedTime = job.CommonJobFields.DSTRepeatedTime
+	return job.cronSchedule.Next(now.In(location)), nil
+}
+
+// testJobV2 is a JobV2 version of testJob, which can be cloned as a whole.
+type testJobV2 struct {
+	testJob
+	executeErr error // Returned by every run
+}
+
+func (job *testJobV2) Execute(ctx context.Context, info RunInfo) (result Result, err error) {
+	return result, job.executeErr
+}
+
+func (job *testJobV2) Clone() JobV2 {
+	clone := *job
+	clone.CommonJobFields = job.CommonJobFields.Copy()
+	return &clone
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("failed to load the location %v: %v", name, err)
+	}
+	return location
+}
+
+func mustParseTime(t testing.TB, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("failed to parse the time %v: %v", value, err)
+	}
+	return parsed
+}
+
+func newTestJobManager(clock Clock, location *time.Location) *JobManager {
+	return NewJobManager(&JobManagerConfig{
+		Location:         location,
+		JobManagerLogger: testLogger{},
+		JobRunnerLogger:  testLogger{},
+		Clock:            clock,
+	})
+}
+
+// startTestScheduler runs only the scheduler go-routine so that the dispatched JobRuns
+// can be read from the job run channel instead of being executed.
+func startTestScheduler(t *testing.T, manager *JobManager) {
+	done := make(chan struct{})
+	manager.runningMu.Lock()
+	manager.running = true
+	manager.schedulerDone = done
+	manager.runningMu.Unlock()
+	go manager.runScheduler(done)
+	t.Cleanup(func() {
+		manager.runningMu.Lock()
+		manager.running = false
+		manager.runningMu.Unlock()
+		manager.stopChan <- struct{}{}
+		<-done
+	})
+}
+
+// collectScheduledRuns fires the scheduler timers and returns the ScheduledAt of the next "count" JobRuns.
+func collectScheduledRuns(t *testing.T, manager *JobManager, clock *fakeClock, count int) (scheduledAt []time.Time) {
+	t.Helper()
+	for len(scheduledAt) < count {
+		clock.fireNextTimer(t)
+		for drained := false; !drained; {
+			select {
+			case jobRun := <-manager.jobRunChan:
+				scheduledAt = append(scheduledAt, jobRun.ScheduledAt)
+			case <-time.After(50 * time.Millisecond):
+				drained = true
+			}
+		}
+	}
+	return
+}
+
+func assertScheduledRuns(t *testing.T, got []time.Time, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %v runs %v, want %v runs %v", len(got), got, len(want), want)
+	}
+	for i := range want {
+		if !got[i].Equal(mustParseTime(t, want[i])) {
+			t.Fatalf("run #%v: got %v, want %v", i, got[i].UTC().Format(time.RFC3339), want[i])
+		}
+	}
+}
+
+// TestSchedulerDST runs the scheduler on a fake clock through the daylight saving transitions
+// and checks the schedule times of the dispatched JobRuns.
+func TestSchedulerDST(t *testing.T) {
+	tests := []struct {
+		name            string
+		managerLocation string
+		jobTimezone     string
+		cronExpr        string
+		repeated        schedule.RepeatedTimePolicy
+		start           string
+		want            []string
+	}{
+		{
+			name:            "skipped hour in the manager location",
+			managerLocation: "America/New_York",
+			cronExpr:        "30 2 * * *",
+			start:           "2026-03-07T17:00:00Z",
+			want:            []string{"2026-03-08T07:00:00Z", "2026-03-09T06:30:00Z", "2026-03-10T06:30:00Z"},
+		},
+		{
+			name:            "repeated hour in the job time zone",
+			managerLocation: "UTC",
+			jobTimezone:     "Europe/Berlin",
+			cronExpr:        "30 2 * * *",
+			repeated:        schedule.REPEATED_TIME_RUN_TWICE,
+			start:           "2026-10-24T12:00:00Z",
+			want:            []string{"2026-10-25T00:30:00Z", "2026-10-25T01:30:00Z", "2026-10-26T01:30:00Z"},
+		},
+		{
+			name:            "repeated hour runs once by default",
+			managerLocation: "Australia/Sydney",
+			cronExpr:        "*/30 2 * * *",
+			start:           "2026-04-04T15:10:00Z",
+			want:            []string{"2026-04-04T15:30:00Z", "2026-04-05T16:00:00Z", "2026-04-05T16:30:00Z"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := newFakeClock(mustParseTime(t, test.start))
+			manager := newTestJobManager(clock, mustLoadLocation(t, test.managerLocation))
+			job := newTestJob(t, "dst-job", test.cronExpr)
+			job.CommonJobFields.Timezone = test.jobTimezone
+			job.CommonJobFields.DSTRepeatedTime = test.repeated
+			manager.AddJob(job)
+			startTestScheduler(t, manager)
+			got := collectScheduledRuns(t, manager, clock, len(test.want))
+			assertScheduledRuns(t, got, test.want)
+		})
+	}
+}
+
+// TestJobManagerConcurrentAccess calls the JobManager APIs from several go-routines while the
+// scheduler and the runner are running. Run with -race to catch the unsynchronized accesses.
+func TestJobManagerConcurrentAccess(t *testing.T) {
+	manager := newTestJobManager(nil, time.UTC)
+	manager.AddJob(newTestJob(t, "shared-job", "* * * * *"))
+	if err := manager.Start(); err != nil {
+		t.Fatalf("failed to start the job manager: %v", err)
+	}
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				jobId := JobId(fmt.Sprintf("job-%v-%v", worker, i))
+				manager.AddJob(newTestJob(t, jobId, "* * * * *"))
+				if jobRun, err := manager.RunNow(jobId); err == nil {
+					jobRun.Wait()
+				}
+				if jobRun, err := manager.RunNow("shared-job"); err == nil {
+					defer jobRun.Wait()
+				}
+				manager.PauseJob(jobId)
+				manager.ResumeJob(jobId)
+				manager.ListJobs()
+				if job, found := manager.GetJob(jobId); found {
+					job.GetCommonJobFields().NextRun = time.Time{}
+				}
+				manager.JobStatus("shared-job")
+				manager.RemoveJob(string(jobId))
+			}
+		}(worker)
+	}
+	wg.Wait()
+	if err := manager.Stop(); err != nil {
+		t.Fatalf("failed to stop the job manager: %v", err)
+	}
+	jobs := manager.ListJobs()
+	if len(jobs) != 1 || jobs[0].GetCommonJobFields().ID != "shared-job" {
+		t.Fatalf("got %v jobs after the removals, want only the shared-job", len(jobs))
+	}
+	if status, found := manager.JobStatus("shared-job"); !found || status.CommonJobFields.LastRun.IsZero() {
+		t.Fatalf("got the status %+v of the shared-job, want the LastRun of the manual runs", status)
+	}
+}
+
+// TestJobManagerUpdateJob checks that UpdateJob recomputes the NextRun of the changed job and leaves
+// the job untouched when the change is rejected.
+func TestJobManagerUpdateJob(t *testing.T) {
+	clock := newFakeClock(mustParseTime(t, "2026-01-01T10:00:00Z"))
+	manager := newTestJobManager(clock, time.UTC)
+	manager.AddJobV2(&testJobV2{testJob: *newTestJob(t, "noon-job", "0 12 * * *")})
+	startTestScheduler(t, manager)
+
+	updated, err := manager.UpdateJob("noon-job", func(job JobV2) error {
+		job.GetCommonJobFields().Timezone = "Asia/Kolkata"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to update the job: %v", err)
+	}
+	want := mustParseTime(t, "2026-01-02T06:30:00Z")
+	if nextRun := updated.GetCommonJobFields().NextRun; !nextRun.Equal(want) {
+		t.Fatalf("got the NextRun %v after the update, want %v", nextRun, want)
+	}
+
+	rejected := []func(job JobV2) error{
+		func(job JobV2) error {
+			job.GetCommonJobFields().Timezone = "Europe/Berlin"
+			return fmt.Errorf("invalid job")
+		},
+		func(job JobV2) error {
+			job.GetCommonJobFields().ID = "other-job"
+			return nil
+		},
+		func(job JobV2) error {
+			job.GetCommonJobFields().DependsOn = []JobDependency{{JobID: "unknown-job"}}
+			return nil
+		},
+	}
+	for i, mutate := range rejected {
+		if _, err := manager.UpdateJob("noon-job", mutate); err == nil {
+			t.Fatalf("update #%v: got no error, want the update to be rejected", i)
+		}
+	}
+	job, found := manager.GetJob("noon-job")
+	if !found {
+		t.Fatalf("job is missing after the rejected updates")
+	}
+	if fields := job.GetCommonJobFields(); fields.Timezone != "Asia/Kolkata" || !fields.NextRun.Equal(want) || len(fields.DependsOn) != 0 {
+		t.Fatalf("got the job fields %+v after the rejected updates, want them unchanged", fields)
+	}
+}
+
+// TestSchedulerMissedRuns starts the scheduler on a fake clock with jobs whose LastRun is in the past and
+// checks the catch-up runs dispatched as per the misfire policy.
+func TestSchedulerMissedRuns(t *testing.T) {
+	tests := []struct {
+		name           string
+		cronExpr       string
+		lastRun        string
+		policy         MisfirePolicy
+		maxCatchUpRuns int
+		wantRuns       []string
+		wantCount      int
+		wantCapped     bool
+	}{
+		{
+			name:      "skip",
+			cronExpr:  "0 * * * *",
+			lastRun:   "2026-03-01T06:30:00Z",
+			policy:    MISFIRE_POLICY_SKIP,
+			wantCount: 5,
+		},
+		{
+			name:      "run once",
+			cronExpr:  "0 * * * *",
+			lastRun:   "2026-03-01T06:30:00Z",
+			policy:    MISFIRE_POLICY_RUN_ONCE,
+			wantRuns:  []string{"2026-03-01T11:00:00Z"},
+			wantCount: 5,
+		},
+		{
+			name:           "run all up to the cap",
+			cronExpr:       "0 * * * *",
+			lastRun:        "2026-03-01T06:30:00Z",
+			policy:         MISFIRE_POLICY_RUN_ALL,
+			maxCatchUpRuns: 3,
+			wantRuns:       []string{"2026-03-01T09:00:00Z", "2026-03-01T10:00:00Z", "2026-03-01T11:00:00Z"},
+			wantCount:      5,
+		},
+		{
+			name:      "run all with the default cap",
+			cronExpr:  "0 * * * *",
+			lastRun:   "2026-03-01T08:30:00Z",
+			policy:    MISFIRE_POLICY_RUN_ALL,
+			wantRuns:  []string{"2026-03-01T09:00:00Z", "2026-03-01T10:00:00Z", "2026-03-01T11:00:00Z"},
+			wantCount: 3,
+		},
+		{
+			name:           "run all after a month of every second runs",
+			cronExpr:       "* * * * * *",
+			lastRun:        "2026-02-01T11:30:00Z",
+			policy:         MISFIRE_POLICY_RUN_ALL,
+			maxCatchUpRuns: 2,
+			wantRuns:       []string{"2026-03-01T11:29:59Z", "2026-03-01T11:30:00Z"},
+			wantCount:      MAX_MISSED_RUN_COUNT,
+			wantCapped:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			now := mustParseTime(t, "2026-03-01T11:30:00Z")
+			clock := newFakeClock(now)
+			manager := newTestJobManager(clock, time.UTC)
+			job := &testJobV2{testJob: *newTestJob(t, "missed-job", test.cronExpr)}
+			job.CommonJobFields.LastRun = mustParseTime(t, test.lastRun)
+			job.CommonJobFields.MisfirePolicy = test.policy
+			job.CommonJobFields.MaxCatchUpRuns = test.maxCatchUpRuns
+
+			if _, missedCount, countCapped := manager.getMissedRunTimes(job, now, 1); missedCount != test.wantCount || countCapped != test.wantCapped {
+				t.Fatalf("got %v missed runs (capped %v), want %v (capped %v)", missedCount, countCapped, test.wantCount, test.wantCapped)
+			}
+
+			manager.AddJobV2(job)
+			startTestScheduler(t, manager)
+			var got []time.Time
+			for drained := false; !drained; {
+				select {
+				case jobRun := <-manager.jobRunChan:
+					if jobRun.Trigger != TRIGGER_CATCH_UP {
+						t.Fatalf("got a run with the trigger %v, want %v", jobRun.Trigger, TRIGGER_CATCH_UP)
+					}
+					got = append(got, jobRun.ScheduledAt)
+				case <-time.After(100 * time.Millisecond):
+					drained = true
+				}
+			}
+			assertScheduledRuns(t, got, test.wantRuns)
+		})
+	}
+}
+
+// TestJobManagerRunNow triggers the manual runs of the jobs and waits for their records.
+func TestJobManagerRunNow(t *testing.T) {
+	manager := newTestJobManager(nil, time.UTC)
+	manager.AddJobV2(&testJobV2{testJob: *newTestJob(t, "ok-job", "0 0 1 1 *")})
+	manager.AddJobV2(&testJobV2{testJob: *newTestJob(t, "failing-job", "0 0 1 1 *"), executeErr: fmt.Errorf("exit status 1")})
+	if _, err := manager.RunNow("ok-job"); err == nil {
+		t.Fatalf("got no error for a manual run while the job manager is not running")
+	}
+	if err := manager.Start(); err != nil {
+		t.Fatalf("failed to start the job manager: %v", err)
+	}
+	defer manager.Stop()
+
+	tests := []struct {
+		jobId      JobId
+		wantErr    bool
+		wantStatus RunStatus
+		wantError  string
+	}{
+		{jobId: "ok-job", wantStatus: RUN_STATUS_SUCCEEDED},
+		{jobId: "failing-job", wantStatus: RUN_STATUS_FAILED, wantError: "exit status 1"},
+		{jobId: "missing-job", wantErr: true},
+	}
+	for _, test := range tests {
+		jobRun, err := manager.RunNow(test.jobId)
+		if (err != nil) != test.wantErr {
+			t.Fatalf("%v: got the error %v, want error %v", test.jobId, err, test.wantErr)
+		}
+		if test.wantErr {
+			continue
+		}
+		record := jobRun.Wait()
+		if record.JobID != test.jobId || record.Trigger != TRIGGER_MANUAL || record.Status != test.wantStatus || record.Error != test.wantError {
+			t.Fatalf("%v: got the record %+v, want a manual run with the status %v and the error %q",
+				test.jobId, record, test.wantStatus, test.wantError)
+		}
+		if record.StartedAt.IsZero() || record.CompletedAt.Before(record.StartedAt) {
+			t.Fatalf("%v: got the run times %v - %v, want the completed run", test.jobId, record.StartedAt, record.CompletedAt)
+		}
+	}
+}
+
+// TestJobManagerRunNowRetries waits for the last attempt of a manual run which is retried.
+func TestJobManagerRunNowRetries(t *testing.T) {
+	manager := newTestJobManager(nil, time.UTC)
+	job := &testJobV2{testJob: *newTestJob(t, "retried-job", "0 0 1 1 *"), executeErr: fmt.Errorf("exit status 1")}
+	job.CommonJobFields.RetryPolicy = &RetryPolicy{MaxAttempts: 3, InitialDelay: Duration(10 * time.Millisecond)}
+	manager.AddJobV2(job)
+	if err := manager.Start(); err != nil {
+		t.Fatalf("failed to start the job manager: %v", err)
+	}
+	defer manager.Stop()
+
+	jobRun, err := manager.RunNow("retried-job")
+	if err != nil {
+		t.Fatalf("got the error %v for the manual run", err)
+	}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := jobRun.LastAttempt(cancelled); err == nil {
+		t.Fatalf("got no error while waiting with a cancelled context")
+	}
+	waitCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	lastAttempt, err := jobRun.LastAttempt(waitCtx)
+	if err != nil {
+		t.Fatalf("got the error %v while waiting for the last attempt", err)
+	}
+	record := lastAttempt.Record()
+	if record.Attempt != 3 || record.ParentRunID != jobRun.ID || record.Trigger != TRIGGER_RETRY || record.Status != RUN_STATUS_FAILED {
+		t.Fatalf("got the record %+v, want the failed third attempt of the run %v", record, jobRun.ID)
+	}
+	// Wait() covers only the first attempt.
+	if record := jobRun.Wait(); record.Attempt != 1 || record.Status != RUN_STATUS_FAILED {
+		t.Fatalf("got the record %+v of the first attempt, want the failed first attempt", record)
+	}
+	if again, _ := lastAttempt.LastAttempt(waitCtx); again != lastAttempt {
+		t.Fatalf("got the attempt %v after the last attempt %v, want the same", again.ID, lastAttempt.ID)
+	}
+
+	// The pending retry is skipped when the job manager stops, which releases the waiters.
+	_, err = manager.UpdateJob("retried-job", func(job JobV2) (err error) {
+		job.GetCommonJobFields().RetryPolicy = &RetryPolicy{MaxAttempts: 3, InitialDelay: Duration(time.Hour)}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("got the error %v while updating the job", err)
+	}
+	if jobRun, err = manager.RunNow("retried-job"); err != nil {
+		t.Fatalf("got the error %v for the manual run", err)
+	}
+	jobRun.Wait()
+	if err := manager.Stop(); err != nil {
+		t.Fatalf("failed to stop the job manager: %v", err)
+	}
+	if lastAttempt, err = jobRun.LastAttempt(waitCtx); err != nil {
+		t.Fatalf("got the error %v while waiting for the last attempt", err)
+	}
+	if record := lastAttempt.Record(); record.Attempt != 2 || record.Status != RUN_STATUS_SKIPPED {
+		t.Fatalf("got the record %+v, want the skipped second attempt", record)
+	}
+}
+
+// TestJobManagerRestart stops the job manager and starts it again.
+func TestJobManagerRestart(t *testing.T) {
+	manager := newTestJobManager(nil, time.UTC)
+	manager.AddJobV2(&testJobV2{testJob: *newTestJob(t, "restarted-job", "0 0 1 1 *")})
+	for i := 0; i < 3; i++ {
+		if err := manager.Start(); err != nil {
+			t.Fatalf("start #%v: got the error %v", i, err)
+		}
+		if err := manager.Start(); err == nil {
+			t.Fatalf("start #%v: got no error while starting the running job manager", i)
+		}
+		jobRun, err := manager.RunNow("restarted-job")
+		if err != nil {
+			t.Fatalf("start #%v: got the error %v for a manual run", i, err)
+		}
+		if record := jobRun.Wait(); record.Status != RUN_STATUS_SUCCEEDED {
+			t.Fatalf("start #%v: got the status %v of the manual run, want succeeded", i, record.Status)
+		}
+		if err := manager.Stop(); err != nil {
+			t.Fatalf("stop #%v: got the error %v", i, err)
+		}
+	}
+}
+
+// drainDispatchedRuns reads the JobRuns dispatched by the scheduler until none comes for a while.
+func drainDispatchedRuns(manager *JobManager) (jobRuns []*JobRun) {
+	for {
+		select {
+		case jobRun := <-manager.jobRunChan:
+			jobRuns = append(jobRuns, jobRun)
+		case <-time.After(100 * time.Millisecond):
+			return
+		}
+	}
+}
+
+// TestJobManagerPauseResume pauses the jobs past their NextRun and resumes them after a few missed runs.
+func TestJobManagerPauseResume(t *testing.T) {
+	clock := newFakeClock(mustParseTime(t, "2026-03-01T10:30:00Z"))
+	manager := newTestJobManager(clock, time.UTC)
+	for _, policy := range []MisfirePolicy{MISFIRE_POLICY_RUN_ALL, MISFIRE_POLICY_SKIP} {
+		job := &testJobV2{testJob: *newTestJob(t, JobId(policy), "0 * * * *")}
+		job.CommonJobFields.LastRun = mustParseTime(t, "2026-03-01T10:00:00Z")
+		job.CommonJobFields.MisfirePolicy = policy
+		manager.AddJobV2(job)
+	}
+	// The timers of this job move the clock while the other jobs are paused.
+	manager.AddJobV2(&testJobV2{testJob: *newTestJob(t, "active-job", "0 * * * *")})
+	if err := manager.PauseJob("missing-job"); err == nil {
+		t.Fatalf("got no error while pausing a missing job before the start")
+	}
+	startTestScheduler(t, manager)
+
+	for _, jobId := range []JobId{"missing-job", JobId(MISFIRE_POLICY_RUN_ALL), JobId(MISFIRE_POLICY_SKIP)} {
+		if err := manager.PauseJob(jobId); (err != nil) != (jobId == "missing-job") {
+			t.Fatalf("got the error %v while pausing the job %v", err, jobId)
+		}
+	}
+	if err := manager.ResumeJob("missing-job"); err == nil {
+		t.Fatalf("got no error while resuming a missing job")
+	}
+	for _, want := range []string{"2026-03-01T11:00:00Z", "2026-03-01T12:00:00Z", "2026-03-01T13:00:00Z"} {
+		clock.fireNextTimer(t)
+		jobRuns := drainDispatchedRuns(manager)
+		if len(jobRuns) != 1 || jobRuns[0].Fields.ID != "active-job" || !jobRuns[0].ScheduledAt.Equal(mustParseTime(t, want)) {
+			t.Fatalf("got the runs %+v, want only the run of the active-job at %v", jobRuns, want)
+		}
+	}
+
+	clock.mu.Lock()
+	clock.now = mustParseTime(t, "2026-03-01T13:30:00Z")
+	clock.mu.Unlock()
+	wantRuns := map[MisfirePolicy][]string{
+		MISFIRE_POLICY_RUN_ALL: {"2026-03-01T11:00:00Z", "2026-03-01T12:00:00Z", "2026-03-01T13:00:00Z"},
+		MISFIRE_POLICY_SKIP:    nil,
+	}
+	for policy, want := range wantRuns {
+		if err := manager.ResumeJob(JobId(policy)); err != nil {
+			t.Fatalf("got the error %v while resuming the job %v", err, policy)
+		}
+		var got []time.Time
+		for _, jobRun := range drainDispatchedRuns(manager) {
+			if jobRun.Fields.ID != JobId(policy) || jobRun.Trigger != TRIGGER_CATCH_UP {
+				t.Fatalf("got a run of the job %v with the trigger %v, want the catch-up runs of %v", jobRun.Fields.ID, jobRun.Trigger, policy)
+			}
+			got = append(got, jobRun.ScheduledAt)
+		}
+		assertScheduledRuns(t, got, want)
+		// The next run is computed from the time of the resume, not from the missed runs.
+		waitForJob(t, manager, JobId(policy), func(job JobV2, found bool) bool {
+			fields := job.GetCommonJobFields()
+			return found && !fields.Paused && fields.NextRun.Equal(mustParseTime(t, "2026-03-01T14:00:00Z"))
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package core
+
+import "container/heap"
+
+// jobQueue is a min-heap of the jobs ordered by NextRun, used by the scheduler to find the next job
+// to run. Jobs without a NextRun (paused or unscheduled) sort last. The position of every job is
+// tracked, so a job can be removed or re-positioned after a NextRun change in O(log n).
+type jobQueue struct {
+	jobs  []JobV2
+	index map[JobId]int
+}
+
+func newJobQueue() *jobQueue {
+	return &jobQueue{index: make(map[JobId]int)}
+}
+
+// Len, Less, Swap, Push and Pop implement heap.Interface. Use the other methods to modify the queue.
+func (queue *jobQueue) Len() int {
+	return len(queue.jobs)
+}
+
+func (queue *jobQueue) Less(a, b int) bool {
+	aNext := queue.jobs[a].GetCommonJobFields().NextRun
+	bNext := queue.jobs[b].GetCommonJobFields().NextRun
+	if aNext.IsZero() {
+		return false
+	}
+	if bNext.IsZero() {
+		return true
+	}
+	return aNext.Before(bNext)
+}
+
+func (queue *jobQueue) Swap(a, b int) {
+	queue.jobs[a], queue.jobs[b] = queue.jobs[b], queue.jobs[a]
+	queue.index[queue.jobs[a].GetCommonJobFields().ID] = a
+	queue.index[queue.jobs[b].GetCommonJobFields().ID] = b
+}
+
+func (queue *jobQueue) Push(x any) {
+	job := x.(JobV2)
+	queue.index[job.GetCommonJobFields().ID] = len(queue.jobs)
+	queue.jobs = append(queue.jobs, job)
+}
+
+func (queue *jobQueue) Pop() any {
+	last := len(queue.jobs) - 1
+	job := queue.jobs[last]
+	queue.jobs[last] = nil
+	queue.jobs = queue.jobs[:last]
+	delete(queue.index, job.GetCommonJobFields().ID)
+	return job
+}
+
+// init restores the heap order after the NextRun of many jobs changed.
+func (queue *jobQueue) init() {
+	heap.Init(queue)
+}
+
+// add adds the job to the queue. A job with the same ID is replaced.
+func (queue *jobQueue) add(job JobV2) {
+	if _, exists := queue.index[job.GetCommonJobFields().ID]; exists {
+		queue.remove(job.GetCommonJobFields().ID)
+	}
+	heap.Push(queue, job)
+}
+
+// remove removes the job from the queue and returns it. Nil is returned if the job is not in the queue.
+func (queue *jobQueue) remove(jobId JobId) (job JobV2) {
+	i, exists := queue.index[jobId]
+	if !exists {
+		return nil
+	}
+	return heap.Remove(queue, i).(JobV2)
+}
+
+// fix re-positions the job after its NextRun changed.
+func (queue *jobQueue) fix(jobId JobId) {
+	if i, exists := queue.index[jobId]; exists {
+		heap.Fix(queue, i)
+	}
+}
+
+// peek returns the job with the earliest NextRun without removing it. Nil is returned for an empty queue.
+func (queue *jobQueue) peek() (job JobV2) {
+	if len(queue.jobs) == 0 {
+		return nil
+	}
+	return queue.jobs[0]
+}
+
+// get returns the job with the ID. Nil is returned if the job is not in the queue.
+func (queue *jobQueue) get(jobId JobId) (job JobV2) {
+	i, exists := queue.index[jobId]
+	if !exists {
+		return nil
+	}
+	return queue.jobs[i]
+}
+
+// all returns the jobs in the queue in no particular order. The returned slice is a copy, so the
+// queue can be changed while iterating over it.
+func (queue *jobQueue) all() (jobs []JobV2) {
+	return append([]JobV2(nil), queue.jobs...)
+}
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJobQueueOrder(t *testing.T) {
+	start := mustParseTime(t, "2026-01-01T00:00:00Z")
+	queue := newJobQueue()
+	offsets := map[JobId]int{"a": 5, "b": 1, "c": 3, "d": 0, "e": 4}
+	for id, offset := range offsets {
+		job := newTestJob(t, id, "* * * * *")
+		if offset > 0 {
+			job.CommonJobFields.NextRun = start.Add(time.Duration(offset) * time.Minute)
+		}
+		queue.add(AdaptJob(job))
+	}
+	// Moving a job to the front and removing one from the middle keeps the heap order.
+	queue.get("e").GetCommonJobFields().NextRun = start
+	queue.fix("e")
+	queue.remove("c")
+	want := []JobId{"e", "b", "a", "d"}
+	for i, id := range want {
+		job := queue.remove(queue.peek().GetCommonJobFields().ID)
+		if got := job.GetCommonJobFields().ID; got != id {
+			t.Fatalf("job #%v: got %v, want %v", i, got, id)
+		}
+	}
+	if queue.Len() != 0 || queue.peek() != nil {
+		t.Fatalf("got %v jobs left in the queue, want none", queue.Len())
+	}
+}
+
+// storedTestJob is a JobV2 which is saved to a JobStore, like the jobs created through the REST API.
+type storedTestJob struct {
+	testJobV2
+	store JobStore
+}
+
+func (job *storedTestJob) Save() (saved bool, err error) {
+	if err = job.store.SaveJob(job); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// newBenchmarkJobManager returns a JobManager with "count" scheduled jobs, spread evenly over the
+// minutes of an hour, and a go-routine consuming the dispatched JobRuns. If "stored" is set, the jobs
+// are saved to a FileJobStore.
+func newBenchmarkJobManager(b *testing.B, count int, stored bool) (manager *JobManager, now time.Time) {
+	b.Helper()
+	now = mustParseTime(b, "2026-01-01T00:00:30Z")
+	manager = newTestJobManager(newFakeClock(now), time.UTC)
+	var store *FileJobStore
+	if stored {
+		registry := NewJobRegistry("Stored")
+		registry.Register(JobType{Name: "Stored", New: func() JobV2 { return &storedTestJob{} }})
+		store = NewFileJobStore(testLogger{}, filepath.Join(b.TempDir(), "jobs.json"), registry)
+		b.Cleanup(func() { store.Close() })
+	}
+	for i := 0; i < count; i++ {
+		testJob := newTestJob(b, JobId(fmt.Sprintf("job-%v", i)), fmt.Sprintf("%v * * * *", i%60))
+		job := AdaptJob(testJob)
+		if stored {
+			job = &storedTestJob{testJobV2: testJobV2{testJob: *testJob}, store: store}
+			job.Save()
+		}
+		job.GetCommonJobFields().NextRun, _ = manager.getNextScheduleTime(job, now)
+		manager.jobs.add(job)
+	}
+	if stored {
+		if err := store.Flush(); err != nil {
+			b.Fatalf("got the error %v while writing the jobs file", err)
+		}
+	}
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-manager.jobRunChan:
+			case <-done:
+				return
+			}
+		}
+	}()
+	b.Cleanup(func() { close(done) })
+	return
+}
+
+// BenchmarkDispatchDueJobs measures a scheduler tick: dispatching the jobs which are due at the top of
+// a minute (1/60 of all the jobs) and re-positioning them in the queue as per their next schedule time.
+// With "stored" set, the dispatched jobs are saved to a FileJobStore holding all the jobs.
+func BenchmarkDispatchDueJobs(b *testing.B) {
+	for _, count := range []int{10000, 100000} {
+		for _, stored := range []bool{false, true} {
+			b.Run(fmt.Sprintf("jobs=%v/stored=%v", count, stored), func(b *testing.B) {
+				manager, _ := newBenchmarkJobManager(b, count, stored)
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					manager.dispatchDueJobs(manager.jobs.peek().GetCommonJobFields().NextRun)
+				}
+				b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N)/float64(count/60), "ns/job")
+			})
+		}
+	}
+}
+
+// BenchmarkJobQueue measures adding a job to and removing it from a queue of scheduled jobs.
+func BenchmarkJobQueue(b *testing.B) {
+	for _, count := range []int{10000, 100000} {
+		b.Run(fmt.Sprintf("jobs=%v", count), func(b *testing.B) {
+			manager, now := newBenchmarkJobManager(b, count, false)
+			job := AdaptJob(newTestJob(b, "benchmark-job", "30 * * * *"))
+			job.GetCommonJobFields().NextRun, _ = manager.getNextScheduleTime(job, now)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				manager.jobs.add(job)
+				manager.jobs.remove("benchmark-job")
+			}
+		})
+	}
+}
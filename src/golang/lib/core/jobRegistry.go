@@ -0,0 +1,169 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Name of the field of the persisted jobs and the REST payloads which holds the job type.
+const JOB_TYPE_FIELD = "Type"
+
+// JobCodec encodes the jobs of a type to JSON objects and decodes them back.
+type JobCodec interface {
+	Encode(job JobV2) (data []byte, err error)
+	// Decode fills the job created by the factory of the type from the data.
+	Decode(data []byte, job JobV2) (err error)
+}
+
+// JSONJobCodec encodes the jobs with encoding/json. The fields tagged with `json:"-"` (e.g. a logger) are not
+// encoded, so the factory of the type should set them.
+type JSONJobCodec struct{}
+
+func (JSONJobCodec) Encode(job JobV2) (data []byte, err error) {
+	return json.Marshal(job)
+}
+
+func (JSONJobCodec) Decode(data []byte, job JobV2) (err error) {
+	return json.Unmarshal(data, job)
+}
+
+// JobType describes a job implementation which can be persisted and created through the REST API.
+type JobType struct {
+	Name string
+	// New returns an empty job of the type with the fields which are not persisted (e.g. the logger) set.
+	// All the jobs it returns should have the same Go type.
+	New func() JobV2
+	// Codec of the jobs. JSONJobCodec is used if it is nil.
+	Codec JobCodec
+	// Validate checks a job created through the REST API. The jobs are not checked if it is nil.
+	Validate func(job JobV2) (err error)
+}
+
+// JobRegistry maps the job type names to the JobTypes. The persisted jobs and the REST payloads carry the
+// type name in their "Type" field. The ones without it are of the DefaultType.
+type JobRegistry struct {
+	DefaultType string
+	types       map[string]*JobType
+	names       map[reflect.Type]string // Type name keyed by the Go type of the jobs
+	mu          sync.RWMutex
+}
+
+// NewJobRegistry creates an empty JobRegistry.
+func NewJobRegistry(defaultType string) (registry *JobRegistry) {
+	return &JobRegistry{
+		DefaultType: defaultType,
+		types:       make(map[string]*JobType),
+		names:       make(map[reflect.Type]string),
+	}
+}
+
+// Register adds the job type. The name and the Go type of the jobs should not be registered already.
+func (registry *JobRegistry) Register(jobType JobType) (err error) {
+	if jobType.Name == "" || jobType.New == nil {
+		return fmt.Errorf("invalid job type. Name and New should be set")
+	}
+	if jobType.Codec == nil {
+		jobType.Codec = JSONJobCodec{}
+	}
+	goType := reflect.TypeOf(jobType.New())
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if _, found := registry.types[jobType.Name]; found {
+		return fmt.Errorf("job type - %v is already registered", jobType.Name)
+	}
+	if name, found := registry.names[goType]; found {
+		return fmt.Errorf("jobs of the Go type - %v are already registered as the job type - %v", goType, name)
+	}
+	registry.types[jobType.Name] = &jobType
+	registry.names[goType] = jobType.Name
+	return nil
+}
+
+// TypeNames returns the names of the registered job types in order.
+func (registry *JobRegistry) TypeNames() (names []string) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	return registry.typeNamesLocked()
+}
+
+// getType returns the JobType with the name, or of the job if the name is empty.
+func (registry *JobRegistry) getType(name string, job JobV2) (jobType *JobType, err error) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	if job != nil {
+		var found bool
+		if name, found = registry.names[reflect.TypeOf(job)]; !found {
+			return nil, fmt.Errorf("job type of the Go type - %T is not registered", job)
+		}
+	}
+	if jobType = registry.types[name]; jobType == nil {
+		return nil, fmt.Errorf("unknown job Type - %v. Registered types are %v", name, strings.Join(registry.typeNamesLocked(), ", "))
+	}
+	return jobType, nil
+}
+
+// typeNamesLocked returns the names of the registered job types in order. Called with mu held.
+func (registry *JobRegistry) typeNamesLocked() (names []string) {
+	for name := range registry.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// Encode encodes the job with the codec of its type and adds the "Type" field to it.
+func (registry *JobRegistry) Encode(job JobV2) (data json.RawMessage, err error) {
+	jobType, err := registry.getType("", job)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := jobType.Codec.Encode(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the job - %v. Error - %v", job.GetCommonJobFields().ID, err)
+	}
+	var object map[string]json.RawMessage
+	if err = json.Unmarshal(encoded, &object); err != nil {
+		return nil, fmt.Errorf("failed to encode the job - %v. The codec of the type - %v should return a JSON object", job.GetCommonJobFields().ID, jobType.Name)
+	}
+	if _, found := object[JOB_TYPE_FIELD]; found {
+		return nil, fmt.Errorf("failed to encode the job - %v. Encoded job has the reserved field - %v", job.GetCommonJobFields().ID, JOB_TYPE_FIELD)
+	}
+	object[JOB_TYPE_FIELD], _ = json.Marshal(jobType.Name)
+	return json.Marshal(object)
+}
+
+// Decode creates a job of the type named by the "Type" field of the data (DefaultType if it is not set)
+// and decodes the data into it.
+func (registry *JobRegistry) Decode(data []byte) (job JobV2, err error) {
+	var header struct {
+		Type string `json:"Type"`
+	}
+	if err = json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("invalid job JSON. Error - %v", err)
+	}
+	if header.Type == "" {
+		header.Type = registry.DefaultType
+	}
+	jobType, err := registry.getType(header.Type, nil)
+	if err != nil {
+		return nil, err
+	}
+	job = jobType.New()
+	if err = jobType.Codec.Decode(data, job); err != nil {
+		return nil, fmt.Errorf("invalid job of the type - %v. Error - %v", jobType.Name, err)
+	}
+	return job, nil
+}
+
+// Validate checks the job as per the Validate function of its type.
+func (registry *JobRegistry) Validate(job JobV2) (err error) {
+	jobType, err := registry.getType("", job)
+	if err != nil || jobType.Validate == nil {
+		return err
+	}
+	return jobType.Validate(job)
+}
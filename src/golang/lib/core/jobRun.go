@@ -0,0 +1,269 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// JobRunTrigger tells why a JobRun was created.
+type JobRunTrigger string
+
+const (
+	TRIGGER_SCHEDULE   JobRunTrigger = "schedule"   // Regular run as per the job's schedule
+	TRIGGER_CATCH_UP   JobRunTrigger = "catch_up"   // Run of a missed schedule time as per the job's misfire policy
+	TRIGGER_MANUAL     JobRunTrigger = "manual"     // Run requested through JobManager.RunNow()
+	TRIGGER_RETRY      JobRunTrigger = "retry"      // Retry of a failed run as per the job's retry policy
+	TRIGGER_DEPENDENCY JobRunTrigger = "dependency" // Run triggered by the completion of the upstream jobs
+)
+
+// RunStatus is the state of a JobRun.
+type RunStatus string
+
+const (
+	RUN_STATUS_QUEUED    RunStatus = "queued"
+	RUN_STATUS_RUNNING   RunStatus = "running"
+	RUN_STATUS_SUCCEEDED RunStatus = "succeeded"
+	RUN_STATUS_FAILED    RunStatus = "failed"
+	RUN_STATUS_SKIPPED   RunStatus = "skipped"
+	RUN_STATUS_TIMED_OUT RunStatus = "timed_out"
+	RUN_STATUS_CANCELLED RunStatus = "cancelled"
+	// The run was stopped by the shutdown of the JobManager, or the daemon exited while the run was active.
+	RUN_STATUS_INTERRUPTED RunStatus = "interrupted"
+)
+
+// errRunInterrupted is the cause of the context of the runs cancelled by the shutdown of the JobRunner.
+var errRunInterrupted = errors.New("job runner is shutting down")
+
+type JobRun struct {
+	ID  string
+	Job JobV2
+	// Fields is a copy of the job's CommonJobFields taken when the JobRun was created. The runner
+	// uses it instead of the job's fields, which are owned by the scheduler.
+	Fields  CommonJobFields
+	Trigger JobRunTrigger
+	// Trigger of the first run of the retry chain. The retries keep it, so that they are handled like the first run.
+	OriginTrigger JobRunTrigger
+	// Attempt is 1 for the first run and incremented for every retry. The retries refer
+	// to the first run of the chain through ParentRunID.
+	Attempt     int
+	ParentRunID string
+	// Run of the upstream job which triggered this run. Set for the dependency triggered runs.
+	UpstreamRunID string
+	ScheduledAt   time.Time
+	// Random delay of the scheduled run as per the job's MaxJitter.
+	Jitter      Duration
+	QueuedAt    time.Time
+	RanAt       time.Time
+	CompletedAt time.Time
+	Running     bool
+	Skipped     bool
+	SkipReason  string
+	// Result and Err are returned by the job's Execute().
+	Result Result
+	Err    error
+	// TimedOut is set when the run was cancelled because the job's Timeout expired.
+	TimedOut bool
+	// Cancelled is set when the run was cancelled through Cancel(), e.g. replaced as per the
+	// Replace concurrency policy or stopped with the JobManager.
+	Cancelled bool
+	// Interrupted is set when the run was cancelled because the JobRunner was shutting down.
+	Interrupted bool
+	// Output writers of the run. Set by the JobRunner when the output is captured.
+	Stdout          io.Writer
+	Stderr          io.Writer
+	OutputTruncated bool
+	Logger          Logger
+	// done is closed once the JobRun is completed or skipped, and its final state is recorded.
+	done chan struct{}
+	// settled is closed after done, once the JobRunner decided whether the JobRun is retried. retry is the
+	// JobRun of the next attempt, if any. It is set before settled is closed.
+	settled chan struct{}
+	retry   *JobRun
+	// ctx is the handle of the run. It is cancelled when the job's Timeout expires or Cancel() is called.
+	// Set when the run starts.
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+}
+
+// RunRecord is a serializable view of a JobRun.
+type RunRecord struct {
+	RunID       string        `json:"RunID"`
+	JobID       JobId         `json:"JobID"`
+	Trigger     JobRunTrigger `json:"Trigger"`
+	Attempt     int           `json:"Attempt,omitempty"`
+	ParentRunID string        `json:"ParentRunID,omitempty"` // First run of the chain, set for the retries
+	// Run of the upstream job which triggered this run, set for the dependency triggered runs.
+	UpstreamRunID string    `json:"UpstreamRunID,omitempty"`
+	Status        RunStatus `json:"Status"`
+	ScheduledAt   time.Time `json:"ScheduledAt"`
+	Jitter        Duration  `json:"Jitter,omitempty"` // Delay of the run after the ScheduledAt as per the MaxJitter
+	QueuedAt      time.Time `json:"QueuedAt"`
+	StartedAt     time.Time `json:"StartedAt"`
+	CompletedAt   time.Time `json:"CompletedAt"`
+	ExitCode      *int      `json:"ExitCode,omitempty"` // Set when the job ran a process which exited
+	Signal        string    `json:"Signal,omitempty"`   // Signal which terminated the process, if any
+	Error         string    `json:"Error,omitempty"`
+	SkipReason    string    `json:"SkipReason,omitempty"`
+	// Tells whether the captured output exceeded the size limit.
+	OutputTruncated bool `json:"OutputTruncated,omitempty"`
+}
+
+// Done returns a channel which is closed once the JobRun is completed or skipped.
+func (jobRun *JobRun) Done() <-chan struct{} {
+	return jobRun.done
+}
+
+// Wait blocks until the JobRun is completed or skipped and returns its record.
+func (jobRun *JobRun) Wait() (record RunRecord) {
+	<-jobRun.done
+	return jobRun.Record()
+}
+
+// LastAttempt waits until the last attempt of the retry chain started by the JobRun is completed or skipped
+// and returns it. The JobRun itself is returned if it isn't retried. Error is returned if the context is done first.
+func (jobRun *JobRun) LastAttempt(ctx context.Context) (lastAttempt *JobRun, err error) {
+	for lastAttempt = jobRun; ; lastAttempt = lastAttempt.retry {
+		select {
+		case <-lastAttempt.settled:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if lastAttempt.retry == nil {
+			return lastAttempt, nil
+		}
+	}
+}
+
+// Context returns the context of the run, which is done when the job's Timeout expires or the run is cancelled.
+func (jobRun *JobRun) Context() context.Context {
+	if jobRun.ctx == nil {
+		return context.Background()
+	}
+	return jobRun.ctx
+}
+
+// Cancel cancels the context of the running JobRun. It has no effect on a run which hasn't started yet.
+func (jobRun *JobRun) Cancel() {
+	if jobRun.cancel != nil {
+		jobRun.cancel(nil)
+	}
+}
+
+// interrupt cancels the context of the running JobRun because the JobRunner is shutting down.
+func (jobRun *JobRun) interrupt() {
+	if jobRun.cancel != nil {
+		jobRun.cancel(errRunInterrupted)
+	}
+}
+
+// info returns the details of the run passed to the job's Execute().
+func (jobRun *JobRun) info() RunInfo {
+	return RunInfo{
+		RunID:       jobRun.ID,
+		JobID:       jobRun.Fields.ID,
+		Trigger:     jobRun.Trigger,
+		Attempt:     jobRun.Attempt,
+		ScheduledAt: jobRun.ScheduledAt,
+		Stdout:      jobRun.Stdout,
+		Stderr:      jobRun.Stderr,
+	}
+}
+
+// Status returns the current state of the JobRun.
+func (jobRun *JobRun) Status() RunStatus {
+	switch {
+	case jobRun.Skipped:
+		return RUN_STATUS_SKIPPED
+	case jobRun.Running:
+		return RUN_STATUS_RUNNING
+	case jobRun.Interrupted:
+		return RUN_STATUS_INTERRUPTED
+	case jobRun.TimedOut:
+		return RUN_STATUS_TIMED_OUT
+	case jobRun.Cancelled:
+		return RUN_STATUS_CANCELLED
+	case !jobRun.CompletedAt.IsZero() && jobRun.Err != nil:
+		return RUN_STATUS_FAILED
+	case !jobRun.CompletedAt.IsZero():
+		return RUN_STATUS_SUCCEEDED
+	default:
+		return RUN_STATUS_QUEUED
+	}
+}
+
+// Record returns the RunRecord of the JobRun.
+func (jobRun *JobRun) Record() (record RunRecord) {
+	record = RunRecord{
+		RunID:           jobRun.ID,
+		JobID:           jobRun.Fields.ID,
+		Trigger:         jobRun.Trigger,
+		Attempt:         jobRun.Attempt,
+		ParentRunID:     jobRun.ParentRunID,
+		UpstreamRunID:   jobRun.UpstreamRunID,
+		Status:          jobRun.Status(),
+		ScheduledAt:     jobRun.ScheduledAt,
+		Jitter:          jobRun.Jitter,
+		QueuedAt:        jobRun.QueuedAt,
+		StartedAt:       jobRun.RanAt,
+		CompletedAt:     jobRun.CompletedAt,
+		SkipReason:      jobRun.SkipReason,
+		OutputTruncated: jobRun.OutputTruncated,
+	}
+	if jobRun.Err != nil {
+		record.Error = jobRun.Err.Error()
+	}
+	if record.Status == RUN_STATUS_SUCCEEDED {
+		exitCode := 0
+		record.ExitCode = &exitCode
+	}
+	if jobRun.Result.ExitCode != nil || jobRun.Result.Signal != "" {
+		record.ExitCode = jobRun.Result.ExitCode
+		record.Signal = jobRun.Result.Signal
+		return
+	}
+	// Jobs which don't fill the Result may still return the error of the process.
+	var exitErr *exec.ExitError
+	if errors.As(jobRun.Err, &exitErr) {
+		exitCode := exitErr.ExitCode()
+		record.ExitCode = &exitCode
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			record.Signal = status.Signal().String()
+		}
+	}
+	return
+}
+
+// complete marks the JobRun as completed with the result returned by the job.
+func (jobRun *JobRun) complete(completedAt time.Time, result Result, err error) {
+	jobRun.CompletedAt = completedAt
+	jobRun.Result = result
+	jobRun.Err = err
+	if jobRun.ctx != nil {
+		jobRun.TimedOut = errors.Is(jobRun.ctx.Err(), context.DeadlineExceeded)
+		jobRun.Cancelled = errors.Is(jobRun.ctx.Err(), context.Canceled)
+		jobRun.Interrupted = errors.Is(context.Cause(jobRun.ctx), errRunInterrupted)
+		jobRun.cancel(nil)
+	}
+	jobRun.Running = false
+}
+
+// skip marks the JobRun as skipped with the reason.
+func (jobRun *JobRun) skip(reason string) {
+	jobRun.Skipped = true
+	jobRun.SkipReason = reason
+}
+
+// finish closes the done channel of the completed or skipped JobRun.
+func (jobRun *JobRun) finish() {
+	close(jobRun.done)
+}
+
+// settle records the retry of the done JobRun (nil if it isn't retried) and closes its settled channel.
+func (jobRun *JobRun) settle(retry *JobRun) {
+	jobRun.retry = retry
+	close(jobRun.settled)
+}
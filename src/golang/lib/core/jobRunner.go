@@ -1,7 +1,9 @@
 package core
 
 import (
+	"context"
 	"fmt"
+	"math/rand/v2"
 	"sync"
 	"time"
 
@@ -12,6 +14,10 @@ const (
 	DEFAULT_MAX_RUNNING_JOBS    = 100
 	DEFAULT_JOB_RUN_CHAN_BUFFER = 50
 	DEFAULT_MONITOR_TICKER      = 60 * time.Second
+	// Number of skipped job runs retained in memory by the JobRunner.
+	DEFAULT_MAX_SKIPPED_JOB_RUNS = 1000
+	// Time given to the interrupted runs to exit on top of their KillGracePeriod when the JobRunner stops.
+	SHUTDOWN_KILL_WAIT_MARGIN = time.Second
 )
 
 type JobRunner struct {
@@ -20,24 +26,66 @@ type JobRunner struct {
 	RunningJobCountMu  sync.Mutex
 	RunningJobs        []*JobRun
 	RunningJobsMu      sync.Mutex
-	Logger             Logger
-	stopChan           chan struct{}
-	JobRunChan         chan *JobRun
+	// PendingJobRuns holds the JobRuns which are waiting for a free slot.
+	// JobRuns are started in FIFO order as soon as a running job completes.
+	PendingJobRuns   []*JobRun
+	PendingJobRunsMu sync.Mutex
+	Logger           Logger
+	// stopChan is closed by Drain() and loopDone is closed when the runner loop exits. Both are created by
+	// every Start(), so that a stopped JobRunner can be started again. The state is guarded by stateMu.
+	stopChan   chan struct{}
+	loopDone   chan struct{}
+	running    bool
+	stopping   bool
+	stateMu    sync.Mutex
+	JobRunChan chan *JobRun
+	doneChan   chan *JobRun
+	// startedChan receives the JobRuns which started and finishedChan receives the JobRuns which completed
+	// and will not be retried, or were skipped. Optional.
+	startedChan  chan *JobRun
+	finishedChan chan *JobRun
+	clock        Clock
+	observers    *observerList
+	history      RunHistory
+	output       *RunOutput
+	waitStats    queueWaitStats
+	// SkippedJobRuns holds the recent JobRuns which were not run because of the job's
+	// concurrency policy. Only the latest DEFAULT_MAX_SKIPPED_JOB_RUNS entries are kept.
+	SkippedJobRuns   []SkippedJobRun
+	SkippedJobRunsMu sync.Mutex
 }
 
-type JobRun struct {
-	ID          string
-	Job         Job
-	ScheduledAt time.Time
-	RanAt       time.Time
-	CompletedAt time.Time
-	Running     bool
-	Logger      Logger
+// SkippedJobRun records a JobRun which was dropped by the JobRunner along with the reason.
+type SkippedJobRun struct {
+	RunID       string    `json:"RunID"`
+	JobID       JobId     `json:"JobID"`
+	ScheduledAt time.Time `json:"ScheduledAt"`
+	SkippedAt   time.Time `json:"SkippedAt"`
+	Reason      string    `json:"Reason"`
+}
+
+// JobRunnerStats is a point in time view of the JobRunner's worker pool and pending queue.
+type JobRunnerStats struct {
+	MaxRunningJobs   int16         `json:"MaxRunningJobs"`
+	RunningJobs      int16         `json:"RunningJobs"`
+	QueuedJobRuns    int           `json:"QueuedJobRuns"`
+	OldestQueuedWait time.Duration `json:"OldestQueuedWait"` // Wait time of the oldest JobRun still in the queue
+	AverageQueueWait time.Duration `json:"AverageQueueWait"` // Average wait time of all the started JobRuns
+	MaxQueueWait     time.Duration `json:"MaxQueueWait"`     // Maximum wait time of all the started JobRuns
+	StartedJobRuns   int64         `json:"StartedJobRuns"`
+}
+
+// queueWaitStats accumulates the time spent by the JobRuns in the pending queue.
+type queueWaitStats struct {
+	mu        sync.Mutex
+	started   int64
+	totalWait time.Duration
+	maxWait   time.Duration
 }
 
 func NewJobRunner(logger Logger, maxRunningJobs int16, jobRunnerChan chan *JobRun) (jobRunner *JobRunner) {
 	logger.Infof("Creating a new instance of JobRunner...")
-	if maxRunningJobs == 0 {
+	if maxRunningJobs <= 0 {
 		logger.Infof("Max running jobs set to default value - %v", DEFAULT_MAX_RUNNING_JOBS)
 		maxRunningJobs = DEFAULT_MAX_RUNNING_JOBS
 	}
@@ -46,97 +94,553 @@ func NewJobRunner(logger Logger, maxRunningJobs int16, jobRunnerChan chan *JobRu
 		RunningJobCount:    0,
 		RunningJobs:        make([]*JobRun, 0),
 		RunningJobCountMu:  sync.Mutex{},
+		PendingJobRuns:     make([]*JobRun, 0),
+		SkippedJobRuns:     make([]SkippedJobRun, 0),
 		Logger:             logger,
-		stopChan:           make(chan struct{}),
 		JobRunChan:         jobRunnerChan,
+		doneChan:           make(chan *JobRun),
+		clock:              realClock{},
+		observers:          newObserverList(logger, realClock{}),
 	}
 	logger.Infof("Successfully created the instance of Job Runner.")
 	return
 }
 
-// CreateJobRun creates an instance of the JobRun struct and populate the fields.
-func (jr *JobRunner) CreateJobRun(job Job) (jobRun *JobRun) {
-	jr.Logger.Infof("Creating a new JobRun instance for the Job - %v, Schedule time - %v",
-		job.GetCommonJobFields().ID, job.GetCommonJobFields().NextRun)
+// CreateJobRun creates an instance of the JobRun struct and populate the fields. The JobRun gets a copy
+// of the job's CommonJobFields, so it must be called by the owner of the job (the scheduler go-routine).
+func (jr *JobRunner) CreateJobRun(job JobV2, scheduledAt time.Time, trigger JobRunTrigger) (jobRun *JobRun) {
+	return jr.createJobRun(job, job.GetCommonJobFields().Copy(), scheduledAt, trigger)
+}
+
+func (jr *JobRunner) createJobRun(job JobV2, fields CommonJobFields, scheduledAt time.Time, trigger JobRunTrigger) (jobRun *JobRun) {
+	jr.Logger.Infof("Creating a new JobRun instance for the Job - %v, Schedule time - %v, Trigger - %v",
+		fields.ID, scheduledAt, trigger)
 	jobRun = &JobRun{
-		ID:          uuid.New().String(),
-		Job:         job,
-		Logger:      jr.Logger,
-		ScheduledAt: job.GetCommonJobFields().NextRun,
-		Running:     false,
+		ID:            uuid.New().String(),
+		Job:           job,
+		Fields:        fields,
+		Trigger:       trigger,
+		OriginTrigger: trigger,
+		Attempt:       1,
+		Logger:        jr.Logger,
+		ScheduledAt:   scheduledAt,
+		Running:       false,
+		done:          make(chan struct{}),
+		settled:       make(chan struct{}),
 	}
 	return
 }
 
+// Start starts the runner loop, which listens for the new JobRuns and the completed JobRuns. New JobRuns are
+// queued and started only when the number of running jobs is less than MaxRunningJobCount. A JobRunner
+// stopped by Drain() can be started again.
 func (jr *JobRunner) Start() (err error) {
+	jr.stateMu.Lock()
+	defer jr.stateMu.Unlock()
+	if jr.running {
+		return fmt.Errorf("job runner is already running")
+	}
 	jr.Logger.Infof("Starting the Job runner...")
-	go jr.monitorJobRunner()
+	jr.running = true
+	jr.stopChan = make(chan struct{})
+	jr.loopDone = make(chan struct{})
+	go jr.run(jr.stopChan, jr.loopDone)
+	go jr.monitorJobRunner(jr.stopChan)
+	return nil
+}
+
+// run is the runner loop. It owns the dispatching of the JobRuns until the stop channel is closed.
+func (jr *JobRunner) run(stopChan chan struct{}, loopDone chan struct{}) {
+	defer close(loopDone)
 	for {
 		select {
 		case jobRun := <-jr.JobRunChan:
 			jr.Logger.Infof("Recieved job on the job run channel.")
-			jr.runJob(jobRun)
-		case <-jr.stopChan:
+			if jr.delayByJitter(jobRun) {
+				continue
+			}
+			jr.observers.publishRun(EVENT_RUN_SCHEDULED, jobRun)
+			if !jr.applyConcurrencyPolicy(jobRun) {
+				continue
+			}
+			jr.enqueue(jobRun)
+			jr.dispatchPending()
+		case jobRun := <-jr.doneChan:
+			jr.RunningJobCountMu.Lock()
+			jr.RunningJobCount--
+			jr.RunningJobCountMu.Unlock()
+			jr.removeRunEntry(jobRun.ID)
+			retry := jr.retryIfFailed(jobRun)
+			jobRun.settle(retry)
+			if retry == nil {
+				jr.notify(jr.finishedChan, jobRun)
+			}
+			jr.dispatchPending()
+		case <-stopChan:
 			jr.Logger.Infof("Recieved signal on stop channel.")
 			return
 		}
 	}
 }
 
+// Stop stops the JobRunner and interrupts the running JobRuns right away. See Drain().
 func (jr *JobRunner) Stop() (err error) {
-	jr.Logger.Infof("Stopping the Job runner...")
+	return jr.Drain(0)
+}
+
+// Drain stops the JobRunner: the queued JobRuns are dropped and no new JobRun is started. The running
+// JobRuns get up to "timeout" to complete. The ones still running are interrupted (SIGTERM for the commands,
+// followed by SIGKILL after their KillGracePeriod) and waited for. Error is returned if any of them is
+// still running after that.
+func (jr *JobRunner) Drain(timeout time.Duration) (err error) {
+	jr.stateMu.Lock()
+	if !jr.running || jr.stopping {
+		jr.stateMu.Unlock()
+		return nil
+	}
+	jr.stopping = true
+	stopChan, loopDone := jr.stopChan, jr.loopDone
+	jr.stateMu.Unlock()
+	defer func() {
+		jr.stateMu.Lock()
+		jr.running, jr.stopping = false, false
+		jr.stateMu.Unlock()
+	}()
+	jr.Logger.Infof("Stopping the Job runner. Running jobs are given %v to complete.", timeout)
 	defer jr.Logger.Infof("Stopped the Job runner.")
-	jr.stopChan <- struct{}{}
-	for _, jobRun := range jr.RunningJobs {
-		jr.Logger.Infof("[Stop] STOPPING the running job - %v, job run ID - %v",
-			jobRun.Job.GetCommonJobFields().ID, jobRun.ID)
-		jobRun.Job.Stop()
-		jr.Logger.Infof("[Stop] STOPPED the job - %v, job run ID - %v",
-			jobRun.Job.GetCommonJobFields().ID, jobRun.ID)
-	}
-	if len(jr.RunningJobs) > 0 {
-		jr.Logger.Errorf("Few jobs are running even after Stop() method invoke.")
-		err = fmt.Errorf("few jobs are running after Stop() method invoke")
-		return err
+	close(stopChan)
+	// No JobRun is started once the runner loop exits.
+	<-loopDone
+	jr.PendingJobRunsMu.Lock()
+	droppedJobRuns := jr.PendingJobRuns
+	jr.PendingJobRuns = make([]*JobRun, 0)
+	jr.PendingJobRunsMu.Unlock()
+	if len(droppedJobRuns) > 0 {
+		jr.Logger.Warnf("[Drain] Dropping %v queued job runs.", len(droppedJobRuns))
+		for _, dropped := range droppedJobRuns {
+			jr.skipJobRun(dropped, "Job runner stopped before the run could start")
+		}
+	}
+	jr.RunningJobsMu.Lock()
+	runningJobs := append([]*JobRun(nil), jr.RunningJobs...)
+	jr.RunningJobsMu.Unlock()
+	if timeout > 0 && len(runningJobs) > 0 {
+		jr.Logger.Infof("[Drain] Waiting for %v running job runs to complete.", len(runningJobs))
+		runningJobs = jr.waitForJobRuns(runningJobs, timeout)
+	}
+	killWait := time.Duration(0)
+	for _, jobRun := range runningJobs {
+		jr.Logger.Infof("[Drain] INTERRUPTING the running job - %v, job run ID - %v",
+			jobRun.Fields.ID, jobRun.ID)
+		jobRun.interrupt()
+		killWait = max(killWait, jobRun.Fields.GetKillGracePeriod())
+	}
+	if len(runningJobs) > 0 {
+		runningJobs = jr.waitForJobRuns(runningJobs, killWait+SHUTDOWN_KILL_WAIT_MARGIN)
+	}
+	if len(runningJobs) > 0 {
+		jr.Logger.Errorf("%v jobs are running even after Stop() method invoke.", len(runningJobs))
+		return fmt.Errorf("few jobs are running after Stop() method invoke")
 	}
 	return nil
 }
 
-func (jr *JobRunner) runJob(jobRun *JobRun) {
-	jr.Logger.Infof("In a go-routine, running the job - %v. Job run ID - %v.",
-		jobRun.Job.GetCommonJobFields().ID, jobRun.ID)
+// waitForJobRuns waits for up to "timeout" for the JobRuns to be done and returns the ones which are not.
+func (jr *JobRunner) waitForJobRuns(jobRuns []*JobRun, timeout time.Duration) (remaining []*JobRun) {
+	timer := jr.clock.NewTimer(timeout)
+	defer timer.Stop()
+	for i, jobRun := range jobRuns {
+		select {
+		case <-jobRun.Done():
+		case <-timer.C():
+			for _, jobRun := range jobRuns[i:] {
+				select {
+				case <-jobRun.Done():
+				default:
+					remaining = append(remaining, jobRun)
+				}
+			}
+			return remaining
+		}
+	}
+	return nil
+}
+
+// Stats returns the current state of the worker pool and the pending queue.
+func (jr *JobRunner) Stats() (stats JobRunnerStats) {
+	now := jr.clock.Now()
+	jr.RunningJobCountMu.Lock()
+	stats.MaxRunningJobs = jr.MaxRunningJobCount
+	stats.RunningJobs = jr.RunningJobCount
+	jr.RunningJobCountMu.Unlock()
+	jr.PendingJobRunsMu.Lock()
+	stats.QueuedJobRuns = len(jr.PendingJobRuns)
+	if len(jr.PendingJobRuns) > 0 {
+		stats.OldestQueuedWait = now.Sub(jr.PendingJobRuns[0].QueuedAt)
+	}
+	jr.PendingJobRunsMu.Unlock()
+	jr.waitStats.mu.Lock()
+	stats.StartedJobRuns = jr.waitStats.started
+	stats.MaxQueueWait = jr.waitStats.maxWait
+	if jr.waitStats.started > 0 {
+		stats.AverageQueueWait = jr.waitStats.totalWait / time.Duration(jr.waitStats.started)
+	}
+	jr.waitStats.mu.Unlock()
+	return
+}
+
+// GetSkippedJobRuns returns the recent skipped JobRuns of the job. All the skipped JobRuns
+// are returned if the jobId is empty.
+func (jr *JobRunner) GetSkippedJobRuns(jobId JobId) (skippedJobRuns []SkippedJobRun) {
+	jr.SkippedJobRunsMu.Lock()
+	defer jr.SkippedJobRunsMu.Unlock()
+	skippedJobRuns = make([]SkippedJobRun, 0)
+	for _, skipped := range jr.SkippedJobRuns {
+		if jobId == "" || skipped.JobID == jobId {
+			skippedJobRuns = append(skippedJobRuns, skipped)
+		}
+	}
+	return
+}
+
+// applyConcurrencyPolicy checks the active (running and queued) runs of the job and decides
+// whether the new JobRun can be queued as per the job's ConcurrencyPolicy.
+func (jr *JobRunner) applyConcurrencyPolicy(jobRun *JobRun) (admitted bool) {
+	fields := &jobRun.Fields
+	runningCount := jr.countRunningJobRuns(fields.ID)
+	pendingCount := jr.countPendingJobRuns(fields.ID)
+	activeCount := runningCount + pendingCount
+	switch fields.ConcurrencyPolicy {
+	case CONCURRENCY_POLICY_FORBID:
+		if activeCount > 0 {
+			jr.skipJobRun(jobRun, fmt.Sprintf("Forbid concurrency policy. %v run(s) of the job are still active", activeCount))
+			return false
+		}
+	case CONCURRENCY_POLICY_MAX_INSTANCES:
+		if activeCount >= fields.MaxInstances {
+			jr.skipJobRun(jobRun, fmt.Sprintf("MaxInstances concurrency policy. %v run(s) of the job are active, MaxInstances - %v",
+				activeCount, fields.MaxInstances))
+			return false
+		}
+	case CONCURRENCY_POLICY_REPLACE:
+		if pendingCount > 0 {
+			for _, replaced := range jr.removePendingJobRuns(fields.ID) {
+				jr.skipJobRun(replaced, "Replace concurrency policy. Replaced by the job run - "+jobRun.ID)
+			}
+		}
+		if runningCount > 0 {
+			jr.Logger.Infof("[applyConcurrencyPolicy] Replace concurrency policy. Cancelling %v running run(s) of the job - %v",
+				runningCount, fields.ID)
+			jr.cancelRunningJobRuns(fields.ID)
+		}
+	}
+	return true
+}
+
+func (jr *JobRunner) countRunningJobRuns(jobId JobId) (count int) {
+	jr.RunningJobsMu.Lock()
+	defer jr.RunningJobsMu.Unlock()
+	for _, running := range jr.RunningJobs {
+		if running.Fields.ID == jobId {
+			count++
+		}
+	}
+	return
+}
+
+func (jr *JobRunner) countPendingJobRuns(jobId JobId) (count int) {
+	jr.PendingJobRunsMu.Lock()
+	defer jr.PendingJobRunsMu.Unlock()
+	for _, pending := range jr.PendingJobRuns {
+		if pending.Fields.ID == jobId {
+			count++
+		}
+	}
+	return
+}
+
+// cancelRunningJobRuns cancels all the running runs of the job.
+func (jr *JobRunner) cancelRunningJobRuns(jobId JobId) {
+	jr.RunningJobsMu.Lock()
+	defer jr.RunningJobsMu.Unlock()
+	for _, running := range jr.RunningJobs {
+		if running.Fields.ID == jobId {
+			running.Cancel()
+		}
+	}
+}
+
+// removePendingJobRuns removes all the queued runs of the job and returns them.
+func (jr *JobRunner) removePendingJobRuns(jobId JobId) (removed []*JobRun) {
+	jr.PendingJobRunsMu.Lock()
+	defer jr.PendingJobRunsMu.Unlock()
+	remaining := make([]*JobRun, 0, len(jr.PendingJobRuns))
+	for _, pending := range jr.PendingJobRuns {
+		if pending.Fields.ID == jobId {
+			removed = append(removed, pending)
+		} else {
+			remaining = append(remaining, pending)
+		}
+	}
+	jr.PendingJobRuns = remaining
+	return
+}
+
+// skipJobRun marks the JobRun as skipped and records it with the reason.
+func (jr *JobRunner) skipJobRun(jobRun *JobRun, reason string) {
+	jr.Logger.Warnf("[skipJobRun] Skipping the job run - %v of the job - %v. Reason - %v",
+		jobRun.ID, jobRun.Fields.ID, reason)
+	jobRun.skip(reason)
+	jr.recordRun(jobRun)
+	jr.observers.publishRun(EVENT_RUN_SKIPPED, jobRun)
+	jobRun.finish()
+	jobRun.settle(nil)
+	jr.notify(jr.finishedChan, jobRun)
+	jr.SkippedJobRunsMu.Lock()
+	defer jr.SkippedJobRunsMu.Unlock()
+	jr.SkippedJobRuns = append(jr.SkippedJobRuns, SkippedJobRun{
+		RunID:       jobRun.ID,
+		JobID:       jobRun.Fields.ID,
+		ScheduledAt: jobRun.ScheduledAt,
+		SkippedAt:   jr.clock.Now(),
+		Reason:      reason,
+	})
+	if len(jr.SkippedJobRuns) > DEFAULT_MAX_SKIPPED_JOB_RUNS {
+		jr.SkippedJobRuns = append([]SkippedJobRun(nil), jr.SkippedJobRuns[len(jr.SkippedJobRuns)-DEFAULT_MAX_SKIPPED_JOB_RUNS:]...)
+	}
+}
+
+// retryIfFailed schedules a retry of the completed JobRun as per the job's RetryPolicy. A retry which
+// would start at or after the next schedule time of the job is dropped, as the scheduled run takes over.
+// It returns the JobRun of the retry, or nil if the JobRun isn't retried.
+func (jr *JobRunner) retryIfFailed(jobRun *JobRun) (retry *JobRun) {
+	fields := &jobRun.Fields
+	policy := fields.RetryPolicy
+	if policy == nil || jobRun.Attempt >= policy.MaxAttempts || !policy.IsRetryable(jobRun.Record()) {
+		return nil
+	}
+	delay := policy.GetDelay(jobRun.Attempt)
+	retryAt := jr.clock.Now().Add(delay)
+	if !fields.NextRun.IsZero() && !retryAt.Before(fields.NextRun) {
+		jr.Logger.Warnf("[retryIfFailed] Not retrying the job run - %v of the job - %v. Retry at %v collides with the next run at %v.",
+			jobRun.ID, fields.ID, retryAt, fields.NextRun)
+		return nil
+	}
+	retry = jr.createJobRun(jobRun.Job, jobRun.Fields.Copy(), retryAt, TRIGGER_RETRY)
+	retry.Attempt = jobRun.Attempt + 1
+	retry.OriginTrigger = jobRun.OriginTrigger
+	retry.ParentRunID = jobRun.ParentRunID
+	if retry.ParentRunID == "" {
+		retry.ParentRunID = jobRun.ID
+	}
+	jr.Logger.Infof("[retryIfFailed] Job run - %v of the job - %v failed (attempt %v/%v). Retrying as the job run - %v in %v.",
+		jobRun.ID, fields.ID, jobRun.Attempt, policy.MaxAttempts, retry.ID, delay)
+	jr.sendAfter(retry, delay)
+	return retry
+}
+
+// delayByJitter sends the scheduled JobRun back to the runner after a random delay below the job's MaxJitter.
+// It returns false if the JobRun should be run now.
+func (jr *JobRunner) delayByJitter(jobRun *JobRun) (delayed bool) {
+	maxJitter := jobRun.Fields.MaxJitter.Duration()
+	if jobRun.Trigger != TRIGGER_SCHEDULE || maxJitter <= 0 || jobRun.Jitter > 0 {
+		return false
+	}
+	jitter := rand.N(maxJitter)
+	if jitter <= 0 {
+		return false
+	}
+	jobRun.Jitter = Duration(jitter)
+	jr.Logger.Infof("[delayByJitter] Delaying the job run - %v of the job - %v by %v.", jobRun.ID, jobRun.Fields.ID, jitter)
+	jr.sendAfter(jobRun, jitter)
+	return true
+}
+
+// sendAfter sends the JobRun to the runner after the delay. The JobRun is skipped if the runner stops first,
+// so that the callers waiting for it are released.
+func (jr *JobRunner) sendAfter(jobRun *JobRun, delay time.Duration) {
+	timer := jr.clock.NewTimer(delay)
+	stopChan := jr.stopChan
 	go func() {
+		select {
+		case <-timer.C():
+			select {
+			case jr.JobRunChan <- jobRun:
+				return
+			case <-stopChan:
+			}
+		case <-stopChan:
+			timer.Stop()
+		}
+		jr.skipJobRun(jobRun, "Job runner stopped before the run could start")
+	}()
+}
+
+// notify sends the JobRun on the channel without blocking the runner. Nil channel is ignored.
+func (jr *JobRunner) notify(notifyChan chan *JobRun, jobRun *JobRun) {
+	if notifyChan == nil {
+		return
+	}
+	select {
+	case notifyChan <- jobRun:
+	default:
+		stopChan := jr.stopChan
+		go func() {
+			select {
+			case notifyChan <- jobRun:
+			case <-stopChan:
+			}
+		}()
+	}
+}
+
+// enqueue adds the JobRun to the end of the pending queue.
+func (jr *JobRunner) enqueue(jobRun *JobRun) {
+	jobRun.QueuedAt = jr.clock.Now()
+	jr.PendingJobRunsMu.Lock()
+	defer jr.PendingJobRunsMu.Unlock()
+	jr.PendingJobRuns = append(jr.PendingJobRuns, jobRun)
+	jr.Logger.Infof("[enqueue] Queued the job run - %v of the job - %v. Queue depth - %v",
+		jobRun.ID, jobRun.Fields.ID, len(jr.PendingJobRuns))
+	jr.recordRun(jobRun)
+}
+
+// recordRun saves the current state of the JobRun to the run history, if configured.
+func (jr *JobRunner) recordRun(jobRun *JobRun) {
+	if jr.history == nil {
+		return
+	}
+	if err := jr.history.Record(jobRun.Record()); err != nil {
+		jr.Logger.Errorf("Failed to record the job run - %v in the run history. Error - %v", jobRun.ID, err)
+	}
+}
+
+// dispatchPending starts the queued JobRuns until all the runner slots are occupied.
+func (jr *JobRunner) dispatchPending() {
+	for {
 		jr.RunningJobCountMu.Lock()
-		jr.RunningJobCount++
+		slotAvailable := jr.RunningJobCount < jr.MaxRunningJobCount
 		jr.RunningJobCountMu.Unlock()
-		jobRun.Job.GetCommonJobFields().LastRun = time.Now()
-		jr.RunningJobsMu.Lock()
-		jr.RunningJobs = append(jr.RunningJobs, jobRun)
-		jr.RunningJobsMu.Unlock()
+		if !slotAvailable {
+			jr.PendingJobRunsMu.Lock()
+			if len(jr.PendingJobRuns) > 0 {
+				jr.Logger.Infof("[dispatchPending] All the %v runner slots are busy. %v job runs are waiting in the queue.",
+					jr.MaxRunningJobCount, len(jr.PendingJobRuns))
+			}
+			jr.PendingJobRunsMu.Unlock()
+			return
+		}
+		jr.PendingJobRunsMu.Lock()
+		if len(jr.PendingJobRuns) == 0 {
+			jr.PendingJobRunsMu.Unlock()
+			return
+		}
+		jobRun := jr.PendingJobRuns[0]
+		jr.PendingJobRuns[0] = nil
+		jr.PendingJobRuns = jr.PendingJobRuns[1:]
+		jr.PendingJobRunsMu.Unlock()
+		jr.runJob(jobRun)
+	}
+}
+
+func (jr *JobRunner) runJob(jobRun *JobRun) {
+	jr.Logger.Infof("In a go-routine, running the job - %v. Job run ID - %v.",
+		jobRun.Fields.ID, jobRun.ID)
+	jr.RunningJobCountMu.Lock()
+	jr.RunningJobCount++
+	jr.RunningJobCountMu.Unlock()
+	jobRun.RanAt = jr.clock.Now()
+	jobRun.Running = true
+	jobRun.ctx, jobRun.cancel = context.WithCancelCause(context.Background())
+	if timeout := jobRun.Fields.Timeout.Duration(); timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		jobRun.ctx, cancelTimeout = context.WithTimeout(jobRun.ctx, timeout)
+		cancel := jobRun.cancel
+		jobRun.cancel = func(cause error) {
+			cancel(cause)
+			cancelTimeout()
+		}
+	}
+	jr.recordQueueWait(jobRun.RanAt.Sub(jobRun.QueuedAt))
+	jr.RunningJobsMu.Lock()
+	jr.RunningJobs = append(jr.RunningJobs, jobRun)
+	jr.RunningJobsMu.Unlock()
+	jr.recordRun(jobRun)
+	// LastRun of the job is updated and persisted by the scheduler, so that the runs missed while
+	// the daemon was down can be found on the restart.
+	jr.notify(jr.startedChan, jobRun)
+	jr.observers.publishRun(EVENT_RUN_STARTED, jobRun)
+	stopChan := jr.stopChan
+	go func() {
 		jr.Logger.Infof("[runJob] Execution of the Job - %v, JobRun - %v STARTED.",
-			jobRun.Job.GetCommonJobFields().ID, jobRun.ID)
-		jobRun.Job.Execute()
+			jobRun.Fields.ID, jobRun.ID)
+		result, err := jr.execute(jobRun)
 		jr.Logger.Infof("[runJob] Execution of the Job - %v, JobRun - %v COMPLETED.",
-			jobRun.Job.GetCommonJobFields().ID, jobRun.ID)
-		jr.RunningJobCountMu.Lock()
-		jr.RunningJobCount--
-		jr.RunningJobCountMu.Unlock()
-		jr.removeRunEntry(jobRun.ID)
+			jobRun.Fields.ID, jobRun.ID)
+		jobRun.complete(jr.clock.Now(), result, err)
+		jr.recordRun(jobRun)
+		if jobRun.Status() == RUN_STATUS_SUCCEEDED {
+			jr.observers.publishRun(EVENT_RUN_FINISHED, jobRun)
+		} else {
+			jr.observers.publishRun(EVENT_RUN_FAILED, jobRun)
+		}
+		jobRun.finish()
+		select {
+		case jr.doneChan <- jobRun:
+		case <-stopChan:
+			// The runner loop is gone, so the slot of the run is released here. The run isn't retried.
+			jr.RunningJobCountMu.Lock()
+			jr.RunningJobCount--
+			jr.RunningJobCountMu.Unlock()
+			jr.removeRunEntry(jobRun.ID)
+			jobRun.settle(nil)
+		}
 	}()
 }
 
+// execute runs the job with the context of the JobRun. The output is captured when the RunOutput is
+// configured, except for the adapted jobs which can't write it.
+func (jr *JobRunner) execute(jobRun *JobRun) (result Result, err error) {
+	if jr.output != nil && UnwrapJob(jobRun.Job) == nil {
+		stdout, stderr, openErr := jr.output.Open(jobRun.ID)
+		if openErr != nil {
+			jr.Logger.Errorf("[execute] Failed to open the output files of the job run - %v. Output will not be captured. Error - %v",
+				jobRun.ID, openErr)
+		} else {
+			jobRun.Stdout = stdout
+			jobRun.Stderr = stderr
+			defer func() {
+				stdout.Close()
+				stderr.Close()
+				jobRun.OutputTruncated = stdout.Truncated() || stderr.Truncated()
+			}()
+		}
+	}
+	return jobRun.Job.Execute(jobRun.Context(), jobRun.info())
+}
+
+func (jr *JobRunner) recordQueueWait(wait time.Duration) {
+	jr.waitStats.mu.Lock()
+	defer jr.waitStats.mu.Unlock()
+	jr.waitStats.started++
+	jr.waitStats.totalWait += wait
+	if wait > jr.waitStats.maxWait {
+		jr.waitStats.maxWait = wait
+	}
+}
+
 func (jr *JobRunner) removeRunEntry(runId string) {
+	jr.RunningJobsMu.Lock()
 	for i, j := range jr.RunningJobs {
 		if j.ID == runId {
 			jr.Logger.Infof("[removeRunEntry] Removing the JobRun entry for the run ID - %v", runId)
-			// Locking here works only when there is only ONE go-routine removes entry.
-			jr.RunningJobsMu.Lock()
-			defer jr.RunningJobsMu.Unlock()
 			jr.RunningJobs = append(jr.RunningJobs[:i], jr.RunningJobs[i+1:]...)
+			jr.RunningJobsMu.Unlock()
 			jr.Logger.Infof("[removeRunEntry] Successfully removed the job with ID - %v", runId)
 			return
 		}
 	}
+	jr.RunningJobsMu.Unlock()
 	// If we don't find the desired job, but somebody is calling remove entry...
 	jr.Logger.Infof("[removeRunEntry] Failed to get the job run with ID - %v", runId)
 	jr.syncRunningCount()
@@ -144,18 +648,20 @@ func (jr *JobRunner) removeRunEntry(runId string) {
 
 func (jr *JobRunner) syncRunningCount() {
 	jr.Logger.Infof("[syncRunningCount] Syncing the job runner's RunningJobCount.")
+	jr.RunningJobsMu.Lock()
 	runningJobsSize := len(jr.RunningJobs)
+	jr.RunningJobsMu.Unlock()
+	jr.RunningJobCountMu.Lock()
+	defer jr.RunningJobCountMu.Unlock()
 	if runningJobsSize != int(jr.RunningJobCount) {
 		jr.Logger.Warnf("[syncRunningCount] Syncing RunningJobCount. Actual running jobs - %v, RunningJobCount - %v", runningJobsSize, jr.RunningJobCount)
-		jr.RunningJobCountMu.Lock()
 		jr.RunningJobCount = int16(runningJobsSize)
-		jr.RunningJobCountMu.Unlock()
 		return
 	}
 	jr.Logger.Infof("[syncRunningCount] Running count is in sync with number of running jobs.")
 }
 
-func (jr *JobRunner) monitorJobRunner() {
+func (jr *JobRunner) monitorJobRunner(stopChan chan struct{}) {
 	monitorTicker := time.NewTicker(DEFAULT_MONITOR_TICKER)
 	defer func() {
 		monitorTicker.Stop()
@@ -163,7 +669,7 @@ func (jr *JobRunner) monitorJobRunner() {
 	}()
 	for {
 		select {
-		case <-jr.stopChan:
+		case <-stopChan:
 			return
 		case <-monitorTicker.C:
 			jr.monitor()
@@ -174,9 +680,14 @@ func (jr *JobRunner) monitorJobRunner() {
 func (jr *JobRunner) monitor() {
 	jr.Logger.Infof("-------------JobRunner MONITOR START-------------")
 	defer jr.Logger.Infof("-------------JobRunner MONITOR STOP-------------")
-	jr.Logger.Infof("Number of running jobs - %v", jr.RunningJobCount)
+	stats := jr.Stats()
+	jr.Logger.Infof("Number of running jobs - %v/%v", stats.RunningJobs, stats.MaxRunningJobs)
+	jr.Logger.Infof("Number of queued job runs - %v, Oldest queued wait - %v, Average queue wait - %v",
+		stats.QueuedJobRuns, stats.OldestQueuedWait, stats.AverageQueueWait)
+	jr.RunningJobsMu.Lock()
+	defer jr.RunningJobsMu.Unlock()
 	for i, j := range jr.RunningJobs {
 		jr.Logger.Infof("| #%v : Jobrun ID - %v :: Job ID - %v :: Scheduled at - %v :: Ran at - %v |",
-			i, j.ID, j.Job.GetCommonJobFields().ID, j.ScheduledAt, j.RanAt)
+			i, j.ID, j.Fields.ID, j.ScheduledAt, j.RanAt)
 	}
 }
@@ -0,0 +1,458 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingJob is a JobV2 whose runs block until they are released or their context is done.
+type blockingJob struct {
+	testJobV2
+	release       chan struct{}
+	ignoreContext bool // The runs block until they are released, even if their context is done
+}
+
+func newBlockingJob(t testing.TB, id JobId, release chan struct{}) *blockingJob {
+	return &blockingJob{testJobV2: testJobV2{testJob: *newTestJob(t, id, "0 0 1 1 *")}, release: release}
+}
+
+func (job *blockingJob) Execute(ctx context.Context, info RunInfo) (result Result, err error) {
+	if job.ignoreContext {
+		<-job.release
+		return result, nil
+	}
+	select {
+	case <-job.release:
+		return result, nil
+	case <-ctx.Done():
+		return result, ctx.Err()
+	}
+}
+
+// startTestRunner starts a JobRunner with "maxRunningJobs" slots. The started JobRuns are sent on "started"
+// and the finished or skipped ones on "finished".
+func startTestRunner(t *testing.T, maxRunningJobs int16) (runner *JobRunner, started chan *JobRun, finished chan *JobRun) {
+	runner = NewJobRunner(testLogger{}, maxRunningJobs, make(chan *JobRun, DEFAULT_JOB_RUN_CHAN_BUFFER))
+	started, finished = make(chan *JobRun, 100), make(chan *JobRun, 100)
+	runner.startedChan, runner.finishedChan = started, finished
+	if err := runner.Start(); err != nil {
+		t.Fatalf("failed to start the job runner: %v", err)
+	}
+	t.Cleanup(func() { runner.Stop() })
+	return
+}
+
+// submitRuns sends a manual JobRun of each job to the runner and returns them.
+func submitRuns(runner *JobRunner, jobs ...JobV2) (jobRuns []*JobRun) {
+	for _, job := range jobs {
+		jobRun := runner.CreateJobRun(job, time.Now(), TRIGGER_MANUAL)
+		runner.JobRunChan <- jobRun
+		jobRuns = append(jobRuns, jobRun)
+	}
+	return
+}
+
+func receiveRun(t *testing.T, jobRuns chan *JobRun) (jobRun *JobRun) {
+	t.Helper()
+	select {
+	case jobRun = <-jobRuns:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no job run was received")
+	}
+	return
+}
+
+func assertNoRun(t *testing.T, jobRuns chan *JobRun) {
+	t.Helper()
+	select {
+	case jobRun := <-jobRuns:
+		t.Fatalf("got the job run %v of the job %v, want none", jobRun.ID, jobRun.Fields.ID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// waitForCondition polls the condition until it is met.
+func waitForCondition(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %v", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestJobRunnerPendingQueue checks that at most MaxRunningJobCount runs execute at once and the queued
+// runs start in FIFO order as the slots free up.
+func TestJobRunnerPendingQueue(t *testing.T) {
+	for _, maxRunningJobs := range []int16{1, 2, 3} {
+		t.Run(fmt.Sprintf("slots=%v", maxRunningJobs), func(t *testing.T) {
+			runner, started, _ := startTestRunner(t, maxRunningJobs)
+			release := make(chan struct{})
+			var jobs []JobV2
+			for i := 0; i < 5; i++ {
+				jobs = append(jobs, newBlockingJob(t, JobId(fmt.Sprintf("job-%v", i)), release))
+			}
+			jobRuns := submitRuns(runner, jobs...)
+			for i, jobRun := range jobRuns {
+				if i >= int(maxRunningJobs) {
+					assertNoRun(t, started)
+					waitForCondition(t, "the queued runs", func() bool {
+						stats := runner.Stats()
+						return stats.RunningJobs == maxRunningJobs && stats.QueuedJobRuns == len(jobRuns)-i
+					})
+					release <- struct{}{}
+				}
+				if got := receiveRun(t, started); got != jobRun {
+					t.Fatalf("run #%v: got the run of %v, want the run of %v", i, got.Fields.ID, jobRun.Fields.ID)
+				}
+			}
+			if stats := runner.Stats(); stats.StartedJobRuns != int64(len(jobRuns)) || stats.QueuedJobRuns != 0 {
+				t.Fatalf("got the stats %+v, want all the runs started", stats)
+			}
+		})
+	}
+}
+
+// waitForRunDone waits until the JobRun is completed or skipped and returns its record.
+func waitForRunDone(t *testing.T, jobRun *JobRun) RunRecord {
+	t.Helper()
+	select {
+	case <-jobRun.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatalf("the job run %v of the job %v is not done", jobRun.ID, jobRun.Fields.ID)
+	}
+	return jobRun.Record()
+}
+
+// TestJobRunnerConcurrencyPolicy submits three runs of a job back to back and checks which of them
+// start, are skipped or are cancelled as per the job's ConcurrencyPolicy.
+func TestJobRunnerConcurrencyPolicy(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        ConcurrencyPolicy
+		maxInstances  int
+		slotBusy      bool // The only runner slot is taken by another job, so the runs are queued
+		wantStarted   []int
+		wantSkipped   []int
+		wantCancelled []int
+		wantReason    string
+	}{
+		{name: "allow", wantStarted: []int{0, 1, 2}},
+		{
+			name:        "forbid",
+			policy:      CONCURRENCY_POLICY_FORBID,
+			wantStarted: []int{0},
+			wantSkipped: []int{1, 2},
+			wantReason:  "Forbid concurrency policy. 1 run(s) of the job are still active",
+		},
+		{
+			name:        "forbid counts the queued runs",
+			policy:      CONCURRENCY_POLICY_FORBID,
+			slotBusy:    true,
+			wantStarted: []int{0},
+			wantSkipped: []int{1, 2},
+			wantReason:  "Forbid concurrency policy",
+		},
+		{
+			name:         "max instances",
+			policy:       CONCURRENCY_POLICY_MAX_INSTANCES,
+			maxInstances: 2,
+			wantStarted:  []int{0, 1},
+			wantSkipped:  []int{2},
+			wantReason:   "MaxInstances concurrency policy. 2 run(s) of the job are active, MaxInstances - 2",
+		},
+		{
+			name:          "replace cancels the running runs",
+			policy:        CONCURRENCY_POLICY_REPLACE,
+			wantStarted:   []int{0, 1, 2},
+			wantCancelled: []int{0, 1},
+		},
+		{
+			name:        "replace drops the queued runs",
+			policy:      CONCURRENCY_POLICY_REPLACE,
+			slotBusy:    true,
+			wantStarted: []int{2},
+			wantSkipped: []int{0, 1},
+			wantReason:  "Replace concurrency policy. Replaced by the job run - ",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			maxRunningJobs := int16(10)
+			if test.slotBusy {
+				maxRunningJobs = 1
+			}
+			runner, started, _ := startTestRunner(t, maxRunningJobs)
+			releaseBusy := make(chan struct{})
+			if test.slotBusy {
+				submitRuns(runner, newBlockingJob(t, "busy-job", releaseBusy))
+				receiveRun(t, started)
+			}
+			job := newBlockingJob(t, "policy-job", make(chan struct{}))
+			job.CommonJobFields.ConcurrencyPolicy = test.policy
+			job.CommonJobFields.MaxInstances = test.maxInstances
+			jobRuns := submitRuns(runner, job, job, job)
+
+			for _, i := range test.wantSkipped {
+				record := waitForRunDone(t, jobRuns[i])
+				if record.Status != RUN_STATUS_SKIPPED || !strings.HasPrefix(record.SkipReason, test.wantReason) {
+					t.Fatalf("run #%v: got the status %v with the reason %q, want skipped with %q",
+						i, record.Status, record.SkipReason, test.wantReason)
+				}
+			}
+			skipped := runner.GetSkippedJobRuns("policy-job")
+			if len(skipped) != len(test.wantSkipped) {
+				t.Fatalf("got %v skipped runs %+v, want %v", len(skipped), skipped, len(test.wantSkipped))
+			}
+			for i, want := range test.wantSkipped {
+				if skipped[i].RunID != jobRuns[want].ID || skipped[i].Reason != jobRuns[want].SkipReason {
+					t.Fatalf("skipped run #%v: got %+v, want the run #%v", i, skipped[i], want)
+				}
+			}
+
+			close(releaseBusy)
+			for _, i := range test.wantStarted {
+				if got := receiveRun(t, started); got != jobRuns[i] {
+					t.Fatalf("got the run %v started, want the run #%v", got.ID, i)
+				}
+			}
+			assertNoRun(t, started)
+			for _, i := range test.wantCancelled {
+				if record := waitForRunDone(t, jobRuns[i]); record.Status != RUN_STATUS_CANCELLED {
+					t.Fatalf("run #%v: got the status %v, want cancelled", i, record.Status)
+				}
+			}
+		})
+	}
+}
+
+// TestJobRunnerDrain stops the runner with the runs in flight and checks how the runs end.
+func TestJobRunnerDrain(t *testing.T) {
+	tests := []struct {
+		name          string
+		timeout       time.Duration
+		releaseAfter  time.Duration // The running run is released after this delay, if set
+		ignoreContext bool
+		wantStatus    RunStatus
+		wantErr       bool
+	}{
+		{name: "run completes within the timeout", timeout: 2 * time.Second, releaseAfter: 50 * time.Millisecond, wantStatus: RUN_STATUS_SUCCEEDED},
+		{name: "run is interrupted after the timeout", timeout: 50 * time.Millisecond, wantStatus: RUN_STATUS_INTERRUPTED},
+		{name: "run is interrupted right away", wantStatus: RUN_STATUS_INTERRUPTED},
+		{name: "run ignoring the interruption", ignoreContext: true, wantStatus: RUN_STATUS_RUNNING, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			runner, started, _ := startTestRunner(t, 1)
+			history := newTestRunHistory(t, filepath.Join(t.TempDir(), "runs.json"), RunRetention{})
+			runner.history = history
+			release := make(chan struct{})
+			t.Cleanup(func() { close(release) })
+			job := newBlockingJob(t, "running-job", release)
+			job.ignoreContext = test.ignoreContext
+			job.CommonJobFields.KillGracePeriod = Duration(10 * time.Millisecond)
+			jobRuns := submitRuns(runner, job, newBlockingJob(t, "queued-job", release))
+			receiveRun(t, started)
+			if test.releaseAfter > 0 {
+				time.AfterFunc(test.releaseAfter, func() { release <- struct{}{} })
+			}
+
+			if err := runner.Drain(test.timeout); (err != nil) != test.wantErr {
+				t.Fatalf("got the error %v from Drain(), want error %v", err, test.wantErr)
+			}
+			if record := waitForRunDone(t, jobRuns[1]); record.Status != RUN_STATUS_SKIPPED {
+				t.Errorf("got the status %v of the queued run, want skipped", record.Status)
+			}
+			record, _, _ := history.GetRun(jobRuns[0].ID)
+			if record.Status != test.wantStatus {
+				t.Errorf("got the recorded status %v of the running run, want %v", record.Status, test.wantStatus)
+			}
+		})
+	}
+}
+
+// TestJobRunnerRestart stops and starts the runner again and checks that it still runs the jobs.
+func TestJobRunnerRestart(t *testing.T) {
+	runner, started, _ := startTestRunner(t, 2)
+	if err := runner.Start(); err == nil {
+		t.Fatalf("got no error while starting the running job runner")
+	}
+	release := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		jobRun := submitRuns(runner, newBlockingJob(t, "restarted-job", release))[0]
+		receiveRun(t, started)
+		if err := runner.Stop(); err != nil {
+			t.Fatalf("stop #%v: got the error %v", i, err)
+		}
+		if record := waitForRunDone(t, jobRun); record.Status != RUN_STATUS_INTERRUPTED {
+			t.Fatalf("stop #%v: got the status %v, want interrupted", i, record.Status)
+		}
+		if err := runner.Stop(); err != nil {
+			t.Fatalf("stop #%v: got the error %v while stopping the stopped job runner", i, err)
+		}
+		if err := runner.Start(); err != nil {
+			t.Fatalf("start #%v: got the error %v", i, err)
+		}
+	}
+	// The slots of the interrupted runs are released.
+	waitForCondition(t, "the released slots", func() bool { return runner.Stats().RunningJobs == 0 })
+	jobRuns := submitRuns(runner, newBlockingJob(t, "job-1", release), newBlockingJob(t, "job-2", release))
+	for _, jobRun := range jobRuns {
+		if got := receiveRun(t, started); got != jobRun {
+			t.Fatalf("got the run of %v started, want the run of %v", got.Fields.ID, jobRun.Fields.ID)
+		}
+	}
+}
+
+// flakyJob is a JobV2 whose first "failures" runs fail with the exit code 1.
+type flakyJob struct {
+	testJobV2
+	failures int32
+	runs     atomic.Int32
+}
+
+func (job *flakyJob) Execute(ctx context.Context, info RunInfo) (result Result, err error) {
+	if job.runs.Add(1) > job.failures {
+		return result, nil
+	}
+	exitCode := 1
+	return Result{ExitCode: &exitCode}, fmt.Errorf("exit status 1")
+}
+
+// TestJobRunnerRetries runs a job which fails a few times and checks the attempts of the retry chain.
+func TestJobRunnerRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int32
+		policy       RetryPolicy
+		nextRunIn    time.Duration // NextRun of the job after the first run, if set
+		wantAttempts int
+		wantStatus   RunStatus
+	}{
+		{
+			name:         "retried until it succeeds",
+			failures:     2,
+			policy:       RetryPolicy{MaxAttempts: 5},
+			wantAttempts: 3,
+			wantStatus:   RUN_STATUS_SUCCEEDED,
+		},
+		{
+			name:         "retried up to MaxAttempts",
+			failures:     5,
+			policy:       RetryPolicy{MaxAttempts: 3},
+			wantAttempts: 3,
+			wantStatus:   RUN_STATUS_FAILED,
+		},
+		{
+			name:         "exit code is not retryable",
+			failures:     5,
+			policy:       RetryPolicy{MaxAttempts: 3, RetryableExitCodes: []int{2}},
+			wantAttempts: 1,
+			wantStatus:   RUN_STATUS_FAILED,
+		},
+		{
+			name:         "retry at the next run is dropped",
+			failures:     5,
+			policy:       RetryPolicy{MaxAttempts: 3},
+			nextRunIn:    time.Second,
+			wantAttempts: 1,
+			wantStatus:   RUN_STATUS_FAILED,
+		},
+		{
+			name:         "retry before the next run",
+			failures:     5,
+			policy:       RetryPolicy{MaxAttempts: 2},
+			nextRunIn:    time.Second + time.Nanosecond,
+			wantAttempts: 2,
+			wantStatus:   RUN_STATUS_FAILED,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			runner, started, finished := startTestRunner(t, 1)
+			// The retries are sent after the delay on the fake clock, so that the NextRun can be compared exactly.
+			clock := newFakeClock(mustParseTime(t, "2026-03-01T10:00:00Z"))
+			runner.clock = clock
+			job := &flakyJob{testJobV2: testJobV2{testJob: *newTestJob(t, "flaky-job", "0 0 1 1 *")}, failures: test.failures}
+			test.policy.InitialDelay = Duration(time.Second)
+			job.CommonJobFields.RetryPolicy = &test.policy
+			if test.nextRunIn > 0 {
+				job.CommonJobFields.NextRun = clock.Now().Add(test.nextRunIn)
+			}
+			first := submitRuns(runner, job)[0]
+
+			for attempt := 1; attempt <= test.wantAttempts; attempt++ {
+				if attempt > 1 {
+					// Nothing is finished until the last attempt.
+					assertNoRun(t, finished)
+					clock.fireNextTimer(t)
+				}
+				jobRun := receiveRun(t, started)
+				wantParent := first.ID
+				if attempt == 1 {
+					wantParent = ""
+				}
+				if jobRun.Attempt != attempt || jobRun.ParentRunID != wantParent || jobRun.OriginTrigger != TRIGGER_MANUAL {
+					t.Fatalf("got the attempt %v with the parent %q and the origin %v, want the attempt %v with the parent %q and the origin %v",
+						jobRun.Attempt, jobRun.ParentRunID, jobRun.OriginTrigger, attempt, wantParent, TRIGGER_MANUAL)
+				}
+			}
+			last := receiveRun(t, finished)
+			if record := last.Record(); record.Attempt != test.wantAttempts || record.Status != test.wantStatus {
+				t.Fatalf("got the finished attempt %v with the status %v, want the attempt %v with the status %v",
+					record.Attempt, record.Status, test.wantAttempts, test.wantStatus)
+			}
+			assertNoRun(t, started)
+			assertNoRun(t, finished)
+		})
+	}
+}
+
+// TestJobRunnerJitter checks that only the scheduled runs are delayed by a random jitter below the job's MaxJitter.
+func TestJobRunnerJitter(t *testing.T) {
+	runner, started, finished := startTestRunner(t, 1)
+	clock := newFakeClock(mustParseTime(t, "2026-03-01T10:00:00Z"))
+	runner.clock = clock
+	history := newTestRunHistory(t, filepath.Join(t.TempDir(), "runs.json"), RunRetention{})
+	runner.history = history
+	maxJitter := time.Minute
+	job := &flakyJob{testJobV2: testJobV2{testJob: *newTestJob(t, "jittered-job", "0 0 1 1 *")}}
+	job.CommonJobFields.MaxJitter = Duration(maxJitter)
+
+	for i := 0; i < 10; i++ {
+		scheduledAt := clock.Now()
+		runner.JobRunChan <- runner.CreateJobRun(job, scheduledAt, TRIGGER_SCHEDULE)
+		assertNoRun(t, started)
+		firedAt := clock.fireNextTimer(t)
+		jobRun := receiveRun(t, started)
+		jitter := firedAt.Sub(scheduledAt)
+		if jitter < 0 || jitter >= maxJitter || jobRun.Jitter != Duration(jitter) || !jobRun.RanAt.Equal(firedAt) {
+			t.Fatalf("run #%v: got the jitter %v (recorded %v) and the start at %v, want the delay below %v recorded",
+				i, jitter, jobRun.Jitter, jobRun.RanAt, maxJitter)
+		}
+		receiveRun(t, finished)
+		if record, _, _ := history.GetRun(jobRun.ID); record.Jitter != jobRun.Jitter {
+			t.Fatalf("run #%v: got the jitter %v in the run history, want %v", i, record.Jitter, jobRun.Jitter)
+		}
+	}
+
+	// The manual runs and the retries start right away.
+	job.failures = job.runs.Load() + 1
+	job.CommonJobFields.RetryPolicy = &RetryPolicy{MaxAttempts: 2, InitialDelay: Duration(time.Second)}
+	manualRun := submitRuns(runner, job)[0]
+	if jobRun := receiveRun(t, started); jobRun != manualRun || jobRun.Jitter != 0 {
+		t.Fatalf("got the run %v with the jitter %v, want the manual run %v without jitter", jobRun.ID, jobRun.Jitter, manualRun.ID)
+	}
+	retriedAt := clock.fireNextTimer(t)
+	if jobRun := receiveRun(t, started); jobRun.Trigger != TRIGGER_RETRY || jobRun.Jitter != 0 || !jobRun.RanAt.Equal(retriedAt) {
+		t.Fatalf("got the %v run with the jitter %v started at %v, want the retry without jitter at %v",
+			jobRun.Trigger, jobRun.Jitter, jobRun.RanAt, retriedAt)
+	}
+}
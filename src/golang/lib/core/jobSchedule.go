@@ -0,0 +1,134 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shreyasksrao/jobmanager/lib/schedule"
+)
+
+// ScheduleType tells how the runs of a job with a JobSchedule are scheduled.
+type ScheduleType string
+
+const (
+	// Run once at At.
+	SCHEDULE_TYPE_ONCE ScheduleType = "Once"
+	// Run every Interval counted from the completion of the previous run (fixed delay). Runs never overlap.
+	SCHEDULE_TYPE_INTERVAL ScheduleType = "Interval"
+	// Run every Interval counted from FirstRun, irrespective of how long the runs take (fixed rate).
+	SCHEDULE_TYPE_RATE ScheduleType = "Rate"
+
+	// Shortest Interval of the Interval and Rate schedules.
+	MIN_SCHEDULE_INTERVAL = time.Second
+)
+
+// ExpiryAction decides what the JobManager does with a job which expired, i.e. its schedule, EndAt or
+// MaxRuns allow no more runs (e.g. a Once job after its run).
+type ExpiryAction string
+
+const (
+	// Pause the job (default). It stays in the JobManager and can still be run manually.
+	EXPIRY_ACTION_DISABLE ExpiryAction = "Disable"
+	// Remove the job from the JobManager and delete its persisted record (see DeletableJob).
+	EXPIRY_ACTION_DELETE ExpiryAction = "Delete"
+)
+
+// JobSchedule is a schedule of a job which is not expressed by the job itself (e.g. the CronExpr of a
+// CommandJob). It is persisted in the CommonJobFields, and the JobManager uses it instead of the job's
+// GetNextScheduleTime() when it is set.
+type JobSchedule struct {
+	Type     ScheduleType `json:"Type"`               // Once, Interval or Rate
+	At       time.Time    `json:"At"`                 // Run time of the Once schedule
+	Interval Duration     `json:"Interval,omitempty"` // Time between the runs of the Interval and Rate schedules
+	// First run of the Interval and Rate schedules. If it is not set, the first run of the Interval schedule
+	// is one Interval after the job is scheduled and the runs of the Rate schedule are aligned to the Unix epoch.
+	FirstRun time.Time `json:"FirstRun"`
+}
+
+// DeletableJob is implemented by the jobs which can delete their persisted record. It is used to
+// delete the jobs expiring with the Delete expiry action.
+type DeletableJob interface {
+	Delete() (err error)
+}
+
+// Schedule returns the schedule.Schedule computing the run times of the JobSchedule.
+func (jobSchedule *JobSchedule) Schedule() (runSchedule schedule.Schedule, err error) {
+	switch jobSchedule.Type {
+	case SCHEDULE_TYPE_ONCE:
+		return &schedule.OnceSchedule{At: jobSchedule.At}, nil
+	case SCHEDULE_TYPE_INTERVAL:
+		return &schedule.EverySchedule{Interval: jobSchedule.Interval.Duration()}, nil
+	case SCHEDULE_TYPE_RATE:
+		return &schedule.RateSchedule{Start: jobSchedule.FirstRun, Interval: jobSchedule.Interval.Duration()}, nil
+	default:
+		return nil, fmt.Errorf("invalid schedule Type - %v. Supported values are Once, Interval and Rate", jobSchedule.Type)
+	}
+}
+
+// Next returns the next run after "now". Zero time is returned if the schedule has no more runs.
+func (jobSchedule *JobSchedule) Next(now time.Time) (nextRun time.Time, err error) {
+	if jobSchedule.Type == SCHEDULE_TYPE_INTERVAL && jobSchedule.FirstRun.After(now) {
+		return jobSchedule.FirstRun.In(now.Location()), nil
+	}
+	runSchedule, err := jobSchedule.Schedule()
+	if err != nil {
+		return
+	}
+	return runSchedule.Next(now), nil
+}
+
+// isType tells whether the schedule is set and has the type.
+func (jobSchedule *JobSchedule) isType(scheduleType ScheduleType) bool {
+	return jobSchedule != nil && jobSchedule.Type == scheduleType
+}
+
+// ValidateSchedule checks the Schedule, the active window, the MaxRuns, the calendar modes and the ExpiryAction
+// of the job. Nil Schedule is valid. The calendars are checked by JobManager.ValidateJobCalendars().
+func ValidateSchedule(fields *CommonJobFields) (err error) {
+	switch fields.ExpiryAction {
+	case "", EXPIRY_ACTION_DISABLE, EXPIRY_ACTION_DELETE:
+	default:
+		return fmt.Errorf("invalid ExpiryAction - %v. Supported values are Disable and Delete", fields.ExpiryAction)
+	}
+	if fields.StartAt != nil && fields.EndAt != nil && !fields.StartAt.Before(*fields.EndAt) {
+		return fmt.Errorf("invalid active window. StartAt - %v should be before EndAt - %v", fields.StartAt, fields.EndAt)
+	}
+	if fields.MaxRuns < 0 {
+		return fmt.Errorf("invalid MaxRuns - %v. MaxRuns can not be negative", fields.MaxRuns)
+	}
+	for _, jobCalendar := range fields.Calendars {
+		switch jobCalendar.Mode {
+		case "", CALENDAR_MODE_INCLUDE, CALENDAR_MODE_EXCLUDE:
+		default:
+			return fmt.Errorf("invalid Mode - %v of the calendar - %v. Supported values are Include and Exclude", jobCalendar.Mode, jobCalendar.ID)
+		}
+	}
+	switch fields.CalendarRoll {
+	case "", schedule.ROLL_NONE, schedule.ROLL_PREVIOUS, schedule.ROLL_NEXT:
+	default:
+		return fmt.Errorf("invalid CalendarRoll - %v. Supported values are None, Previous and Next", fields.CalendarRoll)
+	}
+	jobSchedule := fields.Schedule
+	if jobSchedule == nil {
+		return nil
+	}
+	switch jobSchedule.Type {
+	case SCHEDULE_TYPE_ONCE:
+		if jobSchedule.At.IsZero() {
+			return fmt.Errorf("invalid Schedule. At should be set for the Once schedule")
+		}
+		if jobSchedule.Interval != 0 || !jobSchedule.FirstRun.IsZero() {
+			return fmt.Errorf("invalid Schedule. Interval and FirstRun can not be set for the Once schedule")
+		}
+	case SCHEDULE_TYPE_INTERVAL, SCHEDULE_TYPE_RATE:
+		if jobSchedule.Interval.Duration() < MIN_SCHEDULE_INTERVAL {
+			return fmt.Errorf("invalid Schedule Interval - %v. Interval should be at least %v", jobSchedule.Interval.Duration(), MIN_SCHEDULE_INTERVAL)
+		}
+		if !jobSchedule.At.IsZero() {
+			return fmt.Errorf("invalid Schedule. At can be set only for the Once schedule")
+		}
+	default:
+		return fmt.Errorf("invalid schedule Type - %v. Supported values are Once, Interval and Rate", jobSchedule.Type)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+// finishTestRun reads the next dispatched JobRun, marks it as started at "ranAt" and hands it back
+// to the scheduler as finished.
+func finishTestRun(t *testing.T, manager *JobManager, ranAt time.Time) (jobRun *JobRun) {
+	t.Helper()
+	select {
+	case jobRun = <-manager.jobRunChan:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no job run was dispatched")
+	}
+	jobRun.RanAt = ranAt
+	jobRun.CompletedAt = ranAt
+	manager.finishedChan <- jobRun
+	return
+}
+
+// waitForJob waits until the state of the job in the JobManager satisfies the condition.
+func waitForJob(t *testing.T, manager *JobManager, jobId JobId, condition func(job JobV2, found bool) bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, found := manager.GetJob(jobId); condition(job, found) {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	job, found := manager.GetJob(jobId)
+	t.Fatalf("job - %v didn't reach the expected state. Found - %v, job - %+v", jobId, found, job)
+}
+
+func TestJobManagerIntervalSchedule(t *testing.T) {
+	clock := newFakeClock(mustParseTime(t, "2026-01-01T10:00:00Z"))
+	manager := newTestJobManager(clock, time.UTC)
+	job := &testJobV2{testJob: *newTestJob(t, "interval-job", "* * * * *")}
+	job.CommonJobFields.Schedule = &JobSchedule{Type: SCHEDULE_TYPE_INTERVAL, Interval: Duration(17 * time.Minute)}
+	manager.AddJobV2(job)
+	startTestScheduler(t, manager)
+
+	if fired := clock.fireNextTimer(t); !fired.Equal(mustParseTime(t, "2026-01-01T10:17:00Z")) {
+		t.Fatalf("got the first run at %v, want one interval after the start", fired)
+	}
+	// No run is scheduled until the dispatched run finishes.
+	waitForJob(t, manager, "interval-job", func(job JobV2, found bool) bool {
+		return found && job.GetCommonJobFields().NextRun.IsZero()
+	})
+	// A retry of a manual run finishing meanwhile doesn't schedule the job, as the manual run wouldn't.
+	retriedAt := clock.Now()
+	manager.finishedChan <- &JobRun{ID: "manual-retry", Job: job, Fields: job.CommonJobFields.Copy(), Trigger: TRIGGER_RETRY,
+		OriginTrigger: TRIGGER_MANUAL, Attempt: 2, RanAt: retriedAt, CompletedAt: retriedAt, done: make(chan struct{})}
+	waitForJob(t, manager, "interval-job", func(job JobV2, found bool) bool {
+		return found && job.GetCommonJobFields().LastRun.Equal(retriedAt)
+	})
+	if job, _ := manager.GetJob("interval-job"); !job.GetCommonJobFields().NextRun.IsZero() {
+		t.Fatalf("got the next run at %v after the retry of a manual run, want none", job.GetCommonJobFields().NextRun)
+	}
+	clock.mu.Lock()
+	clock.now = mustParseTime(t, "2026-01-01T10:20:30Z")
+	clock.mu.Unlock()
+	finishTestRun(t, manager, mustParseTime(t, "2026-01-01T10:17:00Z"))
+	want := mustParseTime(t, "2026-01-01T10:37:30Z")
+	waitForJob(t, manager, "interval-job", func(job JobV2, found bool) bool {
+		return found && job.GetCommonJobFields().NextRun.Equal(want)
+	})
+}
+
+func TestJobManagerOnceSchedule(t *testing.T) {
+	clock := newFakeClock(mustParseTime(t, "2026-01-01T10:00:00Z"))
+	manager := newTestJobManager(clock, time.UTC)
+	at := mustParseTime(t, "2026-01-01T11:00:00Z")
+	for _, expiryAction := range []ExpiryAction{EXPIRY_ACTION_DISABLE, EXPIRY_ACTION_DELETE} {
+		job := &testJobV2{testJob: *newTestJob(t, JobId(expiryAction), "* * * * *")}
+		job.CommonJobFields.Schedule = &JobSchedule{Type: SCHEDULE_TYPE_ONCE, At: at}
+		job.CommonJobFields.ExpiryAction = expiryAction
+		manager.AddJobV2(job)
+	}
+	// The upstream job of another job is disabled instead of being deleted.
+	upstream := &testJobV2{testJob: *newTestJob(t, "upstream", "* * * * *")}
+	upstream.CommonJobFields.Schedule = &JobSchedule{Type: SCHEDULE_TYPE_ONCE, At: at}
+	upstream.CommonJobFields.ExpiryAction = EXPIRY_ACTION_DELETE
+	manager.AddJobV2(upstream)
+	dependent := newDependentTestJob(t, "dependent", "upstream")
+	dependent.GetCommonJobFields().Paused = true
+	manager.AddJobV2(dependent)
+	// The run of this job was missed while the JobManager was down, so it runs right away.
+	missed := &testJobV2{testJob: *newTestJob(t, "missed", "* * * * *")}
+	missed.CommonJobFields.Schedule = &JobSchedule{Type: SCHEDULE_TYPE_ONCE, At: mustParseTime(t, "2026-01-01T09:00:00Z")}
+	manager.AddJobV2(missed)
+	startTestScheduler(t, manager)
+
+	assertScheduledRuns(t, collectScheduledRuns(t, manager, clock, 1), []string{"2026-01-01T09:00:00Z"})
+	if fired := clock.fireNextTimer(t); !fired.Equal(at) {
+		t.Fatalf("got the run at %v, want it at %v", fired, at)
+	}
+	for i := 0; i < 3; i++ {
+		finishTestRun(t, manager, at)
+	}
+	waitForJob(t, manager, JobId(EXPIRY_ACTION_DISABLE), func(job JobV2, found bool) bool {
+		return found && job.GetCommonJobFields().Paused && job.GetCommonJobFields().NextRun.IsZero()
+	})
+	waitForJob(t, manager, JobId(EXPIRY_ACTION_DELETE), func(job JobV2, found bool) bool {
+		return !found
+	})
+	waitForJob(t, manager, "upstream", func(job JobV2, found bool) bool {
+		return found && job.GetCommonJobFields().Paused && job.GetCommonJobFields().ExpiredAt != nil
+	})
+}
+
+func TestValidateSchedule(t *testing.T) {
+	at := mustParseTime(t, "2026-11-01T03:00:00Z")
+	valid := []CommonJobFields{
+		{},
+		{Schedule: &JobSchedule{Type: SCHEDULE_TYPE_ONCE, At: at}, ExpiryAction: EXPIRY_ACTION_DELETE},
+		{Schedule: &JobSchedule{Type: SCHEDULE_TYPE_INTERVAL, Interval: Duration(17 * time.Minute)}},
+		{Schedule: &JobSchedule{Type: SCHEDULE_TYPE_RATE, Interval: Duration(90 * time.Second), FirstRun: at}},
+	}
+	for i, fields := range valid {
+		if err := ValidateSchedule(&fields); err != nil {
+			t.Fatalf("valid #%v: got the error %v", i, err)
+		}
+	}
+	invalid := []CommonJobFields{
+		{ExpiryAction: "Archive"},
+		{Schedule: &JobSchedule{Type: "Weekly"}},
+		{Schedule: &JobSchedule{Type: SCHEDULE_TYPE_ONCE}},
+		{Schedule: &JobSchedule{Type: SCHEDULE_TYPE_ONCE, At: at, Interval: Duration(time.Minute)}},
+		{Schedule: &JobSchedule{Type: SCHEDULE_TYPE_RATE, Interval: Duration(time.Millisecond)}},
+		{Schedule: &JobSchedule{Type: SCHEDULE_TYPE_INTERVAL, Interval: Duration(time.Minute), At: at}},
+	}
+	for i, fields := range invalid {
+		if err := ValidateSchedule(&fields); err == nil {
+			t.Fatalf("invalid #%v: got no error", i)
+		}
+	}
+}
+
+func TestJobManagerActiveWindow(t *testing.T) {
+	clock := newFakeClock(mustParseTime(t, "2026-01-01T10:00:00Z"))
+	manager := newTestJobManager(clock, time.UTC)
+	startAt, endAt := mustParseTime(t, "2026-01-01T12:00:00Z"), mustParseTime(t, "2026-01-01T14:30:00Z")
+	windowJob := &testJobV2{testJob: *newTestJob(t, "window-job", "0 * * * *")}
+	windowJob.CommonJobFields.StartAt, windowJob.CommonJobFields.EndAt = &startAt, &endAt
+	manager.AddJobV2(windowJob)
+	countedJob := &testJobV2{testJob: *newTestJob(t, "counted-job", "30 * * * *")}
+	countedJob.CommonJobFields.MaxRuns = 2
+	countedJob.CommonJobFields.ExpiryAction = EXPIRY_ACTION_DELETE
+	manager.AddJobV2(countedJob)
+	startTestScheduler(t, manager)
+
+	assertScheduledRuns(t, collectScheduledRuns(t, manager, clock, 5), []string{
+		"2026-01-01T10:30:00Z", "2026-01-01T11:30:00Z", "2026-01-01T12:00:00Z", "2026-01-01T13:00:00Z", "2026-01-01T14:00:00Z",
+	})
+	// The jobs expire when their last run finishes.
+	finishLastRun := func(jobId JobId) {
+		job, _ := manager.GetJob(jobId)
+		manager.finishedChan <- manager.jobRunner.CreateJobRun(job, clock.Now(), TRIGGER_SCHEDULE)
+	}
+	finishLastRun("window-job")
+	waitForJob(t, manager, "window-job", func(job JobV2, found bool) bool {
+		if !found {
+			return false
+		}
+		fields := job.GetCommonJobFields()
+		return fields.Paused && fields.ExpiredAt != nil && fields.ExpiryReason != "" && fields.RunCount == 3
+	})
+	if err := manager.ResumeJob("window-job"); err == nil {
+		t.Fatalf("got no error while resuming the expired job")
+	}
+	finishLastRun("counted-job")
+	waitForJob(t, manager, "counted-job", func(job JobV2, found bool) bool {
+		return !found
+	})
+}
@@ -0,0 +1,167 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// JobStore persists the jobs of all the types registered in a JobRegistry.
+type JobStore interface {
+	// SaveJob and DeleteJob may write the change in the background. Flush() writes the changes made so far.
+	SaveJob(job JobV2) (err error)
+	DeleteJob(jobId JobId) (err error)
+	Flush() (err error)
+	// LoadJobs returns all the persisted jobs, ordered by the job ID.
+	LoadJobs() (jobs []JobV2, err error)
+}
+
+// FileJobStore is a JobStore persisted as a JSON file (resources/jobs.json) holding the jobs keyed by the job ID.
+// Every job is encoded by the codec of its type, along with the "Type" field. The jobs of the types which are
+// not registered are kept in the file as they are, but not loaded. The encoded jobs are kept in memory and
+// the file is written in batches by a background go-routine, so that saving a job doesn't rewrite the file.
+type FileJobStore struct {
+	Logger   Logger
+	FilePath string
+	Registry *JobRegistry
+	// Encoded jobs keyed by the job ID, read from the file on the first use. The jobs are saved from the
+	// scheduler as well as the runner go-routines.
+	entries map[string]json.RawMessage
+	// Tells whether the entries were changed since they were last written.
+	changed bool
+	mu      sync.Mutex
+	writer  *batchWriter
+}
+
+// NewFileJobStore creates the FileJobStore. Close() the store to write the last changes.
+func NewFileJobStore(logger Logger, filePath string, registry *JobRegistry) (store *FileJobStore) {
+	logger.Infof("Creating the job store with the file - %v", filePath)
+	store = &FileJobStore{Logger: logger, FilePath: filePath, Registry: registry}
+	store.writer = newBatchWriter(DEFAULT_BATCH_WRITE_DELAY, store.save)
+	return
+}
+
+func (store *FileJobStore) SaveJob(job JobV2) (err error) {
+	jobId := job.GetCommonJobFields().ID
+	data, err := store.Registry.Encode(job)
+	if err != nil {
+		store.Logger.Errorf("Failed to save the job - %v. Error - %v", jobId, err)
+		return err
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.Logger.Infof("Saving the job with ID - %v to the resource file.", jobId)
+	if err = store.loadEntries(); err != nil {
+		return fmt.Errorf("failed to save the job - %v. Error - %v", jobId, err)
+	}
+	store.entries[string(jobId)] = data
+	store.changed = true
+	store.writer.schedule()
+	return nil
+}
+
+func (store *FileJobStore) DeleteJob(jobId JobId) (err error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.Logger.Infof("Deleting the job with ID - %v from the resource file.", jobId)
+	if err = store.loadEntries(); err != nil {
+		return fmt.Errorf("failed to delete the job - %v. Error - %v", jobId, err)
+	}
+	delete(store.entries, string(jobId))
+	store.changed = true
+	store.writer.schedule()
+	return nil
+}
+
+// Flush writes the saved jobs to the file now.
+func (store *FileJobStore) Flush() (err error) {
+	return store.writer.flush()
+}
+
+// Close stops the background writes and writes the last changes.
+func (store *FileJobStore) Close() (err error) {
+	return store.writer.close()
+}
+
+func (store *FileJobStore) LoadJobs() (jobs []JobV2, err error) {
+	store.mu.Lock()
+	err = store.loadEntries()
+	entries := make(map[string]json.RawMessage, len(store.entries))
+	for id, data := range store.entries {
+		entries[id] = data
+	}
+	store.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	for id, data := range entries {
+		job, err := store.Registry.Decode(data)
+		if err != nil {
+			store.Logger.Errorf("Failed to load the job - %v. It is kept in the file, but not scheduled. Error - %v", id, err)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].GetCommonJobFields().ID < jobs[j].GetCommonJobFields().ID })
+	store.Logger.Infof("Loaded %v jobs from the file - %v", len(jobs), store.FilePath)
+	return jobs, nil
+}
+
+// loadEntries reads the encoded jobs keyed by the job ID from the file, once. A missing file has no jobs.
+// Called with mu held.
+func (store *FileJobStore) loadEntries() (err error) {
+	if store.entries != nil {
+		return nil
+	}
+	entries := make(map[string]json.RawMessage)
+	fileData, err := os.ReadFile(store.FilePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		store.Logger.Errorf("Error reading the jobs file - %v. Error - %v", store.FilePath, err)
+		return err
+	}
+	if len(fileData) > 0 {
+		if err = json.Unmarshal(fileData, &entries); err != nil {
+			store.Logger.Errorf("Error parsing the jobs file - %v. Error - %v", store.FilePath, err)
+			return err
+		}
+	}
+	store.entries = entries
+	return nil
+}
+
+// save replaces the jobs file with the encoded jobs if they were changed. It is called by the batchWriter.
+func (store *FileJobStore) save() (err error) {
+	store.mu.Lock()
+	if !store.changed {
+		store.mu.Unlock()
+		return nil
+	}
+	// The jobs are encoded without holding mu, so that the jobs can be saved in the meantime.
+	entries := make(map[string]json.RawMessage, len(store.entries))
+	for id, data := range store.entries {
+		entries[id] = data
+	}
+	store.changed = false
+	store.mu.Unlock()
+	defer func() {
+		if err != nil {
+			// Written again by the next save.
+			store.mu.Lock()
+			store.changed = true
+			store.mu.Unlock()
+		}
+	}()
+	jsonData, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		store.Logger.Errorf("Error marshaling the jobs. Error - %v", err)
+		return err
+	}
+	if err = os.WriteFile(store.FilePath, jsonData, 0644); err != nil {
+		store.Logger.Errorf("Error writing the jobs file - %v. Error - %v", store.FilePath, err)
+		return fmt.Errorf("failed to write the jobs file - %v. Error - %v", store.FilePath, err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// otherTestJob is a second job type for the registry tests.
+type otherTestJob struct {
+	testJobV2
+	Message string `json:"Message"`
+}
+
+func newTestJobRegistry(t *testing.T) (registry *JobRegistry) {
+	registry = NewJobRegistry("Test")
+	jobTypes := []JobType{
+		{Name: "Test", New: func() JobV2 { return &testJobV2{testJob: *newTestJob(t, "", "0 * * * *")} }},
+		{
+			Name: "Other",
+			New:  func() JobV2 { return &otherTestJob{testJobV2: testJobV2{testJob: *newTestJob(t, "", "0 * * * *")}} },
+			Validate: func(job JobV2) error {
+				if job.(*otherTestJob).Message == "" {
+					return fmt.Errorf("Message is not set")
+				}
+				return nil
+			},
+		},
+	}
+	for _, jobType := range jobTypes {
+		if err := registry.Register(jobType); err != nil {
+			t.Fatalf("got the error %v while registering the type - %v", err, jobType.Name)
+		}
+	}
+	return
+}
+
+func TestJobRegistry(t *testing.T) {
+	type duplicateTestJob struct{ testJobV2 }
+	registry := newTestJobRegistry(t)
+	if err := registry.Register(JobType{Name: "Test", New: func() JobV2 { return &duplicateTestJob{} }}); err == nil {
+		t.Errorf("got no error while registering a type name twice")
+	}
+	if err := registry.Register(JobType{Name: "Another", New: func() JobV2 { return &testJobV2{} }}); err == nil {
+		t.Errorf("got no error while registering a Go type twice")
+	}
+
+	data, err := registry.Encode(&otherTestJob{testJobV2: testJobV2{testJob: testJob{CommonJobFields: CommonJobFields{ID: "other"}}}, Message: "hello"})
+	if err != nil {
+		t.Fatalf("got the error %v while encoding the job", err)
+	}
+	job, err := registry.Decode(data)
+	if err != nil {
+		t.Fatalf("got the error %v while decoding %s", err, data)
+	}
+	if other, ok := job.(*otherTestJob); !ok || other.Message != "hello" || other.CommonJobFields.ID != "other" {
+		t.Errorf("got the job %+v from %s, want the other job", job, data)
+	}
+	if err = registry.Validate(&otherTestJob{}); err == nil {
+		t.Errorf("got no error while validating an invalid job")
+	}
+
+	// The jobs without a Type are of the default type.
+	if job, err = registry.Decode([]byte(`{"CommonJobFields": {"ID": "legacy"}}`)); err != nil {
+		t.Fatalf("got the error %v while decoding a job without a Type", err)
+	}
+	if _, ok := job.(*testJobV2); !ok {
+		t.Errorf("got the job %T for a job without a Type, want *testJobV2", job)
+	}
+	if _, err = registry.Decode([]byte(`{"Type": "Missing"}`)); err == nil || !strings.Contains(err.Error(), "Other, Test") {
+		t.Errorf("got the error %v for an unknown Type, want the registered types listed", err)
+	}
+}
+
+func TestFileJobStore(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "jobs.json")
+	legacy := `{
+		"legacy": {"CommonJobFields": {"ID": "legacy"}},
+		"unknown": {"Type": "Removed", "CommonJobFields": {"ID": "unknown"}}
+	}`
+	if err := os.WriteFile(filePath, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store := NewFileJobStore(testLogger{}, filePath, newTestJobRegistry(t))
+	defer store.Close()
+	other := &otherTestJob{testJobV2: testJobV2{testJob: testJob{CommonJobFields: CommonJobFields{ID: "other"}}}, Message: "hello"}
+	if err := store.SaveJob(other); err != nil {
+		t.Fatalf("got the error %v while saving the job", err)
+	}
+
+	jobs, err := store.LoadJobs()
+	if err != nil {
+		t.Fatalf("got the error %v while loading the jobs", err)
+	}
+	if len(jobs) != 2 || jobs[0].GetCommonJobFields().ID != "legacy" || jobs[1].GetCommonJobFields().ID != "other" {
+		t.Fatalf("got the jobs %+v, want legacy and other", jobs)
+	}
+	if loaded, ok := jobs[1].(*otherTestJob); !ok || loaded.Message != "hello" {
+		t.Errorf("got the job %+v, want the saved other job", jobs[1])
+	}
+
+	// The legacy job gets its Type when it is saved, and the job of the unknown type is kept.
+	if err = store.SaveJob(jobs[0]); err != nil {
+		t.Fatalf("got the error %v while saving the job", err)
+	}
+	if err = store.DeleteJob("other"); err != nil {
+		t.Fatalf("got the error %v while deleting the job", err)
+	}
+	if err = store.Flush(); err != nil {
+		t.Fatalf("got the error %v while writing the jobs file", err)
+	}
+	fileData, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries map[string]map[string]any
+	if err = json.Unmarshal(fileData, &entries); err != nil {
+		t.Fatalf("got the error %v while parsing %s", err, fileData)
+	}
+	if len(entries) != 2 || entries["legacy"][JOB_TYPE_FIELD] != "Test" || entries["unknown"][JOB_TYPE_FIELD] != "Removed" {
+		t.Errorf("got the jobs file %s, want the typed legacy job and the unknown job", fileData)
+	}
+}
+
+func loadStoredJobs(t *testing.T, filePath string, registry *JobRegistry) (jobs []JobV2, err error) {
+	store := NewFileJobStore(testLogger{}, filePath, registry)
+	defer store.Close()
+	return store.LoadJobs()
+}
+
+// TestFileJobStoreBatchedWrites checks that the saved jobs are written to the file in the background and that
+// Close() writes the last changes.
+func TestFileJobStoreBatchedWrites(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "jobs.json")
+	registry := newTestJobRegistry(t)
+	store := NewFileJobStore(testLogger{}, filePath, registry)
+	for i := 0; i < 3; i++ {
+		if err := store.SaveJob(newDependentTestJob(t, JobId(fmt.Sprintf("job-%v", i)))); err != nil {
+			t.Fatalf("got the error %v while saving the job", err)
+		}
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if jobs, err := loadStoredJobs(t, filePath, registry); err == nil && len(jobs) == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the saved jobs are not written to the file")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	store.DeleteJob("job-1")
+	if err := store.Close(); err != nil {
+		t.Fatalf("got the error %v while closing the store", err)
+	}
+	jobs, err := loadStoredJobs(t, filePath, registry)
+	if err != nil || len(jobs) != 2 || jobs[0].GetCommonJobFields().ID != "job-0" || jobs[1].GetCommonJobFields().ID != "job-2" {
+		t.Fatalf("got the jobs %v and the error %v after closing the store, want job-0 and job-2", jobs, err)
+	}
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// EventType tells what happened to a job or a run of a job.
+type EventType string
+
+const (
+	EVENT_JOB_ADDED   EventType = "job_added"
+	EVENT_JOB_UPDATED EventType = "job_updated"
+	EVENT_JOB_REMOVED EventType = "job_removed"
+	// The schedule, EndAt or MaxRuns of the job allow no more runs. The job is disabled or removed next.
+	EVENT_JOB_EXPIRED EventType = "job_expired"
+	// A JobRun was handed to the JobRunner (scheduled, catch-up, manual, retry or dependency triggered run).
+	EVENT_RUN_SCHEDULED EventType = "run_scheduled"
+	EVENT_RUN_STARTED   EventType = "run_started"
+	// Every started run ends with either a finished (succeeded) or a failed (failed, timed out or cancelled) event.
+	EVENT_RUN_FINISHED EventType = "run_finished"
+	EVENT_RUN_FAILED   EventType = "run_failed"
+	// A JobRun was dropped as per the concurrency policy or because the JobRunner stopped.
+	EVENT_RUN_SKIPPED EventType = "run_skipped"
+
+	// Number of events buffered for every observer. Events are dropped for an observer which falls behind.
+	DEFAULT_OBSERVER_BUFFER = 1000
+)
+
+// Event is sent to the observers of the JobManager.
+type Event struct {
+	Type  EventType `json:"Type"`
+	Time  time.Time `json:"Time"`
+	JobID JobId     `json:"JobID"`
+	// Record of the run at the time of the event. Nil for the job events.
+	Run *RunRecord `json:"Run,omitempty"`
+}
+
+// Observer receives the events of the JobManager and its JobRunner. OnEvent() is called from a go-routine
+// dedicated to the observer, in the order of the events, so a slow observer doesn't hold up the scheduler.
+type Observer interface {
+	OnEvent(event Event)
+}
+
+// ObserverFunc lets an ordinary function be used as an Observer.
+type ObserverFunc func(event Event)
+
+func (observerFunc ObserverFunc) OnEvent(event Event) {
+	observerFunc(event)
+}
+
+// observerList delivers the events to the registered observers. It is shared by the JobManager and the JobRunner.
+type observerList struct {
+	mu        sync.RWMutex
+	observers map[int]chan Event
+	nextId    int
+	logger    Logger
+	clock     Clock
+}
+
+func newObserverList(logger Logger, clock Clock) *observerList {
+	return &observerList{observers: make(map[int]chan Event), logger: logger, clock: clock}
+}
+
+// add registers the observer and returns the function which unregisters it.
+func (list *observerList) add(observer Observer) (remove func()) {
+	events := make(chan Event, DEFAULT_OBSERVER_BUFFER)
+	go func() {
+		for event := range events {
+			observer.OnEvent(event)
+		}
+	}()
+	list.mu.Lock()
+	defer list.mu.Unlock()
+	id := list.nextId
+	list.nextId++
+	list.observers[id] = events
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			list.mu.Lock()
+			defer list.mu.Unlock()
+			delete(list.observers, id)
+			close(events)
+		})
+	}
+}
+
+// publish sends the event to all the observers without blocking.
+func (list *observerList) publish(eventType EventType, jobId JobId, run *RunRecord) {
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+	if len(list.observers) == 0 {
+		return
+	}
+	event := Event{Type: eventType, Time: list.clock.Now(), JobID: jobId, Run: run}
+	for _, events := range list.observers {
+		select {
+		case events <- event:
+		default:
+			list.logger.Warnf("Observer is not keeping up. Dropping the event - %v of the job - %v", eventType, jobId)
+		}
+	}
+}
+
+// publishRun sends the event with the current record of the JobRun.
+func (list *observerList) publishRun(eventType EventType, jobRun *JobRun) {
+	record := jobRun.Record()
+	list.publish(eventType, jobRun.Fields.ID, &record)
+}
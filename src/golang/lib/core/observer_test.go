@@ -0,0 +1,54 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestJobManagerObserver checks the events received by an observer for the runs of a succeeding
+// and a failing job.
+func TestJobManagerObserver(t *testing.T) {
+	manager := newTestJobManager(nil, time.UTC)
+	events := make(chan Event, 100)
+	remove := manager.AddObserver(ObserverFunc(func(event Event) { events <- event }))
+	defer remove()
+	manager.AddJobV2(&testJobV2{testJob: *newTestJob(t, "good-job", "0 0 1 1 *")})
+	manager.AddJobV2(&testJobV2{testJob: *newTestJob(t, "bad-job", "0 0 1 1 *"), executeErr: fmt.Errorf("failed")})
+	if err := manager.Start(); err != nil {
+		t.Fatalf("failed to start the job manager: %v", err)
+	}
+	defer manager.Stop()
+	assertEvents(t, events, []EventType{EVENT_JOB_ADDED, EVENT_JOB_ADDED}, "")
+	for _, test := range []struct {
+		jobId JobId
+		last  EventType
+	}{{"good-job", EVENT_RUN_FINISHED}, {"bad-job", EVENT_RUN_FAILED}} {
+		jobRun, err := manager.RunNow(test.jobId)
+		if err != nil {
+			t.Fatalf("failed to run the job %v: %v", test.jobId, err)
+		}
+		jobRun.Wait()
+		assertEvents(t, events, []EventType{EVENT_RUN_SCHEDULED, EVENT_RUN_STARTED, test.last}, jobRun.ID)
+	}
+	manager.RemoveJob("bad-job")
+	assertEvents(t, events, []EventType{EVENT_JOB_REMOVED}, "")
+}
+
+// assertEvents reads the next events and compares their types. The run events must be of the run "runId".
+func assertEvents(t *testing.T, events chan Event, want []EventType, runId string) {
+	t.Helper()
+	for i, eventType := range want {
+		select {
+		case event := <-events:
+			if event.Type != eventType {
+				t.Fatalf("event #%v: got %v, want %v", i, event.Type, eventType)
+			}
+			if runId != "" && (event.Run == nil || event.Run.RunID != runId) {
+				t.Fatalf("event #%v: got the run %+v, want the run %v", i, event.Run, runId)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("event #%v: got no event, want %v", i, eventType)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	DEFAULT_RETRY_INITIAL_DELAY = 10 * time.Second
+	DEFAULT_RETRY_MULTIPLIER    = 2.0
+)
+
+// RetryPolicy decides whether and when a failed run of the job is retried. The delay before
+// the n-th retry is InitialDelay * Multiplier^(n-1), capped at MaxDelay and randomized by Jitter.
+type RetryPolicy struct {
+	// Total number of attempts including the first run. 0 or 1 disables the retries.
+	MaxAttempts  int      `json:"MaxAttempts"`
+	InitialDelay Duration `json:"InitialDelay,omitempty"` // Defaults to DEFAULT_RETRY_INITIAL_DELAY
+	Multiplier   float64  `json:"Multiplier,omitempty"`   // Defaults to DEFAULT_RETRY_MULTIPLIER
+	MaxDelay     Duration `json:"MaxDelay,omitempty"`     // No cap if zero
+	// Fraction (0 to 1) of the delay by which it is randomly increased or decreased.
+	Jitter float64 `json:"Jitter,omitempty"`
+	// Exit codes for which the run is retried. Every failure is retried if it is empty.
+	RetryableExitCodes []int `json:"RetryableExitCodes,omitempty"`
+}
+
+// IsRetryable tells whether a run with the given record can be retried as per the policy.
+func (policy *RetryPolicy) IsRetryable(record RunRecord) bool {
+	if record.Status != RUN_STATUS_FAILED && record.Status != RUN_STATUS_TIMED_OUT {
+		return false
+	}
+	if len(policy.RetryableExitCodes) == 0 {
+		return true
+	}
+	if record.ExitCode == nil {
+		return false
+	}
+	for _, exitCode := range policy.RetryableExitCodes {
+		if exitCode == *record.ExitCode {
+			return true
+		}
+	}
+	return false
+}
+
+// GetDelay returns the delay before the retry which follows the given attempt (1 for the first run).
+func (policy *RetryPolicy) GetDelay(attempt int) (delay time.Duration) {
+	initialDelay := policy.InitialDelay.Duration()
+	if initialDelay <= 0 {
+		initialDelay = DEFAULT_RETRY_INITIAL_DELAY
+	}
+	multiplier := policy.Multiplier
+	if multiplier <= 0 {
+		multiplier = DEFAULT_RETRY_MULTIPLIER
+	}
+	delaySeconds := initialDelay.Seconds() * math.Pow(multiplier, float64(attempt-1))
+	if maxDelay := policy.MaxDelay.Duration(); maxDelay > 0 && delaySeconds > maxDelay.Seconds() {
+		delaySeconds = maxDelay.Seconds()
+	}
+	if policy.Jitter > 0 {
+		delaySeconds += delaySeconds * policy.Jitter * (2*rand.Float64() - 1)
+	}
+	// Avoid the overflow of time.Duration for the large attempts.
+	if delaySeconds > math.MaxInt64/float64(time.Second) {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delaySeconds * float64(time.Second))
+}
+
+// ValidateRetryPolicy checks the retry policy of the job. Nil policy is valid and means no retries.
+func ValidateRetryPolicy(fields *CommonJobFields) (err error) {
+	policy := fields.RetryPolicy
+	if policy == nil {
+		return nil
+	}
+	if policy.MaxAttempts < 0 {
+		return fmt.Errorf("invalid RetryPolicy. MaxAttempts - %v can not be negative", policy.MaxAttempts)
+	}
+	if policy.InitialDelay < 0 || policy.MaxDelay < 0 {
+		return fmt.Errorf("invalid RetryPolicy. InitialDelay and MaxDelay can not be negative")
+	}
+	if policy.Multiplier < 0 {
+		return fmt.Errorf("invalid RetryPolicy. Multiplier - %v can not be negative", policy.Multiplier)
+	}
+	if policy.Jitter < 0 || policy.Jitter > 1 {
+		return fmt.Errorf("invalid RetryPolicy. Jitter - %v should be between 0 and 1", policy.Jitter)
+	}
+	return nil
+}
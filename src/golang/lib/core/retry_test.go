@@ -0,0 +1,116 @@
+package core
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyGetDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{name: "default first retry", attempt: 1, want: DEFAULT_RETRY_INITIAL_DELAY},
+		{name: "default third retry", attempt: 3, want: 4 * DEFAULT_RETRY_INITIAL_DELAY},
+		{name: "custom curve", policy: RetryPolicy{InitialDelay: Duration(time.Second), Multiplier: 3}, attempt: 4, want: 27 * time.Second},
+		{name: "constant delay", policy: RetryPolicy{InitialDelay: Duration(5 * time.Second), Multiplier: 1}, attempt: 10, want: 5 * time.Second},
+		{name: "below the cap", policy: RetryPolicy{InitialDelay: Duration(time.Second), MaxDelay: Duration(time.Minute)}, attempt: 6, want: 32 * time.Second},
+		{name: "capped", policy: RetryPolicy{InitialDelay: Duration(time.Second), MaxDelay: Duration(time.Minute)}, attempt: 7, want: time.Minute},
+		{name: "capped large attempt", policy: RetryPolicy{InitialDelay: Duration(time.Second), MaxDelay: Duration(time.Hour)}, attempt: 5000, want: time.Hour},
+		{name: "overflow without a cap", policy: RetryPolicy{InitialDelay: Duration(time.Second)}, attempt: 5000, want: time.Duration(math.MaxInt64)},
+	}
+	for _, test := range tests {
+		if got := test.policy.GetDelay(test.attempt); got != test.want {
+			t.Errorf("%v: got the delay %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+// TestRetryPolicyJitter checks that the randomized delays stay within the Jitter fraction of the delay.
+func TestRetryPolicyJitter(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{name: "jitter", policy: RetryPolicy{InitialDelay: Duration(10 * time.Second), Jitter: 0.2}, attempt: 2, min: 16 * time.Second, max: 24 * time.Second},
+		{name: "jitter of the capped delay", policy: RetryPolicy{InitialDelay: Duration(10 * time.Second), MaxDelay: Duration(time.Minute), Jitter: 0.5}, attempt: 10, min: 30 * time.Second, max: 90 * time.Second},
+		{name: "full jitter", policy: RetryPolicy{InitialDelay: Duration(time.Second), Jitter: 1}, attempt: 1, min: 0, max: 2 * time.Second},
+	}
+	for _, test := range tests {
+		distinct := make(map[time.Duration]bool)
+		for i := 0; i < 1000; i++ {
+			delay := test.policy.GetDelay(test.attempt)
+			if delay < test.min || delay > test.max {
+				t.Fatalf("%v: got the delay %v, want it in [%v, %v]", test.name, delay, test.min, test.max)
+			}
+			distinct[delay] = true
+		}
+		if len(distinct) < 100 {
+			t.Errorf("%v: got only %v distinct delays in 1000 retries", test.name, len(distinct))
+		}
+	}
+}
+
+func TestRetryPolicyIsRetryable(t *testing.T) {
+	exitCode := func(code int) *int { return &code }
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		record RunRecord
+		want   bool
+	}{
+		{name: "failed", record: RunRecord{Status: RUN_STATUS_FAILED, ExitCode: exitCode(1)}, want: true},
+		{name: "timed out", record: RunRecord{Status: RUN_STATUS_TIMED_OUT}, want: true},
+		{name: "succeeded", record: RunRecord{Status: RUN_STATUS_SUCCEEDED, ExitCode: exitCode(0)}},
+		{name: "cancelled", record: RunRecord{Status: RUN_STATUS_CANCELLED}},
+		{name: "interrupted", record: RunRecord{Status: RUN_STATUS_INTERRUPTED}},
+		{name: "skipped", record: RunRecord{Status: RUN_STATUS_SKIPPED}},
+		{
+			name:   "retryable exit code",
+			policy: RetryPolicy{RetryableExitCodes: []int{75, 111}},
+			record: RunRecord{Status: RUN_STATUS_FAILED, ExitCode: exitCode(111)},
+			want:   true,
+		},
+		{
+			name:   "other exit code",
+			policy: RetryPolicy{RetryableExitCodes: []int{75, 111}},
+			record: RunRecord{Status: RUN_STATUS_FAILED, ExitCode: exitCode(1)},
+		},
+		{
+			name:   "no exit code",
+			policy: RetryPolicy{RetryableExitCodes: []int{75}},
+			record: RunRecord{Status: RUN_STATUS_TIMED_OUT},
+		},
+	}
+	for _, test := range tests {
+		if got := test.policy.IsRetryable(test.record); got != test.want {
+			t.Errorf("%v: got retryable %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestValidateRetryPolicy(t *testing.T) {
+	tests := []struct {
+		policy  *RetryPolicy
+		wantErr bool
+	}{
+		{policy: nil},
+		{policy: &RetryPolicy{MaxAttempts: 3, InitialDelay: Duration(time.Second), Multiplier: 1.5, MaxDelay: Duration(time.Minute), Jitter: 1}},
+		{policy: &RetryPolicy{MaxAttempts: -1}, wantErr: true},
+		{policy: &RetryPolicy{InitialDelay: Duration(-time.Second)}, wantErr: true},
+		{policy: &RetryPolicy{MaxDelay: Duration(-time.Second)}, wantErr: true},
+		{policy: &RetryPolicy{Multiplier: -2}, wantErr: true},
+		{policy: &RetryPolicy{Jitter: 1.5}, wantErr: true},
+	}
+	for i, test := range tests {
+		if err := ValidateRetryPolicy(&CommonJobFields{RetryPolicy: test.policy}); (err != nil) != test.wantErr {
+			t.Errorf("policy #%v %+v: got the error %v, want error %v", i, test.policy, err, test.wantErr)
+		}
+	}
+}
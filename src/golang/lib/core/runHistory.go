@@ -0,0 +1,251 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_RUN_HISTORY_MAX_RUNS_PER_JOB = 100
+	DEFAULT_RUN_HISTORY_MAX_AGE          = 30 * 24 * time.Hour
+)
+
+// RunHistory stores the RunRecords of the job runs. Record() is called by the JobRunner whenever
+// a JobRun is queued, started, completed or skipped, so it should update the existing record of
+// the same RunID instead of adding a new one.
+type RunHistory interface {
+	Record(record RunRecord) (err error)
+	// GetJobRuns returns the records of the job, latest first.
+	GetJobRuns(jobId JobId) (records []RunRecord, err error)
+	GetRun(runId string) (record RunRecord, found bool, err error)
+}
+
+// RunRetention limits the number of records kept by the FileRunHistory.
+type RunRetention struct {
+	MaxRunsPerJob int           // Only the latest MaxRunsPerJob records of a job are kept
+	MaxAge        time.Duration // Records queued before now - MaxAge are removed
+}
+
+// FileRunHistory is a RunHistory persisted as a JSON file (resources/runs.json).
+// All the records are kept in memory, indexed by the RunID. The changes are written to the file in
+// batches by a background go-routine, see Flush() and Close().
+type FileRunHistory struct {
+	Logger    Logger
+	FilePath  string
+	Retention RunRetention
+	// OnRemove is called for every record removed as per the retention, e.g. to remove the run output.
+	OnRemove func(record RunRecord)
+	records  map[string]RunRecord
+	// RunIDs ordered by the time of the first Record() call of the run, in all and per job. The RunIDs
+	// of the removed records are dropped when the history is written.
+	order   []string
+	jobRuns map[JobId][]string
+	// Records removed as per the retention since the last write. OnRemove is called for them on the write.
+	removed []RunRecord
+	mu      sync.Mutex
+	clock   Clock
+	writer  *batchWriter
+}
+
+// NewFileRunHistory creates the FileRunHistory and loads the existing records from the file.
+// Zero values in the retention are replaced by the defaults. Close() the history to write the last changes.
+func NewFileRunHistory(logger Logger, filePath string, retention RunRetention) (history *FileRunHistory, err error) {
+	logger.Infof("Creating the run history with the file - %v", filePath)
+	if retention.MaxRunsPerJob <= 0 {
+		retention.MaxRunsPerJob = DEFAULT_RUN_HISTORY_MAX_RUNS_PER_JOB
+	}
+	if retention.MaxAge <= 0 {
+		retention.MaxAge = DEFAULT_RUN_HISTORY_MAX_AGE
+	}
+	history = &FileRunHistory{
+		Logger:    logger,
+		FilePath:  filePath,
+		Retention: retention,
+		records:   make(map[string]RunRecord),
+		order:     make([]string, 0),
+		jobRuns:   make(map[JobId][]string),
+		clock:     realClock{},
+	}
+	fileContent, err := os.ReadFile(filePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Errorf("Error reading the run history file - %v. Error - %v", filePath, err)
+		return nil, err
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		logger.Infof("Run history file - %v doesn't exist. Starting with an empty history.", filePath)
+	}
+	if len(fileContent) > 0 {
+		var records []RunRecord
+		if err = json.Unmarshal(fileContent, &records); err != nil {
+			logger.Errorf("Error parsing the run history file - %v. Error - %v", filePath, err)
+			return nil, err
+		}
+		for _, record := range records {
+			history.add(record)
+		}
+		logger.Infof("Loaded %v run records from the file - %v", len(records), filePath)
+	}
+	history.writer = newBatchWriter(DEFAULT_BATCH_WRITE_DELAY, history.save)
+	if interrupted := history.markInterrupted(); interrupted > 0 {
+		logger.Warnf("Marked %v runs left queued or running by the previous run of the job manager as interrupted.", interrupted)
+		if err = history.Flush(); err != nil {
+			history.Close()
+			return nil, err
+		}
+	}
+	return history, nil
+}
+
+// markInterrupted sets the status of the records which were not finished when the history was last saved,
+// as their runs ended with the previous process. The number of such records is returned.
+func (history *FileRunHistory) markInterrupted() (count int) {
+	for runId, record := range history.records {
+		if record.isFinished() {
+			continue
+		}
+		record.Status = RUN_STATUS_INTERRUPTED
+		record.Error = "Job manager stopped before the run completed"
+		history.records[runId] = record
+		count++
+	}
+	return
+}
+
+// Record adds the record or updates the existing record with the same RunID and applies the per job
+// retention. The history is written to the file in the background.
+func (history *FileRunHistory) Record(record RunRecord) (err error) {
+	history.mu.Lock()
+	if _, found := history.records[record.RunID]; found {
+		history.records[record.RunID] = record
+	} else {
+		history.add(record)
+		history.applyJobRetention(record.JobID)
+	}
+	history.mu.Unlock()
+	history.writer.schedule()
+	return nil
+}
+
+// add indexes the new record.
+func (history *FileRunHistory) add(record RunRecord) {
+	history.records[record.RunID] = record
+	history.order = append(history.order, record.RunID)
+	history.jobRuns[record.JobID] = append(history.jobRuns[record.JobID], record.RunID)
+}
+
+func (history *FileRunHistory) GetJobRuns(jobId JobId) (records []RunRecord, err error) {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+	runIds := history.jobRuns[jobId]
+	records = make([]RunRecord, 0, len(runIds))
+	for i := len(runIds) - 1; i >= 0; i-- {
+		if record, found := history.records[runIds[i]]; found {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (history *FileRunHistory) GetRun(runId string) (record RunRecord, found bool, err error) {
+	history.mu.Lock()
+	defer history.mu.Unlock()
+	record, found = history.records[runId]
+	return record, found, nil
+}
+
+// Flush writes the history to the file now.
+func (history *FileRunHistory) Flush() (err error) {
+	return history.writer.flush()
+}
+
+// Close stops the background writes and writes the last changes of the history.
+func (history *FileRunHistory) Close() (err error) {
+	return history.writer.close()
+}
+
+// applyJobRetention removes the oldest records of the job beyond MaxRunsPerJob.
+func (history *FileRunHistory) applyJobRetention(jobId JobId) {
+	runIds := history.jobRuns[jobId]
+	for len(runIds) > history.Retention.MaxRunsPerJob {
+		if record, found := history.records[runIds[0]]; found {
+			delete(history.records, runIds[0])
+			history.removed = append(history.removed, record)
+		}
+		runIds = runIds[1:]
+	}
+	history.jobRuns[jobId] = runIds
+}
+
+// applyRetention removes the finished records older than MaxAge and drops the RunIDs of all the
+// removed records from the indexes.
+func (history *FileRunHistory) applyRetention() {
+	oldestAllowed := history.clock.Now().Add(-history.Retention.MaxAge)
+	order := make([]string, 0, len(history.records))
+	jobRuns := make(map[JobId][]string, len(history.jobRuns))
+	indexed := make(map[string]bool, len(history.records))
+	for _, runId := range history.order {
+		record, found := history.records[runId]
+		// A removed run which is recorded again is indexed twice.
+		if !found || indexed[runId] {
+			continue
+		}
+		if record.isFinished() && record.getCreatedAt().Before(oldestAllowed) {
+			delete(history.records, runId)
+			history.removed = append(history.removed, record)
+			continue
+		}
+		indexed[runId] = true
+		order = append(order, runId)
+		jobRuns[record.JobID] = append(jobRuns[record.JobID], runId)
+	}
+	history.order = order
+	history.jobRuns = jobRuns
+}
+
+// save applies the retention and writes the records to the file. It is called by the batchWriter.
+func (history *FileRunHistory) save() (err error) {
+	history.mu.Lock()
+	history.applyRetention()
+	records := make([]RunRecord, 0, len(history.order))
+	for _, runId := range history.order {
+		records = append(records, history.records[runId])
+	}
+	removed := history.removed
+	history.removed = nil
+	history.mu.Unlock()
+
+	if len(removed) > 0 {
+		history.Logger.Infof("Removed %v run records as per the retention.", len(removed))
+		if history.OnRemove != nil {
+			for _, record := range removed {
+				history.OnRemove(record)
+			}
+		}
+	}
+	jsonData, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		history.Logger.Errorf("Error marshaling the run history. Error - %v", err)
+		return err
+	}
+	if err = os.WriteFile(history.FilePath, jsonData, 0644); err != nil {
+		history.Logger.Errorf("Error writing the run history file - %v. Error - %v", history.FilePath, err)
+		return fmt.Errorf("failed to write the run history file - %v. Error - %v", history.FilePath, err)
+	}
+	return nil
+}
+
+func (record *RunRecord) isFinished() bool {
+	return record.Status != RUN_STATUS_QUEUED && record.Status != RUN_STATUS_RUNNING
+}
+
+// getCreatedAt returns the earliest known time of the run.
+func (record *RunRecord) getCreatedAt() time.Time {
+	if !record.QueuedAt.IsZero() {
+		return record.QueuedAt
+	}
+	return record.ScheduledAt
+}
@@ -0,0 +1,177 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestRunHistory(t *testing.T, filePath string, retention RunRetention) (history *FileRunHistory) {
+	t.Helper()
+	history, err := NewFileRunHistory(testLogger{}, filePath, retention)
+	if err != nil {
+		t.Fatalf("got the error %v while creating the run history", err)
+	}
+	t.Cleanup(func() { history.Close() })
+	return
+}
+
+func readRunHistoryFile(t *testing.T, filePath string) (records []RunRecord) {
+	t.Helper()
+	fileData, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(fileData, &records); err != nil {
+		t.Fatalf("got the error %v while parsing %s", err, fileData)
+	}
+	return
+}
+
+func assertRunIds(t *testing.T, records []RunRecord, want ...string) {
+	t.Helper()
+	got := make([]string, 0, len(records))
+	for _, record := range records {
+		got = append(got, record.RunID)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got the runs %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got the runs %v, want %v", got, want)
+		}
+	}
+}
+
+func TestFileRunHistoryRecord(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "runs.json")
+	history := newTestRunHistory(t, filePath, RunRetention{})
+	queuedAt := time.Now()
+	records := []RunRecord{
+		{RunID: "run-1", JobID: "job-a", Status: RUN_STATUS_QUEUED, QueuedAt: queuedAt},
+		{RunID: "run-2", JobID: "job-b", Status: RUN_STATUS_RUNNING, QueuedAt: queuedAt},
+		{RunID: "run-3", JobID: "job-a", Status: RUN_STATUS_RUNNING, QueuedAt: queuedAt},
+		{RunID: "run-1", JobID: "job-a", Status: RUN_STATUS_SUCCEEDED, QueuedAt: queuedAt},
+	}
+	for _, record := range records {
+		if err := history.Record(record); err != nil {
+			t.Fatalf("got the error %v while recording the run %v", err, record.RunID)
+		}
+	}
+
+	jobRuns, _ := history.GetJobRuns("job-a")
+	assertRunIds(t, jobRuns, "run-3", "run-1")
+	if record, found, _ := history.GetRun("run-1"); !found || record.Status != RUN_STATUS_SUCCEEDED {
+		t.Errorf("got the record %+v (found %v), want the updated run-1", record, found)
+	}
+	if _, found, _ := history.GetRun("missing-run"); found {
+		t.Errorf("got a record of a missing run")
+	}
+
+	// The records are written in the background, in the order of their first Record() call.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if fileData, err := os.ReadFile(filePath); err == nil && len(fileData) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the run history file is not written")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := history.Flush(); err != nil {
+		t.Fatalf("got the error %v while writing the run history", err)
+	}
+	assertRunIds(t, readRunHistoryFile(t, filePath), "run-1", "run-2", "run-3")
+}
+
+func TestFileRunHistoryRetention(t *testing.T) {
+	now := mustParseTime(t, "2026-03-01T12:00:00Z")
+	tests := []struct {
+		name        string
+		retention   RunRetention
+		records     []RunRecord
+		wantRuns    []string
+		wantRemoved []string
+	}{
+		{
+			name:      "max runs per job",
+			retention: RunRetention{MaxRunsPerJob: 2},
+			records: []RunRecord{
+				{RunID: "a-1", JobID: "job-a", Status: RUN_STATUS_SUCCEEDED, QueuedAt: now},
+				{RunID: "b-1", JobID: "job-b", Status: RUN_STATUS_SUCCEEDED, QueuedAt: now},
+				{RunID: "a-2", JobID: "job-a", Status: RUN_STATUS_FAILED, QueuedAt: now},
+				{RunID: "a-3", JobID: "job-a", Status: RUN_STATUS_RUNNING, QueuedAt: now},
+				{RunID: "a-4", JobID: "job-a", Status: RUN_STATUS_QUEUED, QueuedAt: now},
+			},
+			wantRuns:    []string{"b-1", "a-3", "a-4"},
+			wantRemoved: []string{"a-1", "a-2"},
+		},
+		{
+			name:      "max age keeps the unfinished runs",
+			retention: RunRetention{MaxAge: 24 * time.Hour},
+			records: []RunRecord{
+				{RunID: "old-finished", JobID: "job-a", Status: RUN_STATUS_SUCCEEDED, QueuedAt: now.Add(-48 * time.Hour)},
+				{RunID: "old-scheduled", JobID: "job-a", Status: RUN_STATUS_SKIPPED, ScheduledAt: now.Add(-25 * time.Hour)},
+				{RunID: "old-running", JobID: "job-a", Status: RUN_STATUS_RUNNING, QueuedAt: now.Add(-48 * time.Hour)},
+				{RunID: "recent", JobID: "job-b", Status: RUN_STATUS_FAILED, QueuedAt: now.Add(-time.Hour)},
+			},
+			wantRuns:    []string{"old-running", "recent"},
+			wantRemoved: []string{"old-finished", "old-scheduled"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "runs.json")
+			history := newTestRunHistory(t, filePath, test.retention)
+			history.clock = newFakeClock(now)
+			var removed []RunRecord
+			history.OnRemove = func(record RunRecord) { removed = append(removed, record) }
+			for _, record := range test.records {
+				history.Record(record)
+			}
+			if err := history.Flush(); err != nil {
+				t.Fatalf("got the error %v while writing the run history", err)
+			}
+			assertRunIds(t, readRunHistoryFile(t, filePath), test.wantRuns...)
+			assertRunIds(t, removed, test.wantRemoved...)
+		})
+	}
+}
+
+// TestFileRunHistoryReload checks that the records are loaded from the file and that the runs left queued or
+// running by the previous process are marked as interrupted.
+func TestFileRunHistoryReload(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "runs.json")
+	history := newTestRunHistory(t, filePath, RunRetention{})
+	queuedAt := time.Now()
+	for _, record := range []RunRecord{
+		{RunID: "run-1", JobID: "job-a", Status: RUN_STATUS_SUCCEEDED, QueuedAt: queuedAt},
+		{RunID: "run-2", JobID: "job-a", Status: RUN_STATUS_RUNNING, QueuedAt: queuedAt},
+		{RunID: "run-3", JobID: "job-b", Status: RUN_STATUS_QUEUED, QueuedAt: queuedAt},
+	} {
+		history.Record(record)
+	}
+	if err := history.Close(); err != nil {
+		t.Fatalf("got the error %v while closing the run history", err)
+	}
+
+	reloaded := newTestRunHistory(t, filePath, RunRetention{})
+	jobRuns, _ := reloaded.GetJobRuns("job-a")
+	assertRunIds(t, jobRuns, "run-2", "run-1")
+	wantStatus := map[string]RunStatus{"run-1": RUN_STATUS_SUCCEEDED, "run-2": RUN_STATUS_INTERRUPTED, "run-3": RUN_STATUS_INTERRUPTED}
+	for _, record := range readRunHistoryFile(t, filePath) {
+		if record.Status != wantStatus[record.RunID] {
+			t.Errorf("got the status %v of the run %v in the file, want %v", record.Status, record.RunID, wantStatus[record.RunID])
+		}
+		if record.Status == RUN_STATUS_INTERRUPTED && record.Error == "" {
+			t.Errorf("got no error for the interrupted run %v", record.RunID)
+		}
+	}
+	if record, _, _ := reloaded.GetRun("run-3"); record.Status != RUN_STATUS_INTERRUPTED {
+		t.Errorf("got the status %v of the reloaded run-3, want %v", record.Status, RUN_STATUS_INTERRUPTED)
+	}
+}
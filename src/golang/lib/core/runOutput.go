@@ -0,0 +1,127 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	OUTPUT_STREAM_STDOUT = "stdout"
+	OUTPUT_STREAM_STDERR = "stderr"
+
+	DEFAULT_RUN_OUTPUT_MAX_BYTES = 1024 * 1024
+	RUN_OUTPUT_TRUNCATION_MARKER = "\n[output truncated: size limit of %v bytes reached]\n"
+)
+
+// RunOutput captures the stdout and stderr of the job runs to the files
+// "<Directory>/<run ID>.stdout.log" and "<Directory>/<run ID>.stderr.log".
+// Each stream is limited to MaxBytes, the rest of the output is discarded
+// after writing a truncation marker.
+type RunOutput struct {
+	Logger    Logger
+	Directory string
+	MaxBytes  int64
+}
+
+// NewRunOutput creates the RunOutput. MaxBytes defaults to DEFAULT_RUN_OUTPUT_MAX_BYTES.
+func NewRunOutput(logger Logger, directory string, maxBytes int64) (runOutput *RunOutput) {
+	if maxBytes <= 0 {
+		maxBytes = DEFAULT_RUN_OUTPUT_MAX_BYTES
+	}
+	return &RunOutput{Logger: logger, Directory: directory, MaxBytes: maxBytes}
+}
+
+// GetFilePath returns the output file path of the stream of the run.
+func (runOutput *RunOutput) GetFilePath(runId string, stream string) (filePath string, err error) {
+	if stream != OUTPUT_STREAM_STDOUT && stream != OUTPUT_STREAM_STDERR {
+		return "", fmt.Errorf("invalid output stream - %v. Supported values are stdout and stderr", stream)
+	}
+	// Run IDs are generated UUIDs, anything else must not escape the output directory.
+	if runId == "" || filepath.Base(runId) != runId {
+		return "", fmt.Errorf("invalid run ID - %v", runId)
+	}
+	return filepath.Join(runOutput.Directory, runId+"."+stream+".log"), nil
+}
+
+// Open creates the output files of the run.
+func (runOutput *RunOutput) Open(runId string) (stdout *CappedFileWriter, stderr *CappedFileWriter, err error) {
+	stdout, err = runOutput.openStream(runId, OUTPUT_STREAM_STDOUT)
+	if err != nil {
+		return nil, nil, err
+	}
+	stderr, err = runOutput.openStream(runId, OUTPUT_STREAM_STDERR)
+	if err != nil {
+		stdout.Close()
+		return nil, nil, err
+	}
+	return stdout, stderr, nil
+}
+
+// Remove deletes the output files of the run.
+func (runOutput *RunOutput) Remove(runId string) {
+	for _, stream := range []string{OUTPUT_STREAM_STDOUT, OUTPUT_STREAM_STDERR} {
+		filePath, err := runOutput.GetFilePath(runId, stream)
+		if err != nil {
+			continue
+		}
+		if err = os.Remove(filePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			runOutput.Logger.Warnf("Failed to remove the output file - %v. Error - %v", filePath, err)
+		}
+	}
+}
+
+func (runOutput *RunOutput) openStream(runId string, stream string) (writer *CappedFileWriter, err error) {
+	filePath, err := runOutput.GetFilePath(runId, stream)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		runOutput.Logger.Errorf("Failed to create the output file - %v. Error - %v", filePath, err)
+		return nil, err
+	}
+	return &CappedFileWriter{file: file, maxBytes: runOutput.MaxBytes}, nil
+}
+
+// CappedFileWriter writes to a file until MaxBytes are written. The writes never fail
+// so that the process writing the output isn't affected by the truncation.
+type CappedFileWriter struct {
+	file      *os.File
+	maxBytes  int64
+	written   int64
+	truncated bool
+	mu        sync.Mutex
+}
+
+func (writer *CappedFileWriter) Write(p []byte) (n int, err error) {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	if writer.truncated {
+		return len(p), nil
+	}
+	remaining := writer.maxBytes - writer.written
+	if int64(len(p)) <= remaining {
+		n, _ = writer.file.Write(p)
+		writer.written += int64(n)
+		return len(p), nil
+	}
+	n, _ = writer.file.Write(p[:remaining])
+	writer.written += int64(n)
+	writer.file.WriteString(fmt.Sprintf(RUN_OUTPUT_TRUNCATION_MARKER, writer.maxBytes))
+	writer.truncated = true
+	return len(p), nil
+}
+
+// Truncated tells whether any output was discarded.
+func (writer *CappedFileWriter) Truncated() bool {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	return writer.truncated
+}
+
+func (writer *CappedFileWriter) Close() (err error) {
+	return writer.file.Close()
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCappedFileWriter(t *testing.T) {
+	marker := fmt.Sprintf(RUN_OUTPUT_TRUNCATION_MARKER, 10)
+	tests := []struct {
+		name          string
+		writes        []string
+		wantContent   string
+		wantTruncated bool
+	}{
+		{name: "below the limit", writes: []string{"hello", " you"}, wantContent: "hello you"},
+		{name: "exactly the limit", writes: []string{"hello", "world"}, wantContent: "helloworld"},
+		{name: "one write over the limit", writes: []string{"hello world!"}, wantContent: "hello worl" + marker, wantTruncated: true},
+		{name: "split over the limit", writes: []string{"hello", " world", "!"}, wantContent: "hello worl" + marker, wantTruncated: true},
+		{name: "writes after the limit", writes: []string{"helloworld", "more"}, wantContent: "helloworld" + marker, wantTruncated: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			runOutput := NewRunOutput(testLogger{}, t.TempDir(), 10)
+			stdout, stderr, err := runOutput.Open("run-1")
+			if err != nil {
+				t.Fatalf("got the error %v while opening the output", err)
+			}
+			stderr.Close()
+			for _, write := range test.writes {
+				// The writes never fail, so that the process isn't affected by the truncation.
+				if n, err := stdout.Write([]byte(write)); n != len(write) || err != nil {
+					t.Fatalf("got %v, %v from the write of %q, want %v, nil", n, err, write, len(write))
+				}
+			}
+			stdout.Close()
+			if stdout.Truncated() != test.wantTruncated {
+				t.Errorf("got truncated %v, want %v", stdout.Truncated(), test.wantTruncated)
+			}
+			filePath, _ := runOutput.GetFilePath("run-1", OUTPUT_STREAM_STDOUT)
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != test.wantContent {
+				t.Errorf("got the output %q, want %q", content, test.wantContent)
+			}
+		})
+	}
+}
+
+func TestRunOutputGetFilePath(t *testing.T) {
+	directory := t.TempDir()
+	runOutput := NewRunOutput(testLogger{}, directory, 0)
+	if runOutput.MaxBytes != DEFAULT_RUN_OUTPUT_MAX_BYTES {
+		t.Errorf("got MaxBytes %v, want the default %v", runOutput.MaxBytes, DEFAULT_RUN_OUTPUT_MAX_BYTES)
+	}
+	tests := []struct {
+		runId    string
+		stream   string
+		wantPath string
+		wantErr  bool
+	}{
+		{runId: "run-1", stream: OUTPUT_STREAM_STDOUT, wantPath: filepath.Join(directory, "run-1.stdout.log")},
+		{runId: "run-1", stream: OUTPUT_STREAM_STDERR, wantPath: filepath.Join(directory, "run-1.stderr.log")},
+		{runId: "run-1", stream: "stdin", wantErr: true},
+		{runId: "", stream: OUTPUT_STREAM_STDOUT, wantErr: true},
+		{runId: "../run-1", stream: OUTPUT_STREAM_STDOUT, wantErr: true},
+		{runId: "runs/run-1", stream: OUTPUT_STREAM_STDOUT, wantErr: true},
+		{runId: "/etc/passwd", stream: OUTPUT_STREAM_STDOUT, wantErr: true},
+	}
+	for _, test := range tests {
+		filePath, err := runOutput.GetFilePath(test.runId, test.stream)
+		if (err != nil) != test.wantErr || filePath != test.wantPath {
+			t.Errorf("GetFilePath(%q, %q): got %q, %v, want %q, error %v", test.runId, test.stream, filePath, err, test.wantPath, test.wantErr)
+		}
+	}
+	if _, _, err := runOutput.Open("../run-1"); err == nil {
+		t.Errorf("got no error while opening the output of an invalid run ID")
+	}
+}
+
+func TestRunOutputRemove(t *testing.T) {
+	runOutput := NewRunOutput(testLogger{}, t.TempDir(), 0)
+	stdout, stderr, err := runOutput.Open("run-1")
+	if err != nil {
+		t.Fatalf("got the error %v while opening the output", err)
+	}
+	stdout.Close()
+	stderr.Close()
+	runOutput.Remove("run-1")
+	for _, stream := range []string{OUTPUT_STREAM_STDOUT, OUTPUT_STREAM_STDERR} {
+		filePath, _ := runOutput.GetFilePath("run-1", stream)
+		if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+			t.Errorf("got the error %v for the removed output file %v, want not exist", err, filePath)
+		}
+	}
+}
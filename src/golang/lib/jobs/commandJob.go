@@ -1,29 +1,44 @@
 package jobs
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"os"
 	"os/exec"
 	"os/user"
 	"strconv"
 	"syscall"
 	"time"
 
-	"github.com/robfig/cron/v3"
 	"github.com/shreyasksrao/jobmanager/lib/core"
-	"github.com/shreyasksrao/jobmanager/lib/utils"
+	"github.com/shreyasksrao/jobmanager/lib/schedule"
 )
 
 type CommandJob struct {
 	CommonJobFields core.CommonJobFields
-	Command         string      `json:"Command"`   // Command to run
-	Args            []string    `json:"Args"`      // Arguments for the command
-	CronExpr        string      `json:"CronExpr"`  // Cron expression
-	RunAsUser       string      `json:"RunAsUser"` // Username under which the command will be run
-	Logger          core.Logger `json:"-"`
-	cmd             *exec.Cmd   `json:"-"`
-	SaveFile        string      `json:"-"` // Full path of the JSON file where the jobs can be saved.
+	Command         string        `json:"Command"`   // Command to run
+	Args            []string      `json:"Args"`      // Arguments for the command
+	CronExpr        string        `json:"CronExpr"`  // Cron expression
+	RunAsUser       string        `json:"RunAsUser"` // Username under which the command will be run
+	Logger          core.Logger   `json:"-"`
+	Store           core.JobStore `json:"-"` // Store where the job is saved.
+}
+
+// Name of the CommandJob type in the core.JobRegistry.
+const COMMAND_JOB_TYPE = "Command"
+
+// NewCommandJobType returns the core.JobType of the CommandJob. The jobs it creates log to the logger
+// and are saved to the store.
+func NewCommandJobType(logger core.Logger, store core.JobStore) (jobType core.JobType) {
+	return core.JobType{
+		Name: COMMAND_JOB_TYPE,
+		New: func() core.JobV2 {
+			return &CommandJob{Logger: logger, Store: store}
+		},
+		Validate: func(job core.JobV2) (err error) {
+			_, err = ValidatePostPayload(logger, job.(*CommandJob))
+			return
+		},
+	}
 }
 
 func (job *CommandJob) GetCommonJobFields() (commonJobFields *core.CommonJobFields) {
@@ -31,97 +46,62 @@ func (job *CommandJob) GetCommonJobFields() (commonJobFields *core.CommonJobFiel
 	return
 }
 
-// Save saves the Job object to the resource file (resources/jobs.json)
-func (job *CommandJob) Save() (saved bool, err error) {
-	var errMsg string
-	var jobsMap map[string]CommandJob
-	job.Logger.Infof("Saving the job with ID - %v to the resource file.", job.CommonJobFields.ID)
-	exists := utils.CheckFileExistance(job.Logger, job.SaveFile)
-	if exists {
-		job.Logger.Infof("Jobs resource file exists. Loading the existing jobs.")
-		var fileData []byte
-		// Open the file in append mode, create it if it doesn't exist, with write-only permissions
-		fileData, err = os.ReadFile(job.SaveFile)
-		if err != nil {
-			errMsg = "Failed to save the job - " + string(job.CommonJobFields.ID) + "to the file. Error reading file - " + job.SaveFile + ". Error - " + err.Error()
-			job.Logger.Errorf(errMsg)
-			err = fmt.Errorf(errMsg)
-			return false, err
-		}
-		// Unmarshal into map
-		err = json.Unmarshal(fileData, &jobsMap)
-		if err != nil {
-			errMsg = "Failed to save the job - " + string(job.CommonJobFields.ID) + " to the file. Error unmarshaling file - " + job.SaveFile + ". Error - " + err.Error()
-			job.Logger.Errorf(errMsg)
-			err = fmt.Errorf(errMsg)
-			return false, err
-		}
-	} else {
-		job.Logger.Infof("Jobs resources file doesn't exist. Creating an empty jobMap.")
-		jobsMap = make(map[string]CommandJob)
+// Clone returns a deep copy of the job.
+func (job *CommandJob) Clone() (clone core.JobV2) {
+	copied := *job
+	copied.CommonJobFields = job.CommonJobFields.Copy()
+	if job.Args != nil {
+		copied.Args = append([]string(nil), job.Args...)
 	}
+	return &copied
+}
 
-	job.Logger.Debugf("Before update Job - %v", jobsMap[string(job.CommonJobFields.ID)])
-	// Create or update entry
-	jobsMap[string(job.CommonJobFields.ID)] = CommandJob{
-		CommonJobFields: core.CommonJobFields{
-			ID:      job.CommonJobFields.ID,
-			LastRun: job.CommonJobFields.LastRun,
-			NextRun: job.CommonJobFields.NextRun,
-		},
-		Command:   job.Command,
-		Args:      job.Args,
-		CronExpr:  job.CronExpr,
-		RunAsUser: job.RunAsUser,
-	}
-	// Marshal back to JSON
-	jsonData, err := json.MarshalIndent(jobsMap, "", "  ")
-	if err != nil {
-		errMsg = "Error marshaling the JSON. Error : " + err.Error()
-		job.Logger.Errorf(errMsg)
-		err = fmt.Errorf(errMsg)
-		return false, err
-	}
-	// Write to file (overwrite with updated map)
-	err = os.WriteFile(job.SaveFile, jsonData, 0644)
-	if err != nil {
-		errMsg = "Error writing to file. Error : " + err.Error()
-		job.Logger.Errorf(errMsg)
-		err = fmt.Errorf(errMsg)
+// Save saves the job to its JobStore (resources/jobs.json).
+func (job *CommandJob) Save() (saved bool, err error) {
+	if err = job.Store.SaveJob(job); err != nil {
 		return false, err
 	}
-	job.Logger.Debugf("After update Job - %v", jobsMap[string(job.CommonJobFields.ID)])
 	job.Logger.Infof("Successfully saved the Job with ID - %v to the resource file.", string(job.CommonJobFields.ID))
 	return true, nil
 }
 
-// Execute runs the specified command. If the "RunAsUser" field is specified,
-// then this func tries to run the command as that user. Else the command will
-// be run as the default user (root)
-func (job *CommandJob) Execute() (err error) {
+// Delete removes the job from its JobStore. It is called by the JobManager when the job expires
+// with the Delete expiry action.
+func (job *CommandJob) Delete() (err error) {
+	return job.Store.DeleteJob(job.CommonJobFields.ID)
+}
+
+// Execute runs the specified command as a run of the job. If the "RunAsUser" field is specified,
+// then this func tries to run the command as that user. Else the command will be run as the default
+// user (root). The stdout and stderr of the command are written to the output writers of the run.
+// When the context is done (e.g. the job's Timeout expired or the run was cancelled), the process
+// group gets SIGTERM and then SIGKILL after the job's KillGracePeriod.
+func (job *CommandJob) Execute(ctx context.Context, info core.RunInfo) (result core.Result, err error) {
 	job.Logger.Infof("---------------------------------EXECUTION START------------------------------------")
 	defer job.Logger.Infof("---------------------------------EXECUTION STOP------------------------------------")
+	job.Logger.Infof("Executing the job run - %v of the job - %v", info.RunID, job.CommonJobFields.ID)
+	var cmd *exec.Cmd
 	if job.RunAsUser != "" {
 		job.Logger.Infof("Fetching the user details for the username - %v", job.RunAsUser)
 		runUser, err := user.Lookup(job.RunAsUser)
 		if err != nil {
 			job.Logger.Errorf("Failed to fetch the user details for the username - %v. Error - %v", job.RunAsUser, err)
-			return err
+			return result, err
 		}
 		uid, err := strconv.Atoi(runUser.Uid)
 		if err != nil {
-			job.Logger.Errorf("Invalid UID. Error - %v.")
-			return err
+			job.Logger.Errorf("Invalid UID. Error - %v.", err)
+			return result, err
 		}
 		gid, err := strconv.Atoi(runUser.Gid)
 		if err != nil {
-			job.Logger.Errorf("Invalid GID. Error - %v.")
-			return err
+			job.Logger.Errorf("Invalid GID. Error - %v.", err)
+			return result, err
 		}
 		job.Logger.Infof("Executing the command - %v with arguments - %v", job.Command, job.Args)
-		job.cmd = exec.Command(job.Command, job.Args...)
+		cmd = exec.CommandContext(ctx, job.Command, job.Args...)
 		// Set UID and GID of the target user
-		job.cmd.SysProcAttr = &syscall.SysProcAttr{
+		cmd.SysProcAttr = &syscall.SysProcAttr{
 			Credential: &syscall.Credential{
 				Uid: uint32(uid), // replace with target user's UID
 				Gid: uint32(gid), // replace with target user's GID
@@ -130,35 +110,83 @@ func (job *CommandJob) Execute() (err error) {
 	} else {
 		job.Logger.Infof("RunAsUser field is empty, going with the default user.")
 		job.Logger.Infof("Executing the command - %v with arguments - %v", job.Command, job.Args)
-		job.cmd = exec.Command(job.Command, job.Args...)
+		cmd = exec.CommandContext(ctx, job.Command, job.Args...)
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	// Run the command in its own process group, so that the signals reach its child processes as well.
+	cmd.SysProcAttr.Setpgid = true
+	gracePeriod := job.CommonJobFields.GetKillGracePeriod()
+	var killTimer *time.Timer
+	cmd.Cancel = func() error {
+		job.Logger.Warnf("Context of the job run - %v is done (%v). Sending SIGTERM to the process group - %v.",
+			info.RunID, ctx.Err(), cmd.Process.Pid)
+		pgid := cmd.Process.Pid
+		killTimer = time.AfterFunc(gracePeriod, func() {
+			job.Logger.Warnf("Process group - %v didn't exit in %v after SIGTERM. Sending SIGKILL.", pgid, gracePeriod)
+			syscall.Kill(-pgid, syscall.SIGKILL)
+		})
+		return syscall.Kill(-pgid, syscall.SIGTERM)
+	}
+	// Stop waiting for the output of the orphaned child processes after the grace period.
+	cmd.WaitDelay = gracePeriod
+	if info.Stdout != nil {
+		cmd.Stdout = info.Stdout
+	}
+	if info.Stderr != nil {
+		cmd.Stderr = info.Stderr
 	}
-	if err = job.cmd.Start(); err != nil {
+	if err = cmd.Start(); err != nil {
 		job.Logger.Errorf("Error executing job %s: %v", string(job.CommonJobFields.ID), err)
-		return err
+		return result, err
 	}
-	job.Logger.Infof("Process ID - %v", job.cmd.Process.Pid)
-	if err := job.cmd.Wait(); err != nil {
+	job.Logger.Infof("Process ID - %v", cmd.Process.Pid)
+	err = cmd.Wait()
+	if killTimer != nil {
+		killTimer.Stop()
+	}
+	if cmd.ProcessState != nil {
+		exitCode := cmd.ProcessState.ExitCode()
+		result.ExitCode = &exitCode
+		if status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			result.Signal = status.Signal().String()
+		}
+	}
+	if err != nil {
 		job.Logger.Errorf("Process exited with error: %v", err)
-	} else {
-		job.Logger.Infof("Process exited cleanly")
+		return result, err
 	}
+	job.Logger.Infof("Process exited cleanly")
 	job.Logger.Infof("Job %s executed successfully.", string(job.CommonJobFields.ID))
-	return nil
-}
-
-func (job *CommandJob) Stop() {
-	job.Logger.Infof("Stopping the Job - %v", job.CommonJobFields.ID)
-	if err := job.cmd.Process.Signal(syscall.SIGTERM); err != nil {
-		job.Logger.Errorf("failed to send SIGTERM: %v", err)
-	} else {
-		job.Logger.Infof("SIGTERM sent for the process with ID - %v.", job.cmd.Process.Pid)
-	}
+	return result, nil
 }
 
+// GetNextScheduleTime returns the next schedule time of the CronExpr after "now". The CronExpr is
+// evaluated in the job's Timezone if it is set, else in the location of "now". A "CRON_TZ=" or
+// "TZ=" prefix in the CronExpr takes precedence over both. Schedule times falling in a daylight
+// saving transition are handled as per the job's DSTSkippedTime and DSTRepeatedTime policies.
+// Jobs with a Schedule in their CommonJobFields are scheduled by it instead of the CronExpr.
 func (job *CommandJob) GetNextScheduleTime(now time.Time) (nextRun time.Time, err error) {
-	parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
-	schedule, err := parser.Parse(job.CronExpr)
-	return schedule.Next(now), err
+	if job.CommonJobFields.Schedule != nil {
+		return job.CommonJobFields.Schedule.Next(now)
+	}
+	// Jobs without a CronExpr are triggered only by their dependencies (or manually).
+	if job.CronExpr == "" {
+		return time.Time{}, nil
+	}
+	location, err := job.CommonJobFields.GetLocation(now.Location())
+	if err != nil {
+		return
+	}
+	// "H" tokens of the CronExpr are resolved with the job ID, so that they stay the same across the restarts.
+	jobSchedule, err := schedule.ParseHashed(job.CronExpr, string(job.CommonJobFields.ID))
+	if err != nil {
+		return
+	}
+	if cronSchedule, ok := jobSchedule.(*schedule.CronSchedule); ok {
+		cronSchedule.SkippedTime = job.CommonJobFields.DSTSkippedTime
+		cronSchedule.RepeatedTime = job.CommonJobFields.DSTRepeatedTime
+	}
+	return jobSchedule.Next(now.In(location)), nil
 }
 
 func ValidatePostPayload(log core.Logger, job *CommandJob) (isValid bool, err error) {
@@ -167,40 +195,80 @@ func ValidatePostPayload(log core.Logger, job *CommandJob) (isValid bool, err er
 		err = fmt.Errorf("invalid request. Command is not specified in the payload")
 		return false, err
 	}
-	if job.CronExpr == "" {
+	if job.CronExpr == "" && job.CommonJobFields.Schedule == nil && len(job.CommonJobFields.DependsOn) == 0 {
 		log.Errorf("invalid request. CronExpr is not specified in the payload")
-		err = fmt.Errorf("invalid request. CronExpr is not specified in the payload")
+		err = fmt.Errorf("invalid request. Either CronExpr, Schedule or DependsOn should be specified in the payload")
 		return false, err
 	}
-	parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
-	_, err = parser.Parse(job.CronExpr)
+	if job.CronExpr != "" && job.CommonJobFields.Schedule != nil {
+		log.Errorf("invalid request. Both CronExpr and Schedule are specified in the payload")
+		err = fmt.Errorf("invalid request. CronExpr and Schedule can not be specified together")
+		return false, err
+	}
+	err = core.ValidateSchedule(&job.CommonJobFields)
 	if err != nil {
-		log.Errorf("invalid request. Failed to parse the CronExpr - %v. Error - %v", job.CronExpr, err.Error())
+		log.Errorf("invalid request. %v", err.Error())
 		return false, err
 	}
-	log.Infof("Successfully validated the POST payload")
-	return true, nil
-}
-
-func LoadCommandJobsFromJsonFile(log core.Logger, jobFilePath string, jobLogger core.Logger) (jobs []*CommandJob, err error) {
-	log.Infof("Loading the existing Jobs from the file - %v.", jobFilePath)
-	fileContent, err := os.ReadFile(jobFilePath)
+	// Time zone set by the "CRON_TZ=<zone>" or "TZ=<zone>" prefix of the CronExpr, if any.
+	var cronLocation *time.Location
+	if job.CronExpr != "" {
+		var jobSchedule schedule.Schedule
+		jobSchedule, err = schedule.ParseHashed(job.CronExpr, string(job.CommonJobFields.ID))
+		if err != nil {
+			log.Errorf("invalid request. Failed to parse the CronExpr - %v. Error - %v", job.CronExpr, err.Error())
+			return false, fmt.Errorf("invalid CronExpr - %v. Error - %v", job.CronExpr, err)
+		}
+		if cronSchedule, ok := jobSchedule.(*schedule.CronSchedule); ok {
+			cronLocation = cronSchedule.Location
+		}
+	}
+	if job.CommonJobFields.Timezone != "" {
+		if _, err = core.LoadLocation(job.CommonJobFields.Timezone); err != nil {
+			log.Errorf("invalid request. %v", err.Error())
+			return false, err
+		}
+		if cronLocation != nil && cronLocation.String() != job.CommonJobFields.Timezone {
+			log.Errorf("invalid request. Timezone - %v conflicts with the CronExpr time zone - %v", job.CommonJobFields.Timezone, cronLocation)
+			err = fmt.Errorf("invalid request. Timezone - %v conflicts with the time zone - %v in the CronExpr", job.CommonJobFields.Timezone, cronLocation)
+			return false, err
+		}
+	}
+	err = core.ValidateConcurrencyPolicy(&job.CommonJobFields)
 	if err != nil {
-		log.Errorf("Error reading the JSON file : %v. Error : %v", jobFilePath, err.Error())
-		return
+		log.Errorf("invalid request. %v", err.Error())
+		return false, err
 	}
-	var jobsMap map[string]CommandJob
-	err = json.Unmarshal([]byte(fileContent), &jobsMap)
+	err = core.ValidateMisfirePolicy(&job.CommonJobFields)
 	if err != nil {
-		log.Errorf("Error parsing the JSON file : %v. Error : %v", jobFilePath, err.Error())
-		return
+		log.Errorf("invalid request. %v", err.Error())
+		return false, err
+	}
+	err = core.ValidateDSTPolicy(&job.CommonJobFields)
+	if err != nil {
+		log.Errorf("invalid request. %v", err.Error())
+		return false, err
 	}
-	// Add the fields which are not persisted.
-	for _, job := range jobsMap {
-		job.Logger = jobLogger
-		job.SaveFile = jobFilePath
-		jobs = append(jobs, &job)
+	err = core.ValidateTimeout(&job.CommonJobFields)
+	if err != nil {
+		log.Errorf("invalid request. %v", err.Error())
+		return false, err
 	}
-	log.Infof("Successfully loaded the jobs from the file - %v", jobFilePath)
-	return
+	err = core.ValidateMaxJitter(&job.CommonJobFields)
+	if err != nil {
+		log.Errorf("invalid request. %v", err.Error())
+		return false, err
+	}
+	err = core.ValidateRetryPolicy(&job.CommonJobFields)
+	if err != nil {
+		log.Errorf("invalid request. %v", err.Error())
+		return false, err
+	}
+	err = core.ValidateDependencies(&job.CommonJobFields)
+	if err != nil {
+		log.Errorf("invalid request. %v", err.Error())
+		return false, err
+	}
+	log.Infof("Successfully validated the POST payload")
+	return true, nil
 }
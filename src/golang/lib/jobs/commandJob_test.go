@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/shreyasksrao/jobmanager/lib/core"
+)
+
+type testLogger struct{}
+
+func (testLogger) Errorf(template string, args ...any) {}
+func (testLogger) Warnf(template string, args ...any)  {}
+func (testLogger) Infof(template string, args ...any)  {}
+func (testLogger) Debugf(template string, args ...any) {}
+
+// TestValidatePostPayloadTimezone checks the Timezone of the job against the time zone prefix of its CronExpr.
+func TestValidatePostPayloadTimezone(t *testing.T) {
+	tests := []struct {
+		cronExpr string
+		timezone string
+		wantErr  bool
+	}{
+		{cronExpr: "0 2 * * *", timezone: "Europe/Berlin"},
+		{cronExpr: "CRON_TZ=Europe/Berlin 0 2 * * *", timezone: "Europe/Berlin"},
+		{cronExpr: "TZ=Europe/Berlin 0 2 * * *", timezone: "Europe/Berlin"},
+		{cronExpr: "CRON_TZ=Europe/Berlin 0 2 * * *"},
+		{cronExpr: "CRON_TZ=Asia/Kolkata H 2 * * *", timezone: "Europe/Berlin", wantErr: true},
+		{cronExpr: "TZ=Asia/Kolkata 0 2 * * *", timezone: "Europe/Berlin", wantErr: true},
+	}
+	for _, test := range tests {
+		job := &CommandJob{Command: "true", CronExpr: test.cronExpr}
+		job.CommonJobFields.ID = "tz-job"
+		job.CommonJobFields.Timezone = test.timezone
+		if _, err := ValidatePostPayload(testLogger{}, job); (err != nil) != test.wantErr {
+			t.Errorf("CronExpr %q with the Timezone %q: got the error %v, want error %v", test.cronExpr, test.timezone, err, test.wantErr)
+		}
+	}
+}
+
+// readyWriter closes "ready" on the first write of the process.
+type readyWriter struct {
+	ready chan struct{}
+	once  sync.Once
+}
+
+func (writer *readyWriter) Write(p []byte) (n int, err error) {
+	writer.once.Do(func() { close(writer.ready) })
+	return len(p), nil
+}
+
+// TestCommandJobKillEscalation cancels the runs of the commands and checks that SIGTERM is sent to the process
+// first and SIGKILL after the KillGracePeriod if the process ignores SIGTERM.
+func TestCommandJobKillEscalation(t *testing.T) {
+	gracePeriod := 500 * time.Millisecond
+	tests := []struct {
+		name       string
+		script     string
+		wantSignal string
+		killed     bool
+	}{
+		{name: "exits on SIGTERM", script: "echo ready; sleep 10", wantSignal: "terminated"},
+		{name: "ignores SIGTERM", script: `trap "" TERM; echo ready; sleep 10`, wantSignal: "killed", killed: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			job := &CommandJob{Command: "sh", Args: []string{"-c", test.script}, Logger: testLogger{}}
+			job.CommonJobFields.ID = "kill-job"
+			job.CommonJobFields.KillGracePeriod = core.Duration(gracePeriod)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			stdout := &readyWriter{ready: make(chan struct{})}
+			var cancelledAt time.Time
+			go func() {
+				select {
+				case <-stdout.ready:
+				case <-time.After(5 * time.Second):
+				}
+				cancelledAt = time.Now()
+				cancel()
+			}()
+
+			result, err := job.Execute(ctx, core.RunInfo{RunID: "run-1", JobID: "kill-job", Stdout: stdout})
+			waited := time.Since(cancelledAt)
+			if err == nil || result.Signal != test.wantSignal {
+				t.Fatalf("got the signal %q and the error %v, want the signal %q", result.Signal, err, test.wantSignal)
+			}
+			if result.ExitCode == nil || *result.ExitCode != -1 {
+				t.Errorf("got the exit code %v, want -1 for a signaled process", result.ExitCode)
+			}
+			if killed := waited >= gracePeriod; killed != test.killed || waited > 5*time.Second {
+				t.Errorf("process exited %v after the cancellation, grace period %v", waited, gracePeriod)
+			}
+		})
+	}
+}
@@ -0,0 +1,445 @@
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RollPolicy decides what happens to a run time of a CalendarSchedule which falls on an excluded date.
+type RollPolicy string
+
+// Frequency is the FREQ of a RecurrenceRule.
+type Frequency string
+
+const (
+	// Don't run at the excluded time (default).
+	ROLL_NONE RollPolicy = "None"
+	// Run at the same wall clock time on the closest allowed day before the excluded one, e.g. the last business
+	// day of the month for "0 18 L * *" with a calendar of the weekends and holidays.
+	ROLL_PREVIOUS RollPolicy = "Previous"
+	// Run at the same wall clock time on the closest allowed day after the excluded one.
+	ROLL_NEXT RollPolicy = "Next"
+
+	FREQUENCY_DAILY   Frequency = "DAILY"
+	FREQUENCY_WEEKLY  Frequency = "WEEKLY"
+	FREQUENCY_MONTHLY Frequency = "MONTHLY"
+	FREQUENCY_YEARLY  Frequency = "YEARLY"
+)
+
+const (
+	// Run times rejected by the calendars before CalendarSchedule.Next() gives up.
+	maxCalendarCandidates = 100000
+	// Days searched for an allowed day while rolling a run time.
+	maxRollDays = 31
+)
+
+// Calendar is a set of events, e.g. the holidays of an exchange, read from an iCalendar file by ParseICalendar().
+type Calendar struct {
+	Name   string
+	Events []CalendarEvent
+}
+
+// CalendarEvent is a VEVENT of a Calendar. A recurring event covers [start, start + Duration) of every occurrence.
+type CalendarEvent struct {
+	UID      string
+	Summary  string
+	Start    time.Time
+	Duration time.Duration
+	// All-day events (e.g. a holiday) and the times without a time zone are floating. Their Start is the wall
+	// clock time in UTC and they are matched on the wall clock of the checked time.
+	AllDay      bool
+	Floating    bool
+	Recurrence  *RecurrenceRule
+	ExceptDates []time.Time // EXDATE, start of the occurrences which are removed from the Recurrence
+}
+
+// RecurrenceRule is the RRULE of a recurring CalendarEvent. BYDAY with an ordinal (e.g. "4TH", "-1MO") is
+// supported only with the MONTHLY and YEARLY frequencies, BYMONTH only with YEARLY, and BYMONTHDAY can not
+// be combined with BYDAY.
+type RecurrenceRule struct {
+	Frequency  Frequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByMonth    []time.Month
+	ByMonthDay []int
+	ByDay      []WeekdayNum
+}
+
+// WeekdayNum is a BYDAY value: a weekday with an optional ordinal within the month, e.g. -1 for the last one.
+type WeekdayNum struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+var icalWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseRecurrenceRule parses the value of an RRULE property, e.g. "FREQ=WEEKLY;BYDAY=SA,SU".
+func parseRecurrenceRule(value string) (rule *RecurrenceRule, err error) {
+	rule = &RecurrenceRule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, partValue, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Frequency = Frequency(strings.ToUpper(partValue))
+		case "INTERVAL", "COUNT":
+			number, err := strconv.Atoi(partValue)
+			if err != nil || number <= 0 {
+				return nil, fmt.Errorf("invalid RRULE %v - %v", key, partValue)
+			}
+			if strings.ToUpper(key) == "INTERVAL" {
+				rule.Interval = number
+			} else {
+				rule.Count = number
+			}
+		case "UNTIL":
+			if rule.Until, _, _, err = parseICalendarTime(icalProperty{value: partValue}); err != nil {
+				return nil, fmt.Errorf("invalid RRULE UNTIL - %v", partValue)
+			}
+		case "BYMONTH":
+			for _, month := range strings.Split(partValue, ",") {
+				number, err := strconv.Atoi(month)
+				if err != nil || number < 1 || number > 12 {
+					return nil, fmt.Errorf("invalid RRULE BYMONTH - %v", partValue)
+				}
+				rule.ByMonth = append(rule.ByMonth, time.Month(number))
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(partValue, ",") {
+				number, err := strconv.Atoi(day)
+				if err != nil || number == 0 || number < -31 || number > 31 {
+					return nil, fmt.Errorf("invalid RRULE BYMONTHDAY - %v", partValue)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, number)
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(strings.ToUpper(partValue), ",") {
+				if len(day) < 2 {
+					return nil, fmt.Errorf("invalid RRULE BYDAY - %v", partValue)
+				}
+				weekday, found := icalWeekdays[day[len(day)-2:]]
+				ordinal := 0
+				if prefix := day[:len(day)-2]; prefix != "" {
+					ordinal, err = strconv.Atoi(prefix)
+				}
+				if !found || err != nil || ordinal < -5 || ordinal > 5 {
+					return nil, fmt.Errorf("invalid RRULE BYDAY - %v", partValue)
+				}
+				rule.ByDay = append(rule.ByDay, WeekdayNum{Ordinal: ordinal, Weekday: weekday})
+			}
+		case "WKST":
+			// Weeks start on Monday. WKST only changes the weekly rules with an INTERVAL and a BYDAY.
+		default:
+			return nil, fmt.Errorf("unsupported RRULE part - %v", key)
+		}
+	}
+	switch rule.Frequency {
+	case FREQUENCY_DAILY, FREQUENCY_WEEKLY, FREQUENCY_MONTHLY, FREQUENCY_YEARLY:
+	default:
+		return nil, fmt.Errorf("unsupported RRULE FREQ - %v. Supported values are DAILY, WEEKLY, MONTHLY and YEARLY", rule.Frequency)
+	}
+	if rule.Frequency == FREQUENCY_DAILY && (len(rule.ByDay) > 0 || len(rule.ByMonthDay) > 0) ||
+		rule.Frequency == FREQUENCY_WEEKLY && len(rule.ByMonthDay) > 0 {
+		return nil, fmt.Errorf("unsupported RRULE - %v. BYDAY and BYMONTHDAY are not supported with FREQ=%v", value, rule.Frequency)
+	}
+	if len(rule.ByMonth) > 0 && rule.Frequency != FREQUENCY_YEARLY {
+		return nil, fmt.Errorf("unsupported RRULE - %v. BYMONTH is supported only with FREQ=YEARLY", value)
+	}
+	if len(rule.ByDay) > 0 && len(rule.ByMonthDay) > 0 {
+		return nil, fmt.Errorf("unsupported RRULE - %v. BYDAY can not be combined with BYMONTHDAY", value)
+	}
+	for _, day := range rule.ByDay {
+		if day.Ordinal != 0 && rule.Frequency == FREQUENCY_WEEKLY {
+			return nil, fmt.Errorf("unsupported RRULE - %v. BYDAY ordinals are not supported with FREQ=WEEKLY", value)
+		}
+	}
+	return rule, nil
+}
+
+// Contains tells whether "t" falls in an event of the calendar.
+func (calendar *Calendar) Contains(t time.Time) bool {
+	_, found := calendar.covering(t)
+	return found
+}
+
+// covering returns the end of the latest ending event occurrence which covers "t".
+func (calendar *Calendar) covering(t time.Time) (end time.Time, found bool) {
+	for i := range calendar.Events {
+		if occurrenceEnd, covered := calendar.Events[i].covering(t); covered {
+			if !found || occurrenceEnd.After(end) {
+				end = occurrenceEnd
+			}
+			found = true
+		}
+	}
+	return
+}
+
+// covering returns the end of the occurrence of the event which covers "t", in the location of "t".
+// A zero length event covers only its start.
+func (event *CalendarEvent) covering(t time.Time) (end time.Time, covered bool) {
+	location := t.Location()
+	if event.Floating {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	}
+	covers := func(start time.Time) bool {
+		return start.Equal(t) || !t.Before(start) && t.Before(start.Add(event.Duration))
+	}
+	var coveredStart time.Time
+	if event.Recurrence == nil {
+		covered, coveredStart = covers(event.Start), event.Start
+	} else {
+		event.Recurrence.occurrences(event, t.Add(-event.Duration), t, func(start time.Time) bool {
+			covered, coveredStart = covers(start), start
+			return covered
+		})
+	}
+	if !covered {
+		return
+	}
+	end = coveredStart.Add(event.Duration)
+	if event.Floating {
+		end = time.Date(end.Year(), end.Month(), end.Day(), end.Hour(), end.Minute(), end.Second(), end.Nanosecond(), location)
+	}
+	return end.In(location), true
+}
+
+// occurrences calls "yield" with the starts of the occurrences of the event between "from" and "to" (both
+// inclusive), in order, until it returns true. The occurrences are computed period by period (e.g. month by
+// month for MONTHLY). Without a COUNT the periods before "from" are not computed.
+func (rule *RecurrenceRule) occurrences(event *CalendarEvent, from time.Time, to time.Time, yield func(start time.Time) bool) {
+	dtStart := event.Start
+	period := 0
+	if rule.Count == 0 && from.After(dtStart) {
+		// One period less than the estimate, as the days are not always 24 hours long.
+		period = max(rule.periodsBetween(dtStart, from)/rule.Interval-1, 0)
+	}
+	count := 0
+	for ; ; period++ {
+		periodStart, starts := rule.periodStarts(dtStart, period*rule.Interval)
+		if periodStart.After(to) {
+			return
+		}
+		for _, start := range starts {
+			if start.Before(dtStart) {
+				continue
+			}
+			if !rule.Until.IsZero() && start.After(rule.Until) {
+				return
+			}
+			count++
+			if rule.Count > 0 && count > rule.Count {
+				return
+			}
+			if start.After(to) {
+				return
+			}
+			if start.Before(from) || event.isExcepted(start) {
+				continue
+			}
+			if yield(start) {
+				return
+			}
+		}
+	}
+}
+
+// periodsBetween returns the number of whole periods of the rule's frequency from "start" to "t".
+func (rule *RecurrenceRule) periodsBetween(start time.Time, t time.Time) int {
+	switch rule.Frequency {
+	case FREQUENCY_DAILY:
+		return int(t.Sub(start).Hours() / 24)
+	case FREQUENCY_WEEKLY:
+		return int(t.Sub(start).Hours() / 24 / 7)
+	case FREQUENCY_MONTHLY:
+		return (t.Year()-start.Year())*12 + int(t.Month()) - int(start.Month())
+	default:
+		return t.Year() - start.Year()
+	}
+}
+
+// periodStarts returns the start of the n-th period after the one of "dtStart" and the sorted starts of the
+// occurrences in that period. The occurrences have the wall clock time of "dtStart".
+func (rule *RecurrenceRule) periodStarts(dtStart time.Time, n int) (periodStart time.Time, starts []time.Time) {
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, dtStart.Hour(), dtStart.Minute(), dtStart.Second(), dtStart.Nanosecond(), dtStart.Location())
+	}
+	year, month, day := dtStart.Date()
+	switch rule.Frequency {
+	case FREQUENCY_DAILY:
+		periodStart = at(year, month, day+n)
+		return periodStart, []time.Time{periodStart}
+	case FREQUENCY_WEEKLY:
+		// Weeks start on Monday.
+		periodStart = at(year, month, day-(int(dtStart.Weekday())+6)%7+7*n)
+		if len(rule.ByDay) == 0 {
+			return periodStart, []time.Time{at(year, month, day+7*n)}
+		}
+		for _, weekday := range rule.ByDay {
+			starts = append(starts, periodStart.AddDate(0, 0, (int(weekday.Weekday)+6)%7))
+		}
+	case FREQUENCY_MONTHLY:
+		periodStart = at(year, month+time.Month(n), 1)
+		starts = rule.monthStarts(periodStart, day, at)
+	default:
+		periodStart = at(year+n, time.January, 1)
+		months := rule.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{month}
+		}
+		for _, byMonth := range months {
+			starts = append(starts, rule.monthStarts(at(year+n, byMonth, 1), day, at)...)
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	return periodStart, starts
+}
+
+// monthStarts returns the starts of the occurrences in the month of "monthStart" as per BYMONTHDAY and BYDAY.
+// Without them, the occurrence is on "day" of the month, and is dropped in the months without that day.
+func (rule *RecurrenceRule) monthStarts(monthStart time.Time, day int, at func(int, time.Month, int) time.Time) (starts []time.Time) {
+	year, month := monthStart.Year(), monthStart.Month()
+	lastDay := at(year, month+1, 0).Day()
+	addDay := func(monthDay int) {
+		if monthDay >= 1 && monthDay <= lastDay {
+			starts = append(starts, at(year, month, monthDay))
+		}
+	}
+	switch {
+	case len(rule.ByMonthDay) > 0:
+		for _, monthDay := range rule.ByMonthDay {
+			if monthDay < 0 {
+				monthDay = lastDay + monthDay + 1
+			}
+			addDay(monthDay)
+		}
+	case len(rule.ByDay) > 0:
+		firstWeekday := int(at(year, month, 1).Weekday())
+		for _, weekday := range rule.ByDay {
+			first := 1 + (int(weekday.Weekday)-firstWeekday+7)%7
+			switch {
+			case weekday.Ordinal > 0:
+				addDay(first + 7*(weekday.Ordinal-1))
+			case weekday.Ordinal < 0:
+				last := first + 7*((lastDay-first)/7)
+				addDay(last + 7*(weekday.Ordinal+1))
+			default:
+				for monthDay := first; monthDay <= lastDay; monthDay += 7 {
+					addDay(monthDay)
+				}
+			}
+		}
+	default:
+		addDay(day)
+	}
+	return
+}
+
+// isExcepted tells whether the occurrence starting at "start" is removed by an EXDATE.
+func (event *CalendarEvent) isExcepted(start time.Time) bool {
+	for _, exDate := range event.ExceptDates {
+		if exDate.Equal(start) || event.AllDay && exDate.Year() == start.Year() && exDate.YearDay() == start.YearDay() {
+			return true
+		}
+	}
+	return false
+}
+
+// CalendarSchedule runs at the times of the Base schedule which are allowed by the calendars: the time falls in
+// an event of an Include calendar (if there are any) and in no event of an Exclude calendar. A run time which is
+// not allowed is dropped or rolled to another day as per Roll.
+type CalendarSchedule struct {
+	Base    Schedule
+	Include []*Calendar
+	Exclude []*Calendar
+	Roll    RollPolicy // None (default), Previous or Next
+	// Location of the wall clock on which the floating events (e.g. the holidays) are matched and the run times
+	// are rolled. If nil, the location of the run time is used.
+	Location *time.Location
+}
+
+// Allows tells whether the calendars allow a run at "t".
+func (schedule *CalendarSchedule) Allows(t time.Time) bool {
+	if schedule.Location != nil {
+		t = t.In(schedule.Location)
+	}
+	for _, calendar := range schedule.Exclude {
+		if calendar.Contains(t) {
+			return false
+		}
+	}
+	if len(schedule.Include) == 0 {
+		return true
+	}
+	for _, calendar := range schedule.Include {
+		if calendar.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the first allowed run time strictly after "after". Zero time is returned if the Base schedule
+// has no more runs, or none of its next few run times is allowed.
+func (schedule *CalendarSchedule) Next(after time.Time) (next time.Time) {
+	start := after
+	for i := 0; i < maxCalendarCandidates; i++ {
+		next = schedule.Base.Next(after)
+		if next.IsZero() || schedule.Allows(next) {
+			return next
+		}
+		if rolled := schedule.roll(next); !rolled.IsZero() && rolled.After(start) {
+			return rolled
+		}
+		after = next
+		if end, excluded := schedule.excludedUntil(next); schedule.Roll != ROLL_PREVIOUS && excluded && end.After(next) {
+			// The run times up to the end of the excluding event are not allowed either. Rolling them forward
+			// gives the same or a later time than rolling "next".
+			after = end.Add(-time.Nanosecond)
+		}
+	}
+	return time.Time{}
+}
+
+// roll moves the run time to the same wall clock time on the closest allowed day as per the Roll policy.
+// Zero time is returned if the time is not rolled.
+func (schedule *CalendarSchedule) roll(t time.Time) time.Time {
+	step := 0
+	switch schedule.Roll {
+	case ROLL_PREVIOUS:
+		step = -1
+	case ROLL_NEXT:
+		step = 1
+	default:
+		return time.Time{}
+	}
+	if schedule.Location != nil {
+		t = t.In(schedule.Location)
+	}
+	for days := 1; days <= maxRollDays; days++ {
+		rolled := time.Date(t.Year(), t.Month(), t.Day()+step*days, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+		if schedule.Allows(rolled) {
+			return rolled
+		}
+	}
+	return time.Time{}
+}
+
+// excludedUntil returns the end of the latest ending event of the Exclude calendars which covers "t".
+func (schedule *CalendarSchedule) excludedUntil(t time.Time) (end time.Time, excluded bool) {
+	if schedule.Location != nil {
+		t = t.In(schedule.Location)
+	}
+	for _, calendar := range schedule.Exclude {
+		if calendarEnd, found := calendar.covering(t); found && (!excluded || calendarEnd.After(end)) {
+			end, excluded = calendarEnd, true
+		}
+	}
+	return
+}
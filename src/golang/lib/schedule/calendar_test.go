@@ -0,0 +1,142 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// testHolidays has the weekends, two holidays (one of them recurring) and a timed event in New York.
+const testHolidays = `BEGIN:VCALENDAR
+VERSION:2.0
+X-WR-CALNAME:Exchange holidays
+BEGIN:VEVENT
+UID:weekends
+SUMMARY:Weekend
+DTSTART;VALUE=DATE:20000101
+RRULE:FREQ=WEEKLY;BYDAY=SA,SU
+END:VEVENT
+BEGIN:VEVENT
+UID:christmas
+SUMMARY:Christmas
+DTSTART;VALUE=DATE:20261225
+DTEND;VALUE=DATE:20261226
+END:VEVENT
+BEGIN:VEVENT
+UID:thanksgiving
+SUMMARY:Thanks
+ giving
+DTSTART;VALUE=DATE:20201126
+RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=4TH
+EXDATE;VALUE=DATE:20271125
+END:VEVENT
+BEGIN:VEVENT
+UID:early-close
+SUMMARY:Early close
+DTSTART;TZID=America/New_York:20261124T130000
+DURATION:PT3H
+END:VEVENT
+BEGIN:VEVENT
+UID:cancelled
+DTSTART;VALUE=DATE:20261201
+STATUS:CANCELLED
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestParseICalendar(t *testing.T) {
+	calendar, err := ParseICalendar([]byte(strings.ReplaceAll(testHolidays, "\n", "\r\n")))
+	if err != nil {
+		t.Fatalf("failed to parse the calendar: %v", err)
+	}
+	if calendar.Name != "Exchange holidays" || len(calendar.Events) != 4 || calendar.Events[2].Summary != "Thanksgiving" {
+		t.Fatalf("got the calendar %+v", calendar)
+	}
+	kolkata := mustLoadLocation(t, "Asia/Kolkata")
+	tests := []struct {
+		at   time.Time
+		want bool
+	}{
+		{mustParseTime(t, "2026-10-31T12:00:00Z"), true},  // Saturday
+		{mustParseTime(t, "2026-10-30T23:59:59Z"), false}, // Friday
+		{mustParseTime(t, "2026-12-25T00:00:00Z"), true},
+		{mustParseTime(t, "2026-12-26T00:00:00Z"), true}, // Saturday
+		{mustParseTime(t, "2026-12-28T09:00:00Z"), false},
+		{mustParseTime(t, "2026-11-26T09:00:00Z"), true},
+		{mustParseTime(t, "2027-11-25T09:00:00Z"), false}, // EXDATE
+		{mustParseTime(t, "2028-11-23T09:00:00Z"), true},
+		{mustParseTime(t, "2026-11-24T18:00:00Z"), true}, // 13:00 in New York
+		{mustParseTime(t, "2026-11-24T21:00:00Z"), false},
+		{mustParseTime(t, "2026-12-01T09:00:00Z"), false}, // Cancelled
+		// The floating events are matched on the wall clock of the time: 23:00 UTC on Friday is Saturday in Kolkata.
+		{mustParseTime(t, "2026-10-30T23:00:00Z").In(kolkata), true},
+	}
+	for _, test := range tests {
+		if got := calendar.Contains(test.at); got != test.want {
+			t.Errorf("Contains(%v) = %v, want %v", test.at, got, test.want)
+		}
+	}
+}
+
+func TestParseICalendarErrors(t *testing.T) {
+	invalid := []string{
+		"BEGIN:VEVENT\nDTSTART:20261225\nEND:VEVENT\n",
+		"BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:No start\nEND:VEVENT\nEND:VCALENDAR\n",
+		"BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20261225\nRRULE:FREQ=HOURLY\nEND:VEVENT\nEND:VCALENDAR\n",
+		"BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20261225\nRRULE:FREQ=WEEKLY;BYDAY=2MO\nEND:VEVENT\nEND:VCALENDAR\n",
+		"BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;TZID=Mars/Olympus:20261225T090000\nEND:VEVENT\nEND:VCALENDAR\n",
+		"BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20261225\nDTEND:20261224\nEND:VEVENT\nEND:VCALENDAR\n",
+	}
+	for i, data := range invalid {
+		if _, err := ParseICalendar([]byte(data)); err == nil {
+			t.Errorf("invalid #%v: got no error", i)
+		}
+	}
+}
+
+func TestCalendarSchedule(t *testing.T) {
+	holidays, err := ParseICalendar([]byte(testHolidays))
+	if err != nil {
+		t.Fatalf("failed to parse the calendar: %v", err)
+	}
+	payDays, err := ParseICalendar([]byte("BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20260101\nRRULE:FREQ=MONTHLY;BYMONTHDAY=1,-1\nEND:VEVENT\nEND:VCALENDAR\n"))
+	if err != nil {
+		t.Fatalf("failed to parse the calendar: %v", err)
+	}
+	tests := []struct {
+		name     string
+		expr     string
+		schedule CalendarSchedule
+		after    string
+		want     []string
+	}{
+		// 31 October 2026 is a Saturday and 31 January 2027 is a Sunday.
+		{"last business day", "0 18 L * *", CalendarSchedule{Exclude: []*Calendar{holidays}, Roll: ROLL_PREVIOUS}, "2026-10-01T00:00:00Z",
+			[]string{"2026-10-30T18:00:00Z", "2026-11-30T18:00:00Z", "2026-12-31T18:00:00Z", "2027-01-29T18:00:00Z"}},
+		{"holidays skipped", "0 9 * * *", CalendarSchedule{Exclude: []*Calendar{holidays}}, "2026-12-24T09:00:00Z",
+			[]string{"2026-12-28T09:00:00Z", "2026-12-29T09:00:00Z"}},
+		{"rolled to the next business day", "0 9 1 * *", CalendarSchedule{Exclude: []*Calendar{holidays}, Roll: ROLL_NEXT}, "2026-10-15T00:00:00Z",
+			[]string{"2026-11-02T09:00:00Z", "2026-12-01T09:00:00Z"}},
+		{"included days", "0 12 * * *", CalendarSchedule{Include: []*Calendar{payDays}}, "2026-01-02T00:00:00Z",
+			[]string{"2026-01-31T12:00:00Z", "2026-02-01T12:00:00Z", "2026-02-28T12:00:00Z"}},
+		// The first and the last days of January, February and March 2026 are weekends except 31 March.
+		{"included and excluded days", "0 12 * * *", CalendarSchedule{Include: []*Calendar{payDays}, Exclude: []*Calendar{holidays}},
+			"2026-01-02T00:00:00Z", []string{"2026-03-31T12:00:00Z", "2026-04-01T12:00:00Z"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			base, err := Parse(test.expr)
+			if err != nil {
+				t.Fatalf("failed to parse %v: %v", test.expr, err)
+			}
+			test.schedule.Base = base
+			current := mustParseTime(t, test.after)
+			for i, want := range test.want {
+				current = test.schedule.Next(current)
+				if !current.Equal(mustParseTime(t, want)) {
+					t.Fatalf("run #%v: got %v, want %v", i, current, want)
+				}
+			}
+		})
+	}
+}
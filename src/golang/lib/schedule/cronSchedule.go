@@ -0,0 +1,223 @@
+package schedule
+
+import (
+	"sort"
+	"time"
+)
+
+// SkippedTimePolicy decides when a schedule time which falls in the skipped hour of a
+// daylight saving transition (clocks moving forward) runs.
+type SkippedTimePolicy string
+
+// RepeatedTimePolicy decides how many times a schedule time which falls in the repeated
+// hour of a daylight saving transition (clocks moving backward) runs.
+type RepeatedTimePolicy string
+
+const (
+	// Run at the first valid instant after the skipped period, i.e. at the transition (default).
+	// Several schedule times inside the same skipped period result in a single run.
+	SKIPPED_TIME_RUN_AT_NEXT_VALID SkippedTimePolicy = "RunAtNextValid"
+	// Don't run the schedule times which fall in the skipped period.
+	SKIPPED_TIME_SKIP SkippedTimePolicy = "Skip"
+
+	// Run only at the first occurrence of the repeated wall clock time (default).
+	REPEATED_TIME_RUN_ONCE RepeatedTimePolicy = "RunOnce"
+	// Run at both the occurrences of the repeated wall clock time.
+	REPEATED_TIME_RUN_TWICE RepeatedTimePolicy = "RunTwice"
+)
+
+const (
+	// Schedule times are searched up to these many years ahead.
+	maxSearchYears = 5
+	// Daylight saving transitions within this duration are considered while computing the next run.
+	// It is larger than any real world daylight saving shift.
+	dstLookAhead = 3 * time.Hour
+	// Set by ParseCron() when the field is "*" or "?".
+	starBit = 1 << 63
+)
+
+// CronSchedule is a cron schedule evaluated on the wall clock of its location.
+//
+// Daylight saving semantics:
+//   - A wall clock time in the skipped hour (e.g. 02:30 when the clocks move from 02:00 to 03:00)
+//     doesn't exist. As per SkippedTime, it either runs at the transition instant (03:00) or not at all.
+//   - A wall clock time in the repeated hour (e.g. 01:30 when the clocks move from 02:00 back to 01:00)
+//     occurs twice. As per RepeatedTime, it runs only at the first occurrence or at both the occurrences.
+//
+// Every other wall clock time maps to exactly one instant and runs once.
+type CronSchedule struct {
+	Second, Minute, Hour, Dom, Month, Dow uint64
+	// Quartz style modifiers of the day fields (L, W and #), matched in addition to the Dom and Dow bits.
+	domRules, dowRules []dayRule
+	// Location in which the wall clock times are evaluated. If nil, the location of
+	// the time passed to Next() is used.
+	Location     *time.Location
+	SkippedTime  SkippedTimePolicy
+	RepeatedTime RepeatedTimePolicy
+}
+
+// Next returns the first run time of the schedule strictly after "after", in the schedule's location.
+// Zero time is returned if there is no run time in the next few years.
+func (schedule *CronSchedule) Next(after time.Time) (next time.Time) {
+	location := schedule.Location
+	if location == nil {
+		location = after.Location()
+	}
+	after = after.In(location)
+	// If the clocks move backward shortly after "after", the wall clock times just before
+	// wall("after") occur again. Start the search early enough to find them.
+	start := toWall(after).Truncate(time.Second)
+	_, afterOffset := after.Zone()
+	_, aheadOffset := after.Add(dstLookAhead).Zone()
+	repeatedDuration := time.Duration(afterOffset-aheadOffset) * time.Second
+	if repeatedDuration > 0 {
+		start = start.Add(-repeatedDuration)
+	} else {
+		repeatedDuration = 0
+	}
+	for wall := schedule.nextWall(start); !wall.IsZero(); wall = schedule.nextWall(wall.Add(time.Second)) {
+		// Outside the repeated period the instants increase with the wall clock, so the first instant
+		// found is the earliest. Within it, a later wall clock time can still map to an earlier instant.
+		if !next.IsZero() && wall.After(toWall(next).Add(repeatedDuration)) {
+			break
+		}
+		for _, instant := range schedule.resolve(wall, location) {
+			if instant.After(after) && (next.IsZero() || instant.Before(next)) {
+				next = instant
+			}
+		}
+	}
+	return next
+}
+
+// nextWall returns the first wall clock time at or after "wall" which matches the schedule fields.
+// Wall clock times are represented in UTC so that the daylight saving transitions don't interfere.
+func (schedule *CronSchedule) nextWall(wall time.Time) time.Time {
+	yearLimit := wall.Year() + maxSearchYears
+WRAP:
+	if wall.Year() > yearLimit {
+		return time.Time{}
+	}
+	for 1<<uint(wall.Month())&schedule.Month == 0 {
+		wall = time.Date(wall.Year(), wall.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		if wall.Month() == time.January {
+			goto WRAP
+		}
+	}
+	for !schedule.dayMatches(wall) {
+		wall = time.Date(wall.Year(), wall.Month(), wall.Day()+1, 0, 0, 0, 0, time.UTC)
+		if wall.Day() == 1 {
+			goto WRAP
+		}
+	}
+	for 1<<uint(wall.Hour())&schedule.Hour == 0 {
+		wall = wall.Truncate(time.Hour).Add(time.Hour)
+		if wall.Hour() == 0 {
+			goto WRAP
+		}
+	}
+	for 1<<uint(wall.Minute())&schedule.Minute == 0 {
+		wall = wall.Truncate(time.Minute).Add(time.Minute)
+		if wall.Minute() == 0 {
+			goto WRAP
+		}
+	}
+	for 1<<uint(wall.Second())&schedule.Second == 0 {
+		wall = wall.Add(time.Second)
+		if wall.Second() == 0 {
+			goto WRAP
+		}
+	}
+	return wall
+}
+
+// dayMatches follows the cron convention: if both day of month and day of week are
+// restricted, the day matches when either of them matches.
+func (schedule *CronSchedule) dayMatches(wall time.Time) bool {
+	domMatch := 1<<uint(wall.Day())&schedule.Dom > 0 || matchesAny(schedule.domRules, wall)
+	dowMatch := 1<<uint(wall.Weekday())&schedule.Dow > 0 || matchesAny(schedule.dowRules, wall)
+	if schedule.Dom&starBit > 0 || schedule.Dow&starBit > 0 {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func matchesAny(rules []dayRule, wall time.Time) bool {
+	for _, rule := range rules {
+		if rule.matches(wall) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the instants at which the wall clock time runs in the location, as per the
+// daylight saving policies of the schedule.
+func (schedule *CronSchedule) resolve(wall time.Time, location *time.Location) (instants []time.Time) {
+	instants = wallInstants(wall, location)
+	switch len(instants) {
+	case 0:
+		if schedule.SkippedTime == SKIPPED_TIME_SKIP {
+			return nil
+		}
+		return []time.Time{skippedWallTransition(wall, location)}
+	case 1:
+		return instants
+	default:
+		if schedule.RepeatedTime == REPEATED_TIME_RUN_TWICE {
+			return instants
+		}
+		return instants[:1]
+	}
+}
+
+// wallInstants returns all the instants whose wall clock time in the location is "wall", in
+// chronological order. It returns no instant for a skipped time and two for a repeated time.
+func wallInstants(wall time.Time, location *time.Location) (instants []time.Time) {
+	for _, offset := range nearbyOffsets(wall, location) {
+		instant := wall.Add(-time.Duration(offset) * time.Second).In(location)
+		if toWall(instant).Equal(wall) {
+			instants = append(instants, instant)
+		}
+	}
+	sort.Slice(instants, func(a, b int) bool { return instants[a].Before(instants[b]) })
+	return
+}
+
+// skippedWallTransition returns the instant at which the clocks moved forward over the skipped "wall".
+func skippedWallTransition(wall time.Time, location *time.Location) time.Time {
+	offsets := nearbyOffsets(wall, location)
+	// Interpreting the wall clock time with the smallest (pre-transition) offset gives an instant
+	// just after the transition. The transition is the start of the zone period of that instant.
+	instant := wall.Add(-time.Duration(offsets[0]) * time.Second).In(location)
+	transition, _ := instant.ZoneBounds()
+	if transition.IsZero() {
+		return instant
+	}
+	return transition.In(location)
+}
+
+// nearbyOffsets returns the distinct UTC offsets (in seconds, ascending) of the location around the wall clock time.
+func nearbyOffsets(wall time.Time, location *time.Location) (offsets []int) {
+	approx := wall.In(location)
+	for _, probe := range []time.Time{approx.Add(-24 * time.Hour), approx, approx.Add(24 * time.Hour)} {
+		_, offset := probe.Zone()
+		found := false
+		for _, existing := range offsets {
+			if existing == offset {
+				found = true
+				break
+			}
+		}
+		if !found {
+			offsets = append(offsets, offset)
+		}
+	}
+	sort.Ints(offsets)
+	return
+}
+
+// toWall returns the wall clock time of "t" as a UTC time.
+func toWall(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
@@ -0,0 +1,197 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("failed to load the location %v: %v", name, err)
+	}
+	return location
+}
+
+func mustParseTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("failed to parse the time %v: %v", value, err)
+	}
+	return parsed
+}
+
+func mustParseCron(t *testing.T, cronExpr string, skipped SkippedTimePolicy, repeated RepeatedTimePolicy) *CronSchedule {
+	t.Helper()
+	cronSchedule, err := ParseCron(cronExpr)
+	if err != nil {
+		t.Fatalf("failed to parse the cron expression %v: %v", cronExpr, err)
+	}
+	cronSchedule.SkippedTime = skipped
+	cronSchedule.RepeatedTime = repeated
+	return cronSchedule
+}
+
+// TestCronScheduleDST checks the sequence of run times produced by Next() around the
+// daylight saving transitions. The expected run times are UTC instants.
+func TestCronScheduleDST(t *testing.T) {
+	tests := []struct {
+		name     string
+		location string
+		cronExpr string
+		skipped  SkippedTimePolicy
+		repeated RepeatedTimePolicy
+		after    string
+		want     []string
+	}{
+		{
+			name:     "New York skipped hour runs at the transition",
+			location: "America/New_York",
+			cronExpr: "30 2 * * *",
+			after:    "2026-03-07T17:00:00Z",
+			want:     []string{"2026-03-08T07:00:00Z", "2026-03-09T06:30:00Z"},
+		},
+		{
+			name:     "New York skipped hour with Skip policy",
+			location: "America/New_York",
+			cronExpr: "30 2 * * *",
+			skipped:  SKIPPED_TIME_SKIP,
+			after:    "2026-03-07T17:00:00Z",
+			want:     []string{"2026-03-09T06:30:00Z"},
+		},
+		{
+			name:     "New York several times in the skipped hour run once",
+			location: "America/New_York",
+			cronExpr: "*/20 2,3 * * *",
+			after:    "2026-03-08T06:59:00Z",
+			want:     []string{"2026-03-08T07:00:00Z", "2026-03-08T07:20:00Z", "2026-03-08T07:40:00Z"},
+		},
+		{
+			name:     "New York repeated hour runs once",
+			location: "America/New_York",
+			cronExpr: "30 1 * * *",
+			after:    "2026-11-01T04:00:00Z",
+			want:     []string{"2026-11-01T05:30:00Z", "2026-11-02T06:30:00Z"},
+		},
+		{
+			name:     "New York repeated hour runs twice",
+			location: "America/New_York",
+			cronExpr: "30 1 * * *",
+			repeated: REPEATED_TIME_RUN_TWICE,
+			after:    "2026-11-01T04:00:00Z",
+			want:     []string{"2026-11-01T05:30:00Z", "2026-11-01T06:30:00Z", "2026-11-02T06:30:00Z"},
+		},
+		{
+			name:     "New York every 15 minutes through the repeated hour runs once",
+			location: "America/New_York",
+			cronExpr: "*/15 * * * *",
+			after:    "2026-11-01T05:40:00Z",
+			want:     []string{"2026-11-01T05:45:00Z", "2026-11-01T07:00:00Z", "2026-11-01T07:15:00Z"},
+		},
+		{
+			name:     "New York every 15 minutes through the repeated hour runs twice",
+			location: "America/New_York",
+			cronExpr: "*/15 * * * *",
+			repeated: REPEATED_TIME_RUN_TWICE,
+			after:    "2026-11-01T05:40:00Z",
+			want: []string{"2026-11-01T05:45:00Z", "2026-11-01T06:00:00Z", "2026-11-01T06:15:00Z",
+				"2026-11-01T06:30:00Z", "2026-11-01T06:45:00Z", "2026-11-01T07:00:00Z"},
+		},
+		{
+			name:     "New York inside the second pass of the repeated hour",
+			location: "America/New_York",
+			cronExpr: "*/15 * * * *",
+			after:    "2026-11-01T06:10:00Z",
+			want:     []string{"2026-11-01T07:00:00Z"},
+		},
+		{
+			name:     "London skipped hour",
+			location: "Europe/London",
+			cronExpr: "30 1 * * *",
+			after:    "2026-03-28T12:00:00Z",
+			want:     []string{"2026-03-29T01:00:00Z", "2026-03-30T00:30:00Z"},
+		},
+		{
+			name:     "London repeated hour runs twice",
+			location: "Europe/London",
+			cronExpr: "30 1 * * *",
+			repeated: REPEATED_TIME_RUN_TWICE,
+			after:    "2026-10-24T12:00:00Z",
+			want:     []string{"2026-10-25T00:30:00Z", "2026-10-25T01:30:00Z", "2026-10-26T01:30:00Z"},
+		},
+		{
+			name:     "Sydney skipped hour in October",
+			location: "Australia/Sydney",
+			cronExpr: "30 2 * * *",
+			after:    "2026-10-03T00:00:00Z",
+			want:     []string{"2026-10-03T16:00:00Z", "2026-10-04T15:30:00Z"},
+		},
+		{
+			name:     "Sydney repeated hour in April runs once",
+			location: "Australia/Sydney",
+			cronExpr: "30 2 * * *",
+			after:    "2026-04-04T00:00:00Z",
+			want:     []string{"2026-04-04T15:30:00Z", "2026-04-05T16:30:00Z"},
+		},
+		{
+			name:     "Lord Howe half hour skipped period",
+			location: "Australia/Lord_Howe",
+			cronExpr: "15 2 * * *",
+			after:    "2026-10-03T00:00:00Z",
+			want:     []string{"2026-10-03T15:30:00Z", "2026-10-04T15:15:00Z"},
+		},
+		{
+			name:     "Lord Howe half hour repeated period runs twice",
+			location: "Australia/Lord_Howe",
+			cronExpr: "45 1 * * *",
+			repeated: REPEATED_TIME_RUN_TWICE,
+			after:    "2026-04-04T00:00:00Z",
+			want:     []string{"2026-04-04T14:45:00Z", "2026-04-04T15:15:00Z", "2026-04-05T15:15:00Z"},
+		},
+		{
+			name:     "Kolkata has no daylight saving",
+			location: "Asia/Kolkata",
+			cronExpr: "0 9 * * *",
+			after:    "2026-03-08T00:00:00Z",
+			want:     []string{"2026-03-08T03:30:00Z", "2026-03-09T03:30:00Z"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			location := mustLoadLocation(t, test.location)
+			cronSchedule := mustParseCron(t, test.cronExpr, test.skipped, test.repeated)
+			current := mustParseTime(t, test.after).In(location)
+			for i, want := range test.want {
+				next := cronSchedule.Next(current)
+				if !next.Equal(mustParseTime(t, want)) {
+					t.Fatalf("run #%v: got %v (%v), want %v", i, next.UTC().Format(time.RFC3339), next, want)
+				}
+				if next.Location() != location {
+					t.Fatalf("run #%v: got the location %v, want %v", i, next.Location(), location)
+				}
+				current = next
+			}
+		})
+	}
+}
+
+func TestCronScheduleTimezonePrefix(t *testing.T) {
+	cronSchedule := mustParseCron(t, "CRON_TZ=America/New_York 30 2 * * *", "", "")
+	next := cronSchedule.Next(mustParseTime(t, "2026-03-07T17:00:00Z"))
+	if want := mustParseTime(t, "2026-03-08T07:00:00Z"); !next.Equal(want) {
+		t.Fatalf("got %v, want %v", next, want)
+	}
+	if next.Location().String() != "America/New_York" {
+		t.Fatalf("got the location %v, want America/New_York", next.Location())
+	}
+}
+
+func TestCronScheduleNoMatch(t *testing.T) {
+	cronSchedule := mustParseCron(t, "0 0 30 2 *", "", "")
+	if next := cronSchedule.Next(mustParseTime(t, "2026-01-01T00:00:00Z")); !next.IsZero() {
+		t.Fatalf("got %v, want zero time for 30th February", next)
+	}
+}
@@ -0,0 +1,120 @@
+package schedule
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// hashFieldRanges are the ranges of the cron fields (second, minute, hour, day of month, month, day of week) from
+// which the "H" tokens pick their values. Day of month stops at 28 so that the value exists in every month.
+var hashFieldRanges = [6][2]int{{0, 59}, {0, 59}, {0, 23}, {1, 28}, {1, 12}, {0, 6}}
+
+// ParseHashed parses the schedule expression as per Parse() after replacing its "H" tokens as per ExpandHash().
+func ParseHashed(expr string, seed string) (schedule Schedule, err error) {
+	expanded, err := ExpandHash(expr, seed)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(expanded)
+}
+
+// ExpandHash replaces the Jenkins style "H" tokens of the cron expression with values derived from the
+// seed (e.g. the job ID), so that the jobs with the same expression run at different but stable times:
+//   - "H" picks one value of the field's range, e.g. "H * * * *" runs once an hour at a fixed minute.
+//   - "H(a-b)" picks one value in a-b.
+//   - "H/n" and "H(a-b)/n" run every n starting at an offset below n.
+//
+// Each field gets its own value. The expressions without "H" are returned unchanged.
+func ExpandHash(cronExpr string, seed string) (expanded string, err error) {
+	trimmed := strings.TrimSpace(cronExpr)
+	prefix := ""
+	if strings.HasPrefix(trimmed, "TZ=") || strings.HasPrefix(trimmed, "CRON_TZ=") {
+		parts := strings.SplitN(trimmed, " ", 2)
+		if len(parts) < 2 {
+			return cronExpr, nil
+		}
+		prefix, trimmed = parts[0]+" ", strings.TrimSpace(parts[1])
+	}
+	// The zone name of the prefix may contain "H" (e.g. Europe/Helsinki).
+	if !strings.Contains(trimmed, "H") || strings.HasPrefix(trimmed, "@") {
+		return cronExpr, nil
+	}
+	fields := strings.Fields(trimmed)
+	if len(fields) != 5 && len(fields) != 6 {
+		return "", fmt.Errorf("expected 5 or 6 fields, found %v: %q", len(fields), trimmed)
+	}
+	// The fields are aligned to the end, as the second field is optional.
+	ranges := hashFieldRanges[len(hashFieldRanges)-len(fields):]
+	for i, field := range fields {
+		items := strings.Split(field, ",")
+		for j, item := range items {
+			if strings.HasPrefix(item, "H") {
+				fieldIndex := i + len(hashFieldRanges) - len(fields)
+				if items[j], err = expandHashItem(item, ranges[i], hashValue(seed, fieldIndex)); err != nil {
+					return "", err
+				}
+			}
+		}
+		fields[i] = strings.Join(items, ",")
+	}
+	return prefix + strings.Join(fields, " "), nil
+}
+
+// expandHashItem replaces a single "H", "H(a-b)", "H/n" or "H(a-b)/n" item of a field with the range [low, high].
+func expandHashItem(item string, fieldRange [2]int, hash uint32) (expanded string, err error) {
+	low, high := fieldRange[0], fieldRange[1]
+	rest := strings.TrimPrefix(item, "H")
+	if strings.HasPrefix(rest, "(") {
+		end := strings.Index(rest, ")")
+		if end < 0 {
+			return "", fmt.Errorf("missing ')' in %v", item)
+		}
+		bounds := strings.Split(rest[1:end], "-")
+		if len(bounds) != 2 {
+			return "", fmt.Errorf("invalid range in %v. Expected H(a-b)", item)
+		}
+		if low, err = parseHashBound(bounds[0], item); err != nil {
+			return "", err
+		}
+		if high, err = parseHashBound(bounds[1], item); err != nil {
+			return "", err
+		}
+		if low < fieldRange[0] || high > fieldRange[1] || low > high {
+			return "", fmt.Errorf("range of %v is outside %v-%v", item, fieldRange[0], fieldRange[1])
+		}
+		rest = rest[end+1:]
+	}
+	if rest == "" {
+		return strconv.Itoa(low + int(hash%uint32(high-low+1))), nil
+	}
+	if !strings.HasPrefix(rest, "/") {
+		return "", fmt.Errorf("invalid hash token %v", item)
+	}
+	step, err := strconv.Atoi(rest[1:])
+	if err != nil || step <= 0 {
+		return "", fmt.Errorf("invalid step in %v", item)
+	}
+	start := low + int(hash%uint32(step))
+	if start > high {
+		start = low
+	}
+	return fmt.Sprintf("%v-%v/%v", start, high, step), nil
+}
+
+func parseHashBound(bound string, item string) (value int, err error) {
+	value, err = strconv.Atoi(bound)
+	if err != nil {
+		return 0, fmt.Errorf("invalid range in %v. Error - %v", item, err)
+	}
+	return value, nil
+}
+
+// hashValue returns a stable hash of the seed for the field.
+func hashValue(seed string, field int) uint32 {
+	hasher := fnv.New32a()
+	hasher.Write([]byte(seed))
+	hasher.Write([]byte{byte(field)})
+	return hasher.Sum32()
+}
@@ -0,0 +1,90 @@
+package schedule
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestExpandHash checks that the "H" tokens are replaced by stable values within the field ranges.
+func TestExpandHash(t *testing.T) {
+	tests := []struct {
+		cronExpr string
+		check    func(t *testing.T, schedule *CronSchedule)
+	}{
+		{"H * * * *", func(t *testing.T, schedule *CronSchedule) { assertBitCount(t, schedule.Minute, 0, 59, 1) }},
+		{"H(10-19) H(0-5) * * *", func(t *testing.T, schedule *CronSchedule) {
+			assertBitCount(t, schedule.Minute, 10, 19, 1)
+			assertBitCount(t, schedule.Hour, 0, 5, 1)
+		}},
+		{"H/15 * * * *", func(t *testing.T, schedule *CronSchedule) { assertBitCount(t, schedule.Minute, 0, 59, 4) }},
+		{"0 H(0-11)/6 H * *", func(t *testing.T, schedule *CronSchedule) {
+			assertBitCount(t, schedule.Hour, 0, 11, 2)
+			assertBitCount(t, schedule.Dom, 1, 28, 1)
+		}},
+		{"CRON_TZ=Asia/Kolkata 30,H(0-29) * * * THU", func(t *testing.T, schedule *CronSchedule) {
+			assertBitCount(t, schedule.Minute, 0, 59, 2)
+		}},
+		{"CRON_TZ=Europe/Helsinki H 2 * * *", func(t *testing.T, schedule *CronSchedule) {
+			assertBitCount(t, schedule.Minute, 0, 59, 1)
+			if schedule.Location.String() != "Europe/Helsinki" {
+				t.Fatalf("got the location %v, want Europe/Helsinki", schedule.Location)
+			}
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.cronExpr, func(t *testing.T) {
+			spread := make(map[string]bool)
+			for i := 0; i < 20; i++ {
+				seed := fmt.Sprintf("job-%v", i)
+				expanded, err := ExpandHash(test.cronExpr, seed)
+				if err != nil {
+					t.Fatalf("failed to expand %v: %v", test.cronExpr, err)
+				}
+				if again, _ := ExpandHash(test.cronExpr, seed); again != expanded {
+					t.Fatalf("got %v and %v for the same seed, want the same expression", expanded, again)
+				}
+				schedule, err := ParseHashed(test.cronExpr, seed)
+				if err != nil {
+					t.Fatalf("failed to parse %v expanded as %v: %v", test.cronExpr, expanded, err)
+				}
+				test.check(t, schedule.(*CronSchedule))
+				spread[expanded] = true
+			}
+			if len(spread) < 2 {
+				t.Fatalf("got the same expression for all the seeds, want them spread")
+			}
+		})
+	}
+	// The expressions without "H" tokens are unchanged, even if the zone name of the prefix has an "H".
+	for _, cronExpr := range []string{"@hourly", "CRON_TZ=Europe/Helsinki @daily", "TZ=America/Havana 0 2 * * *", "CRON_TZ=Europe/Helsinki"} {
+		if expanded, err := ExpandHash(cronExpr, "job"); err != nil || expanded != cronExpr {
+			t.Fatalf("got %q and the error %v for %v, want it unchanged", expanded, err, cronExpr)
+		}
+	}
+	if _, err := ParseHashed("CRON_TZ=Europe/Helsinki @daily", "job"); err != nil {
+		t.Fatalf("failed to parse the descriptor with the Europe/Helsinki prefix: %v", err)
+	}
+	for _, cronExpr := range []string{"H(50-70) * * * *", "H(5) * * * *", "H/0 * * * *", "H(1-2 * * * *", "Hx * * * *", "H * * *"} {
+		if _, err := ExpandHash(cronExpr, "job"); err == nil {
+			t.Fatalf("got no error for %v, want an error", cronExpr)
+		}
+	}
+}
+
+// assertBitCount checks that the field has "count" values and all of them are in [low, high].
+func assertBitCount(t *testing.T, field uint64, low, high, count int) {
+	t.Helper()
+	got := 0
+	for bit := 0; bit < 63; bit++ {
+		if field&(1<<uint(bit)) == 0 {
+			continue
+		}
+		if bit < low || bit > high {
+			t.Fatalf("got the value %v, want it in %v-%v", bit, low, high)
+		}
+		got++
+	}
+	if got != count {
+		t.Fatalf("got %v values, want %v", got, count)
+	}
+}
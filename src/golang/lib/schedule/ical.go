@@ -0,0 +1,205 @@
+package schedule
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// isoDurationRegex matches the iCalendar durations, e.g. "P1D", "PT1H30M" or "P2W".
+var isoDurationRegex = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// icalEvent is a VEVENT being parsed.
+type icalEvent struct {
+	CalendarEvent
+	end         time.Time // DTEND
+	hasDuration bool
+	cancelled   bool
+}
+
+// icalProperty is a content line of an iCalendar file, e.g. "DTSTART;TZID=Europe/London:20261225T090000".
+type icalProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// ParseICalendar parses the VEVENTs of an iCalendar (RFC 5545) file. Supported properties are DTSTART, DTEND,
+// DURATION, RRULE, EXDATE, SUMMARY, UID and STATUS (cancelled events are dropped). Times with a TZID are loaded
+// from the IANA time zone database, so the VTIMEZONE definitions are not read. The supported RRULE parts are
+// FREQ, INTERVAL, COUNT, UNTIL, BYMONTH, BYMONTHDAY and BYDAY (see RecurrenceRule).
+func ParseICalendar(data []byte) (calendar *Calendar, err error) {
+	lines, err := unfoldICalendar(data)
+	if err != nil {
+		return nil, err
+	}
+	calendar = &Calendar{}
+	var event *icalEvent
+	foundCalendar := false
+	for lineNo, line := range lines {
+		if line == "" {
+			continue
+		}
+		property, err := parseICalendarLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid iCalendar line %v. Error - %v", lineNo+1, err)
+		}
+		switch {
+		case property.name == "BEGIN" && property.value == "VCALENDAR":
+			foundCalendar = true
+		case property.name == "BEGIN" && property.value == "VEVENT":
+			event = &icalEvent{}
+		case property.name == "END" && property.value == "VEVENT" && event != nil:
+			if event.Start.IsZero() {
+				return nil, fmt.Errorf("invalid iCalendar event %q. DTSTART is not set", event.Summary)
+			}
+			if !event.end.IsZero() {
+				event.Duration = event.end.Sub(event.Start)
+			} else if !event.hasDuration && event.AllDay {
+				// An all-day event without an end lasts for the day.
+				event.Duration = 24 * time.Hour
+			}
+			if event.Duration < 0 {
+				return nil, fmt.Errorf("invalid iCalendar event %q. It ends before it starts", event.Summary)
+			}
+			if !event.cancelled {
+				calendar.Events = append(calendar.Events, event.CalendarEvent)
+			}
+			event = nil
+		case property.name == "X-WR-CALNAME" && event == nil:
+			calendar.Name = unescapeICalendarText(property.value)
+		case event != nil:
+			if err = event.setProperty(property); err != nil {
+				return nil, fmt.Errorf("invalid iCalendar event %q. Error - %v", event.Summary, err)
+			}
+		}
+	}
+	if !foundCalendar {
+		return nil, fmt.Errorf("invalid iCalendar data. BEGIN:VCALENDAR is not found")
+	}
+	return calendar, nil
+}
+
+// setProperty sets a property of the VEVENT.
+func (event *icalEvent) setProperty(property icalProperty) (err error) {
+	switch property.name {
+	case "UID":
+		event.UID = property.value
+	case "SUMMARY":
+		event.Summary = unescapeICalendarText(property.value)
+	case "STATUS":
+		event.cancelled = property.value == "CANCELLED"
+	case "DTSTART":
+		event.Start, event.AllDay, event.Floating, err = parseICalendarTime(property)
+	case "DTEND":
+		event.end, _, _, err = parseICalendarTime(property)
+	case "DURATION":
+		event.Duration, err = parseICalendarDuration(property.value)
+		event.hasDuration = true
+	case "RRULE":
+		event.Recurrence, err = parseRecurrenceRule(property.value)
+	case "EXDATE":
+		for _, value := range strings.Split(property.value, ",") {
+			exDate, _, _, err := parseICalendarTime(icalProperty{name: property.name, params: property.params, value: value})
+			if err != nil {
+				return err
+			}
+			event.ExceptDates = append(event.ExceptDates, exDate)
+		}
+	}
+	return
+}
+
+// unfoldICalendar splits the data into the content lines, joining the folded lines (lines starting with a
+// space or a tab continue the previous line).
+func unfoldICalendar(data []byte) (lines []string, err error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseICalendarLine splits a content line into its name, parameters and value.
+func parseICalendarLine(line string) (property icalProperty, err error) {
+	// The value starts at the first colon outside the quoted parameter values.
+	inQuotes, colon := false, -1
+	for i, char := range line {
+		if char == '"' {
+			inQuotes = !inQuotes
+		} else if char == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return property, fmt.Errorf("%q has no value", line)
+	}
+	parts := strings.Split(line[:colon], ";")
+	property.name = strings.ToUpper(parts[0])
+	property.value = line[colon+1:]
+	property.params = make(map[string]string)
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		property.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return
+}
+
+// parseICalendarTime parses a DATE or DATE-TIME value. DATE values and the DATE-TIME values without a time zone
+// are floating, i.e. the same wall clock time everywhere. They are returned in UTC.
+func parseICalendarTime(property icalProperty) (t time.Time, allDay bool, floating bool, err error) {
+	value := strings.TrimSpace(property.value)
+	if property.params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err = time.Parse("20060102", value)
+		return t, true, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse("20060102T150405Z", value)
+		return t, false, false, err
+	}
+	if tzid := property.params["TZID"]; tzid != "" {
+		location, err := time.LoadLocation(tzid)
+		if err != nil {
+			return t, false, false, fmt.Errorf("invalid TZID - %v. Error - %v", tzid, err)
+		}
+		t, err = time.ParseInLocation("20060102T150405", value, location)
+		return t, false, false, err
+	}
+	t, err = time.Parse("20060102T150405", value)
+	return t, false, true, err
+}
+
+// parseICalendarDuration parses a duration like "P1D" or "PT1H30M". Days are 24 hours long.
+func parseICalendarDuration(value string) (duration time.Duration, err error) {
+	match := isoDurationRegex.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil || value == "P" || value == "PT" {
+		return 0, fmt.Errorf("invalid DURATION - %v", value)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	for i, unit := range units {
+		if match[i+2] != "" {
+			count, _ := strconv.Atoi(match[i+2])
+			duration += time.Duration(count) * unit
+		}
+	}
+	if match[1] == "-" {
+		duration = -duration
+	}
+	return
+}
+
+// unescapeICalendarText unescapes a TEXT value.
+func unescapeICalendarText(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
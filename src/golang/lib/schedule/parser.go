@@ -0,0 +1,343 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule computes the run times of a job.
+type Schedule interface {
+	// Next returns the first run time strictly after "after". Zero time is returned if there is none.
+	Next(after time.Time) (next time.Time)
+}
+
+// EverySchedule runs at a fixed interval from the time it is asked for the next run ("@every 30s").
+type EverySchedule struct {
+	Interval time.Duration
+}
+
+func (schedule *EverySchedule) Next(after time.Time) (next time.Time) {
+	return after.Truncate(time.Second).Add(schedule.Interval)
+}
+
+// cronField describes a field of the cron expression.
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	secondField = cronField{name: "second", min: 0, max: 59}
+	minuteField = cronField{name: "minute", min: 0, max: 59}
+	hourField   = cronField{name: "hour", min: 0, max: 23}
+	domField    = cronField{name: "day of month", min: 1, max: 31}
+	monthField  = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}}
+	// Day of week 7 is also Sunday.
+	dowField = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}}
+)
+
+// cronDescriptors are the predefined schedules which can be used in place of the cron fields.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a schedule expression: a cron expression accepted by ParseCron() or "@every <duration>"
+// (e.g. "@every 1h30m"), which returns an EverySchedule.
+func Parse(expr string) (schedule Schedule, err error) {
+	trimmed := strings.TrimSpace(expr)
+	if strings.HasPrefix(trimmed, "@every") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(trimmed, "@every")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid @every duration in %q. Error - %v", expr, err)
+		}
+		if interval < time.Second {
+			return nil, fmt.Errorf("invalid @every duration in %q. Interval should be at least 1s", expr)
+		}
+		return &EverySchedule{Interval: interval}, nil
+	}
+	return ParseCron(expr)
+}
+
+// ParseCron parses a cron expression. It has 5 fields (minute, hour, day of month, month, day of week), or 6 with
+// a leading second field. Every field is a comma separated list of "*", values, ranges "a-b" and steps "*/n",
+// "a/n" or "a-b/n". Months and days of week can be names (JAN, MON). "?" is the same as "*" in the day fields.
+// The day fields also accept the Quartz modifiers:
+//   - Day of month: "L" (last day), "L-n" (n days before the last day), "LW" (last weekday) and
+//     "nW" (weekday nearest to the day n, within the month).
+//   - Day of week: "dL" (last day d of the month, e.g. "5L") and "d#n" (n-th day d of the month, e.g. "2#2").
+//
+// The expression can also be one of @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly.
+// A "CRON_TZ=<zone>" or "TZ=<zone>" prefix sets the Location.
+func ParseCron(cronExpr string) (schedule *CronSchedule, err error) {
+	expr := strings.TrimSpace(cronExpr)
+	var location *time.Location
+	if strings.HasPrefix(expr, "TZ=") || strings.HasPrefix(expr, "CRON_TZ=") {
+		parts := strings.SplitN(expr, " ", 2)
+		zone := parts[0][strings.Index(parts[0], "=")+1:]
+		// time.LoadLocation("") returns UTC, which would hide a missing zone.
+		if zone == "" {
+			return nil, fmt.Errorf("missing the time zone after %q", parts[0])
+		}
+		if location, err = time.LoadLocation(zone); err != nil {
+			return nil, fmt.Errorf("invalid time zone %q. Error - %v", zone, err)
+		}
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("missing the cron fields after the time zone %q", zone)
+		}
+		expr = strings.TrimSpace(parts[1])
+	}
+	if strings.HasPrefix(expr, "@") {
+		descriptor, found := cronDescriptors[expr]
+		if !found {
+			return nil, fmt.Errorf("unknown descriptor %q. Supported descriptors are @yearly, @annually, @monthly, @weekly, @daily, @midnight, @hourly and @every", expr)
+		}
+		expr = descriptor
+	}
+	fields := strings.Fields(expr)
+	if len(fields) == 5 {
+		fields = append([]string{"0"}, fields...)
+	}
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("expected 5 or 6 fields, found %v: %q", len(fields), expr)
+	}
+	schedule = &CronSchedule{Location: location}
+	if schedule.Second, err = parseField(fields[0], secondField); err != nil {
+		return nil, err
+	}
+	if schedule.Minute, err = parseField(fields[1], minuteField); err != nil {
+		return nil, err
+	}
+	if schedule.Hour, err = parseField(fields[2], hourField); err != nil {
+		return nil, err
+	}
+	if schedule.Dom, schedule.domRules, err = parseDayField(fields[3], domField, parseDomRule); err != nil {
+		return nil, err
+	}
+	if schedule.Month, err = parseField(fields[4], monthField); err != nil {
+		return nil, err
+	}
+	if schedule.Dow, schedule.dowRules, err = parseDayField(fields[5], dowField, parseDowRule); err != nil {
+		return nil, err
+	}
+	// Sunday can be written as 7.
+	if schedule.Dow&(1<<7) > 0 {
+		schedule.Dow = schedule.Dow&^(1<<7) | 1<<0
+	}
+	return schedule, nil
+}
+
+// parseDayField parses a day of month or day of week field, whose items can also be Quartz modifiers.
+func parseDayField(value string, field cronField, parseRule func(item string, field cronField) (rule *dayRule, err error)) (bits uint64, rules []dayRule, err error) {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		rule, err := parseRule(strings.ToUpper(item), field)
+		if err != nil {
+			return 0, nil, fieldError(field, value, err)
+		}
+		if rule != nil {
+			rules = append(rules, *rule)
+		} else {
+			items = append(items, item)
+		}
+	}
+	if len(items) > 0 {
+		if bits, err = parseField(strings.Join(items, ","), field); err != nil {
+			return 0, nil, err
+		}
+	}
+	return bits, rules, nil
+}
+
+// parseField parses a comma separated list of values, ranges and steps into a bit set.
+// The starBit is set for "*" and "?", as the day fields need to know whether they are restricted.
+func parseField(value string, field cronField) (bits uint64, err error) {
+	for _, item := range strings.Split(value, ",") {
+		itemBits, err := parseItem(item, field)
+		if err != nil {
+			return 0, fieldError(field, value, err)
+		}
+		bits |= itemBits
+	}
+	return bits, nil
+}
+
+func parseItem(item string, field cronField) (bits uint64, err error) {
+	rangePart, stepPart, hasStep := strings.Cut(item, "/")
+	low, high := field.min, field.max
+	star := false
+	switch {
+	case rangePart == "*" || rangePart == "?":
+		star = true
+	case strings.Contains(rangePart, "-"):
+		lowPart, highPart, _ := strings.Cut(rangePart, "-")
+		if low, err = parseValue(lowPart, field); err != nil {
+			return 0, err
+		}
+		if high, err = parseValue(highPart, field); err != nil {
+			return 0, err
+		}
+		if low > high {
+			return 0, fmt.Errorf("range %v is backwards", rangePart)
+		}
+	default:
+		if low, err = parseValue(rangePart, field); err != nil {
+			return 0, err
+		}
+		// "a/n" runs from a to the end of the range.
+		if !hasStep {
+			high = low
+		}
+	}
+	step := 1
+	if hasStep {
+		if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+			return 0, fmt.Errorf("invalid step %q", stepPart)
+		}
+	}
+	for value := low; value <= high; value += step {
+		bits |= 1 << uint(value)
+	}
+	if star && step == 1 {
+		bits |= starBit
+	}
+	return bits, nil
+}
+
+// parseValue parses a number or a name of the field and checks its range.
+func parseValue(value string, field cronField) (parsed int, err error) {
+	if named, found := field.names[strings.ToUpper(value)]; found {
+		return named, nil
+	}
+	parsed, err = strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("unknown value %q", value)
+	}
+	if parsed < field.min || parsed > field.max {
+		return 0, fmt.Errorf("value %v is outside %v-%v", parsed, field.min, field.max)
+	}
+	return parsed, nil
+}
+
+func fieldError(field cronField, value string, err error) error {
+	return fmt.Errorf("invalid %v field %q: %v", field.name, value, err)
+}
+
+// dayRuleKind is a Quartz style day modifier.
+type dayRuleKind int
+
+const (
+	lastDayOfMonth        dayRuleKind = iota // "L" and "L-n"
+	lastWeekdayOfMonth                       // "LW"
+	nearestWeekdayOfMonth                    // "nW"
+	lastDayOfWeek                            // "dL"
+	nthDayOfWeek                             // "d#n"
+)
+
+// dayRule matches the days which can't be expressed with the bit sets.
+type dayRule struct {
+	kind dayRuleKind
+	// Days before the last day for "L-n", day of month for "nW" and day of week for "dL" and "d#n".
+	value int
+	// Occurrence of the day of week in the month for "d#n".
+	nth int
+}
+
+// parseDomRule parses the day of month modifiers. Nil is returned for the plain items.
+func parseDomRule(item string, field cronField) (rule *dayRule, err error) {
+	switch {
+	case item == "L":
+		return &dayRule{kind: lastDayOfMonth}, nil
+	case item == "LW":
+		return &dayRule{kind: lastWeekdayOfMonth}, nil
+	case strings.HasPrefix(item, "L-"):
+		offset, err := strconv.Atoi(item[2:])
+		if err != nil || offset < 0 || offset > 30 {
+			return nil, fmt.Errorf("invalid offset in %q. Expected L-n with n in 0-30", item)
+		}
+		return &dayRule{kind: lastDayOfMonth, value: offset}, nil
+	case strings.HasSuffix(item, "W"):
+		day, err := parseValue(strings.TrimSuffix(item, "W"), field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid day in %q. %v", item, err)
+		}
+		return &dayRule{kind: nearestWeekdayOfMonth, value: day}, nil
+	}
+	return nil, nil
+}
+
+// parseDowRule parses the day of week modifiers. Nil is returned for the plain items.
+func parseDowRule(item string, field cronField) (rule *dayRule, err error) {
+	if day, nth, found := strings.Cut(item, "#"); found {
+		weekday, err := parseValue(day, field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid day in %q. %v", item, err)
+		}
+		occurrence, err := strconv.Atoi(nth)
+		if err != nil || occurrence < 1 || occurrence > 5 {
+			return nil, fmt.Errorf("invalid occurrence in %q. Expected d#n with n in 1-5", item)
+		}
+		return &dayRule{kind: nthDayOfWeek, value: weekday % 7, nth: occurrence}, nil
+	}
+	if strings.HasSuffix(item, "L") && item != "L" {
+		weekday, err := parseValue(strings.TrimSuffix(item, "L"), field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid day in %q. %v", item, err)
+		}
+		return &dayRule{kind: lastDayOfWeek, value: weekday % 7}, nil
+	}
+	if item == "L" {
+		return nil, fmt.Errorf("\"L\" should follow a day of week, e.g. 5L for the last Friday")
+	}
+	return nil, nil
+}
+
+// matches tells whether the day of the wall clock time satisfies the rule.
+func (rule dayRule) matches(wall time.Time) bool {
+	day := wall.Day()
+	lastDay := time.Date(wall.Year(), wall.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	switch rule.kind {
+	case lastDayOfMonth:
+		return day == lastDay-rule.value
+	case lastWeekdayOfMonth:
+		return day == nearestWeekday(wall, lastDay, lastDay)
+	case nearestWeekdayOfMonth:
+		return day == nearestWeekday(wall, min(rule.value, lastDay), lastDay)
+	case lastDayOfWeek:
+		return int(wall.Weekday()) == rule.value && day+7 > lastDay
+	case nthDayOfWeek:
+		return int(wall.Weekday()) == rule.value && (day-1)/7+1 == rule.nth
+	}
+	return false
+}
+
+// nearestWeekday returns the weekday (Monday to Friday) nearest to the day of the month of "wall",
+// without leaving the month.
+func nearestWeekday(wall time.Time, day int, lastDay int) int {
+	switch time.Date(wall.Year(), wall.Month(), day, 0, 0, 0, 0, time.UTC).Weekday() {
+	case time.Saturday:
+		if day == 1 {
+			return day + 2
+		}
+		return day - 1
+	case time.Sunday:
+		if day == lastDay {
+			return day - 2
+		}
+		return day + 1
+	}
+	return day
+}
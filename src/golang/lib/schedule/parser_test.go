@@ -0,0 +1,113 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParse checks the run times of the extended cron syntax. The times are in UTC.
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr  string
+		after string
+		want  []string
+	}{
+		{"@daily", "2026-01-01T10:00:00Z", []string{"2026-01-02T00:00:00Z", "2026-01-03T00:00:00Z"}},
+		{"@hourly", "2026-01-01T10:20:00Z", []string{"2026-01-01T11:00:00Z"}},
+		{"@every 90s", "2026-01-01T10:00:00.5Z", []string{"2026-01-01T10:01:30Z", "2026-01-01T10:03:00Z"}},
+		{"*/20 * * * * *", "2026-01-01T10:00:00Z", []string{"2026-01-01T10:00:20Z", "2026-01-01T10:00:40Z", "2026-01-01T10:01:00Z"}},
+		{"0 9 L * *", "2026-01-15T00:00:00Z", []string{"2026-01-31T09:00:00Z", "2026-02-28T09:00:00Z"}},
+		{"0 9 L-2 * *", "2026-02-01T00:00:00Z", []string{"2026-02-26T09:00:00Z", "2026-03-29T09:00:00Z"}},
+		// 31 January 2026 is a Saturday and 31 May 2026 is a Sunday.
+		{"0 9 LW * *", "2026-01-01T00:00:00Z", []string{"2026-01-30T09:00:00Z", "2026-02-27T09:00:00Z"}},
+		{"0 9 LW 5 *", "2026-01-01T00:00:00Z", []string{"2026-05-29T09:00:00Z"}},
+		// 1 August 2026 is a Saturday and 15 March 2026 is a Sunday.
+		{"0 9 1W 8 *", "2026-01-01T00:00:00Z", []string{"2026-08-03T09:00:00Z"}},
+		{"0 9 15W 3 *", "2026-01-01T00:00:00Z", []string{"2026-03-16T09:00:00Z"}},
+		{"0 9 * * 5L", "2026-01-01T00:00:00Z", []string{"2026-01-30T09:00:00Z", "2026-02-27T09:00:00Z"}},
+		{"0 9 ? * TUE#2", "2026-01-01T00:00:00Z", []string{"2026-01-13T09:00:00Z", "2026-02-10T09:00:00Z"}},
+		{"0 0 * JAN-FEB SUN,7", "2026-01-01T00:00:00Z", []string{"2026-01-04T00:00:00Z", "2026-01-11T00:00:00Z"}},
+		{"CRON_TZ=Asia/Kolkata @daily", "2026-01-01T00:00:00Z", []string{"2026-01-01T18:30:00Z"}},
+	}
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			schedule, err := Parse(test.expr)
+			if err != nil {
+				t.Fatalf("failed to parse %v: %v", test.expr, err)
+			}
+			current := mustParseTime(t, test.after)
+			for i, want := range test.want {
+				next := schedule.Next(current)
+				if !next.Equal(mustParseTime(t, want)) {
+					t.Fatalf("run #%v: got %v, want %v", i, next.UTC().Format(time.RFC3339), want)
+				}
+				current = next
+			}
+		})
+	}
+}
+
+// TestParseErrors checks that the errors name the bad field.
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"60 * * * *", "invalid minute field"},
+		{"* 24 * * *", "invalid hour field"},
+		{"* * 0 * *", "invalid day of month field"},
+		{"* * * FOO *", "invalid month field"},
+		{"* * * * MON-FOO", "invalid day of week field"},
+		{"* * * * 8#1", "invalid day of week field"},
+		{"* * * * 2#6", "invalid day of week field"},
+		{"* * * * L", "invalid day of week field"},
+		{"* * 32W * *", "invalid day of month field"},
+		{"*/0 * * * * *", "invalid second field"},
+		{"5-1 * * * *", "range 5-1 is backwards"},
+		{"* * * *", "expected 5 or 6 fields"},
+		{"@fortnightly", "unknown descriptor"},
+		{"@every 10ms", "at least 1s"},
+		{"@every soon", "invalid @every duration"},
+		{"TZ=Mars/Olympus * * * * *", "invalid time zone"},
+		{"TZ= 0 * * * *", "missing the time zone"},
+		{"CRON_TZ= @daily", "missing the time zone"},
+		{"CRON_TZ=", "missing the time zone"},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.expr)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Fatalf("%v: got the error %v, want it to contain %q", test.expr, err, test.want)
+		}
+	}
+}
+
+func TestSimpleSchedules(t *testing.T) {
+	at := mustParseTime(t, "2026-11-01T03:00:00Z")
+	once := &OnceSchedule{At: at}
+	if next := once.Next(at.Add(-time.Hour)); !next.Equal(at) {
+		t.Fatalf("once: got %v before the time, want %v", next, at)
+	}
+	if next := once.Next(at); !next.IsZero() {
+		t.Fatalf("once: got %v after the time, want no run", next)
+	}
+
+	rate := &RateSchedule{Start: at, Interval: 90 * time.Second}
+	tests := []struct {
+		after string
+		want  string
+	}{
+		{"2026-10-01T00:00:00Z", "2026-11-01T03:00:00Z"},
+		{"2026-11-01T03:00:00Z", "2026-11-01T03:01:30Z"},
+		{"2026-11-01T03:02:59Z", "2026-11-01T03:03:00Z"},
+	}
+	for _, test := range tests {
+		if next := rate.Next(mustParseTime(t, test.after)); !next.Equal(mustParseTime(t, test.want)) {
+			t.Fatalf("rate after %v: got %v, want %v", test.after, next, test.want)
+		}
+	}
+	epochRate := &RateSchedule{Interval: time.Hour}
+	if next := epochRate.Next(mustParseTime(t, "2026-11-01T03:20:00Z")); !next.Equal(mustParseTime(t, "2026-11-01T04:00:00Z")) {
+		t.Fatalf("rate without a start: got %v, want the next hour", next)
+	}
+}
@@ -0,0 +1,37 @@
+package schedule
+
+import "time"
+
+// OnceSchedule runs once at the given time.
+type OnceSchedule struct {
+	At time.Time
+}
+
+func (schedule *OnceSchedule) Next(after time.Time) (next time.Time) {
+	if schedule.At.After(after) {
+		return schedule.At.In(after.Location())
+	}
+	return time.Time{}
+}
+
+// RateSchedule runs at a fixed rate: Start, Start + Interval, Start + 2 * Interval and so on. The runs are
+// aligned to the Unix epoch if Start is zero, e.g. every 90 seconds since 1970-01-01T00:00:00Z.
+type RateSchedule struct {
+	Start    time.Time
+	Interval time.Duration
+}
+
+func (schedule *RateSchedule) Next(after time.Time) (next time.Time) {
+	if schedule.Interval <= 0 {
+		return time.Time{}
+	}
+	start := schedule.Start
+	if start.IsZero() {
+		start = time.Unix(0, 0)
+	}
+	if start.After(after) {
+		return start.In(after.Location())
+	}
+	elapsed := after.Sub(start)
+	return start.Add((elapsed/schedule.Interval + 1) * schedule.Interval).In(after.Location())
+}